@@ -0,0 +1,8 @@
+package domain
+
+// Captcha 图形验证码挑战，答案只保存在服务端
+type Captcha struct {
+	Id string
+	// Image base64 编码的 PNG，可以直接放在 img 标签的 src 里面
+	Image string
+}
@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// CodePolicy 某个业务的验证码策略，不同业务（登录、重置密码、绑定手机）可以不一样
+type CodePolicy struct {
+	Length   int    // 验证码长度
+	Alphabet string // 验证码可用的字符
+	// 验证码有效期
+	Expiration time.Duration
+	// 两次发送之间至少间隔多久
+	ResendInterval time.Duration
+	// 最多可以验证几次
+	MaxAttempts int
+}
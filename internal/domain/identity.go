@@ -0,0 +1,11 @@
+package domain
+
+// UserIdentity 第三方身份，例如 GitHub、Google 或者公司的 OIDC
+type UserIdentity struct {
+	// Provider 身份提供方的名字，例如 github
+	Provider string
+	// Subject 用户在身份提供方那边的唯一标识
+	Subject string
+	Email   string
+	Name    string
+}
@@ -0,0 +1,55 @@
+package domain
+
+import "time"
+
+// OAuth2State 第三方登录的 state，保存在服务端，只能用一次
+type OAuth2State struct {
+	// Provider 发起登录的提供方，防止拿 A 的 state 去 B 的回调
+	Provider string
+	// ReturnURL 登录成功之后跳回去的地址，可以为空
+	ReturnURL string
+}
+
+// OAuth2Client 接入 webBook 登录的应用
+type OAuth2Client struct {
+	ClientId string
+	// SecretHash bcrypt 之后的 secret，空的是公开客户端，例如 SPA 和 App，必须用 PKCE
+	SecretHash   string
+	Name         string
+	RedirectURIs []string
+	// Scopes 允许申请的 scope
+	Scopes     []string
+	GrantTypes []string
+}
+
+func (c OAuth2Client) Public() bool {
+	return c.SecretHash == ""
+}
+
+// OAuth2Consent 用户同意给某个应用的 scope
+type OAuth2Consent struct {
+	Uid      int64
+	ClientId string
+	Scopes   []string
+}
+
+// OAuth2AuthCode 授权码绑定的信息，保存在 Redis，只能用一次
+type OAuth2AuthCode struct {
+	ClientId    string
+	Uid         int64
+	RedirectURI string
+	Scopes      []string
+	// PKCE，只支持 S256
+	CodeChallenge string
+}
+
+// OAuth2Grant 一次授权的结果，web 层据此签发 access token
+type OAuth2Grant struct {
+	// Id 也就是 access token 的 jti，撤销的时候用
+	Id       string
+	ClientId string
+	// Uid client_credentials 没有用户，是 0
+	Uid       int64
+	Scopes    []string
+	ExpiresAt time.Time
+}
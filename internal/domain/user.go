@@ -8,9 +8,11 @@ type User struct {
 	Email      string    // 用户的电子邮件地址，用于登录和通信
 	Password   string    // 用户的密码，应该是加密存储的
 	Nickname   string    // 用户的昵称，可以是用户的非正式名称
+	Avatar     string    // 用户头像的 URL
 	Birthday   time.Time // 用户的生日，使用Go的time包中的Time类型表示日期和时间
 	AboutMe    string    // 用户自我介绍的文本
 	Phone      string    // 用户的电话号码
+	Locale     string    // 用户设置的语言，例如 en-US，为空就是跟着浏览器
 	Ctime      time.Time // 用户创建时间，记录用户账号的创建时间
 	WechatInfo WechatInfo
 }
@@ -2,5 +2,20 @@ package domain
 
 type WechatInfo struct {
 	UnionId string
-	OpenId  string
+	// OpenId 网站应用的 openid
+	OpenId string
+	// MiniOpenId 小程序的 openid，和网站应用的不一样，两边靠 UnionId 打通
+	MiniOpenId string
+	// Nickname 和 Avatar 来自微信的 sns/userinfo，只在创建用户的时候用
+	Nickname string
+	Avatar   string
+	// RefreshToken 明文，落库的时候加密，缓存和查询结果里面不会有
+	RefreshToken string
+}
+
+// WechatSession 小程序 jscode2session 的结果，SessionKey 只保存在服务端
+type WechatSession struct {
+	OpenId     string
+	UnionId    string
+	SessionKey string
 }
@@ -0,0 +1,70 @@
+package errs
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// Module 错误码的模块前缀，错误码是 模块 * 1000 + 序号，例如 201001 是用户模块的第一个错误。
+// 错误码一旦发布就不能修改含义，前端按照错误码判断
+type Module int
+
+const (
+	ModuleCommon  Module = 100
+	ModuleUser    Module = 201
+	ModuleCode    Module = 202 // 短信验证码
+	ModuleCaptcha Module = 203 // 图形验证码
+	ModuleOAuth2  Module = 204 // 第三方登录
+)
+
+// Code 业务错误码，同时也是一个 error，handler 可以直接返回
+type Code struct {
+	Code int
+	// HTTPStatus 对应的 HTTP 状态码
+	HTTPStatus int
+	// Msg 默认的提示信息
+	Msg string
+}
+
+func (c *Code) Error() string {
+	return fmt.Sprintf("%d: %s", c.Code, c.Msg)
+}
+
+// LegacyCode 老版本的客户端只认识 4（用户输入有问题）和 5（系统错误）
+func (c *Code) LegacyCode() int {
+	if c.HTTPStatus >= http.StatusInternalServerError {
+		return 5
+	}
+	return 4
+}
+
+var registry = map[int]*Code{}
+
+// register 只能在包初始化的时候调用，错误码重复直接 panic
+func register(m Module, seq int, status int, msg string) *Code {
+	c := &Code{Code: int(m)*1000 + seq, HTTPStatus: status, Msg: msg}
+	if _, ok := registry[c.Code]; ok {
+		panic(fmt.Sprintf("错误码 %d 重复了", c.Code))
+	}
+	registry[c.Code] = c
+	return c
+}
+
+// Lookup 根据错误码找到定义
+func Lookup(code int) (*Code, bool) {
+	c, ok := registry[code]
+	return c, ok
+}
+
+// All 所有的错误码，按照错误码排序，用来生成文档
+func All() []*Code {
+	res := make([]*Code, 0, len(registry))
+	for _, c := range registry {
+		res = append(res, c)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Code < res[j].Code
+	})
+	return res
+}
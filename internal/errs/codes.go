@@ -0,0 +1,47 @@
+package errs
+
+import "net/http"
+
+// 通用
+var (
+	SystemError  = register(ModuleCommon, 1, http.StatusInternalServerError, "系统错误")
+	InvalidParam = register(ModuleCommon, 2, http.StatusBadRequest, "参数错误")
+	Unauthorized = register(ModuleCommon, 3, http.StatusUnauthorized, "请先登录")
+)
+
+// 用户，2、3、6、7 已经改成参数校验，返回 InvalidParam，保留下来避免编号被复用
+var (
+	UserInvalidEmail      = register(ModuleUser, 1, http.StatusBadRequest, "非法邮箱格式")
+	UserPasswordMismatch  = register(ModuleUser, 2, http.StatusBadRequest, "两次输入密码不对")
+	UserWeakPassword      = register(ModuleUser, 3, http.StatusBadRequest, "密码必须包含字母、数字、特殊字符，并且不少于八位")
+	UserDuplicateEmail    = register(ModuleUser, 4, http.StatusConflict, "邮箱冲突，请换一个")
+	UserInvalidCredential = register(ModuleUser, 5, http.StatusBadRequest, "用户名或者密码不对")
+	UserInvalidBirthday   = register(ModuleUser, 6, http.StatusBadRequest, "生日格式不对")
+	UserPhoneRequired     = register(ModuleUser, 7, http.StatusBadRequest, "请输入手机号码")
+	UserInvalidPhone      = register(ModuleUser, 8, http.StatusBadRequest, "手机号码格式不对")
+	UserPhoneNotSupported = register(ModuleUser, 9, http.StatusBadRequest, "暂不支持该地区的手机号码")
+)
+
+// 短信验证码
+var (
+	CodeInvalid        = register(ModuleCode, 1, http.StatusBadRequest, "验证码不对，请重新输入")
+	CodeSendTooMany    = register(ModuleCode, 2, http.StatusTooManyRequests, "短信发送太频繁，请稍后再试")
+	CodeQuotaExhausted = register(ModuleCode, 3, http.StatusTooManyRequests, "今天发送验证码的次数太多了，请明天再试")
+)
+
+// 图形验证码
+var (
+	CaptchaInvalid = register(ModuleCaptcha, 1, http.StatusBadRequest, "图形验证码不对，请重新输入")
+)
+
+// 第三方登录
+var (
+	OAuth2UnsupportedProvider = register(ModuleOAuth2, 1, http.StatusNotFound, "不支持的登录方式")
+	OAuth2InvalidState        = register(ModuleOAuth2, 2, http.StatusBadRequest, "非法请求")
+	OAuth2InvalidCode         = register(ModuleOAuth2, 3, http.StatusBadRequest, "授权码有误")
+	OAuth2ReturnURLNotAllowed = register(ModuleOAuth2, 4, http.StatusBadRequest, "跳转地址不合法")
+	OAuth2SessionExpired      = register(ModuleOAuth2, 5, http.StatusUnauthorized, "登录已过期，请重新登录")
+	OAuth2InvalidPhone        = register(ModuleOAuth2, 6, http.StatusBadRequest, "手机号码有误")
+	OAuth2InvalidClient       = register(ModuleOAuth2, 7, http.StatusBadRequest, "应用不存在")
+	OAuth2InvalidRedirectURI  = register(ModuleOAuth2, 8, http.StatusBadRequest, "跳转地址和应用注册的不一致")
+)
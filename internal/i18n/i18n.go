@@ -0,0 +1,116 @@
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/text/language"
+	"strconv"
+	"webBook/internal/errs"
+)
+
+var (
+	ZhCN = language.MustParse("zh-CN")
+	EnUS = language.MustParse("en-US")
+	// Supported 支持的语言，第一个是默认的
+	Supported = []language.Tag{ZhCN, EnUS}
+)
+
+// 非错误的提示信息，错误信息直接用错误码当 key
+const (
+	MsgOK       = "ok"
+	MsgLoginOK  = "login_ok"
+	MsgLogoutOK = "logout_ok"
+	MsgSignupOK = "signup_ok"
+	MsgEditOK   = "edit_ok"
+	MsgCodeSent = "code_sent"
+)
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+type catalog struct {
+	Codes    map[string]string `json:"codes"`
+	Messages map[string]string `json:"messages"`
+}
+
+var (
+	catalogs = make(map[language.Tag]catalog, len(Supported))
+	matcher  = language.NewMatcher(Supported)
+)
+
+func init() {
+	for _, tag := range Supported {
+		data, err := localeFS.ReadFile(fmt.Sprintf("locales/%s.json", tag))
+		if err != nil {
+			panic(err)
+		}
+		var c catalog
+		if err = json.Unmarshal(data, &c); err != nil {
+			panic(fmt.Errorf("语言包 %s 格式不对 %w", tag, err))
+		}
+		catalogs[tag] = c
+	}
+}
+
+// Negotiate 选出响应用的语言，用户在个人资料里面设置的优先，其次是 Accept-Language，
+// 都匹配不上就用默认的
+func Negotiate(preference, acceptLanguage string) language.Tag {
+	if preference != "" {
+		if tag, err := language.Parse(preference); err == nil {
+			if res, conf := match(tag); conf != language.No {
+				return res
+			}
+		}
+	}
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Supported[0]
+	}
+	res, _ := match(tags...)
+	return res
+}
+
+// match 返回 Supported 里面的 tag，而不是 matcher 带了 -u-rg 之类扩展的 tag。
+// 完全匹配不上的时候 matcher 不一定返回第一个，这里统一用默认的
+func match(tags ...language.Tag) (language.Tag, language.Confidence) {
+	_, idx, conf := matcher.Match(tags...)
+	if conf == language.No {
+		return Supported[0], conf
+	}
+	return Supported[idx], conf
+}
+
+type localeKey struct{}
+
+func WithLocale(ctx context.Context, tag language.Tag) context.Context {
+	return context.WithValue(ctx, localeKey{}, tag)
+}
+
+// FromContext 没有经过语言中间件的 ctx 返回默认的语言
+func FromContext(ctx context.Context) language.Tag {
+	if tag, ok := ctx.Value(localeKey{}).(language.Tag); ok {
+		return tag
+	}
+	return Supported[0]
+}
+
+// CodeMsg 错误码在 ctx 对应语言里面的提示，语言包里面没有的用错误码自带的
+func CodeMsg(ctx context.Context, code *errs.Code) string {
+	if msg, ok := catalogs[FromContext(ctx)].Codes[strconv.Itoa(code.Code)]; ok {
+		return msg
+	}
+	return code.Msg
+}
+
+// T 提示信息在 ctx 对应语言里面的翻译，语言包里面没有的用默认语言，还是没有就返回 key
+func T(ctx context.Context, key string) string {
+	if msg, ok := catalogs[FromContext(ctx)].Messages[key]; ok {
+		return msg
+	}
+	if msg, ok := catalogs[Supported[0]].Messages[key]; ok {
+		return msg
+	}
+	return key
+}
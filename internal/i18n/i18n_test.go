@@ -0,0 +1,86 @@
+package i18n
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+	"strconv"
+	"testing"
+	"webBook/internal/errs"
+)
+
+func TestNegotiate(t *testing.T) {
+	testCases := []struct {
+		name           string
+		preference     string
+		acceptLanguage string
+
+		want language.Tag
+	}{
+		{
+			name: "什么都没有",
+			want: ZhCN,
+		},
+		{
+			name:           "Accept-Language",
+			acceptLanguage: "en-US,en;q=0.9,zh-CN;q=0.8",
+			want:           EnUS,
+		},
+		{
+			name:           "Accept-Language 地区不一样",
+			acceptLanguage: "en-GB",
+			want:           EnUS,
+		},
+		{
+			name:           "Accept-Language 不支持",
+			acceptLanguage: "fr",
+			want:           ZhCN,
+		},
+		{
+			name:           "Accept-Language 后面的支持",
+			acceptLanguage: "fr, en;q=0.5",
+			want:           EnUS,
+		},
+		{
+			name:           "个人设置优先",
+			preference:     "en-US",
+			acceptLanguage: "zh-CN",
+			want:           EnUS,
+		},
+		{
+			name:           "个人设置不支持",
+			preference:     "fr",
+			acceptLanguage: "en-US",
+			want:           EnUS,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, Negotiate(tc.preference, tc.acceptLanguage))
+		})
+	}
+}
+
+// TestCatalog 所有的错误码和提示信息在每个语言包里面都要有
+func TestCatalog(t *testing.T) {
+	keys := []string{MsgOK, MsgLoginOK, MsgLogoutOK, MsgSignupOK, MsgEditOK, MsgCodeSent}
+	for _, tag := range Supported {
+		c := catalogs[tag]
+		for _, code := range errs.All() {
+			assert.NotEmpty(t, c.Codes[strconv.Itoa(code.Code)], "%s 缺少错误码 %d", tag, code.Code)
+		}
+		for _, key := range keys {
+			assert.NotEmpty(t, c.Messages[key], "%s 缺少提示信息 %s", tag, key)
+		}
+		assert.Len(t, c.Messages, len(keys), "%s 有多余的提示信息", tag)
+	}
+}
+
+func TestCodeMsg(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "验证码不对，请重新输入", CodeMsg(ctx, errs.CodeInvalid))
+	assert.Equal(t, "Incorrect verification code, please try again",
+		CodeMsg(WithLocale(ctx, EnUS), errs.CodeInvalid))
+	assert.Equal(t, "Signed up", T(WithLocale(ctx, EnUS), MsgSignupOK))
+	assert.Equal(t, "unknown", T(ctx, "unknown"))
+}
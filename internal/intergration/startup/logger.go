@@ -0,0 +1,8 @@
+package startup
+
+import "webBook/pkg/logger"
+
+// InitLogger 测试不需要日志
+func InitLogger() logger.LoggerV1 {
+	return logger.NewNopLogger()
+}
@@ -2,10 +2,13 @@ package startup
 
 import (
 	"github.com/redis/go-redis/v9"
+	"webBook/pkg/redisx"
 )
 
 func InitRedis() redis.Cmdable {
-	return redis.NewClient(&redis.Options{
+	client := redis.NewClient(&redis.Options{
 		Addr: "localhost:6379",
 	})
+	client.AddHook(redisx.NewTracingHook())
+	return client
 }
@@ -8,7 +8,6 @@ import (
 	"webBook/internal/repository"
 	"webBook/internal/repository/cache"
 	"webBook/internal/repository/dao"
-	"webBook/internal/service"
 	"webBook/internal/web"
 	"webBook/ioc"
 )
@@ -17,24 +16,49 @@ func InitWebServer() *gin.Engine {
 	wire.Build(
 		// 第三方依赖
 		InitRedis, ioc.InitDB,
+		InitLogger, ioc.InitTokenCipher,
 		// DAO 部分
-		dao.NewUserDAO,
+		dao.NewUserDAO, dao.NewUserIdentityDAO, dao.NewOAuth2ClientDAO,
 
 		// cache 部分
-		cache.NewCodeCache, cache.NewUserCache,
+		cache.NewCodeCache, cache.NewCaptchaCache, cache.NewWechatSessionCache, cache.NewOAuth2StateCache,
+		cache.NewOAuth2TokenCache,
 
 		// repository 部分
 		repository.NewCachedUserRepository,
 		repository.NewCodeRepository,
+		repository.NewUserIdentityRepository,
+		repository.NewCaptchaRepository,
+		repository.NewWechatSessionRepository,
+		repository.NewOAuth2StateRepository,
+		repository.NewOAuth2ClientRepository,
+		repository.NewOAuth2TokenRepository,
 
 		// Service 部分
 		ioc.InitSMSService,
-		service.NewUserService,
-		service.NewCodeService,
+		ioc.InitAuthSMSService,
+		ioc.InitWechatService,
+		ioc.InitMiniProgramService,
+		ioc.InitOAuth2Providers,
+		ioc.InitOAuth2StateService,
+		ioc.InitOAuth2ServerService,
+		ioc.InitUserService,
+		ioc.InitUserCache,
+		ioc.InitCodeService,
+		ioc.InitCaptchaService,
+		ioc.InitCaptchaVerifier,
 
 		// handler 部分
+		ioc.InitPhoneParser,
 		web.NewUserHandler,
-
+		ioc.InitJWTHandler,
+		web.NewOAuth2WechatHandler,
+		web.NewMiniProgramHandler,
+		web.NewOAuth2Handler,
+		web.NewOAuth2ServerHandler,
+		ioc.InitStateCookieConfig,
+		web.NewCaptchaHandler,
+		ioc.InitConcurrencyLimiter,
 		ioc.InitGinMiddlewares,
 		ioc.InitWebServer,
 	)
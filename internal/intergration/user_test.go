@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
@@ -21,6 +22,9 @@ func init() {
 
 func init() {
 	gin.SetMode(gin.ReleaseMode)
+	// 签名 key 都没有默认值
+	viper.Set("sms.auth.key", "intergration-test-key")
+	viper.Set("oauth2.server.jwtKey", "intergration-test-key")
 }
 
 func TestUserHandler_SendSMSCode(t *testing.T) {
@@ -45,7 +49,7 @@ func TestUserHandler_SendSMSCode(t *testing.T) {
 			after: func(t *testing.T) {
 				ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 				defer cancel()
-				key := "phone_code:login:15212345678"
+				key := "phone_code:login:+8615212345678"
 				code, err := rdb.Get(ctx, key).Result()
 				assert.NoError(t, err)
 				assert.True(t, len(code) > 0)
@@ -70,7 +74,10 @@ func TestUserHandler_SendSMSCode(t *testing.T) {
 			wantCode: http.StatusOK,
 			wantBody: web.Result{
 				Code: 4,
-				Msg:  "请输入手机号码",
+				Msg:  "参数错误",
+				Data: []any{
+					map[string]any{"field": "phone", "msg": "phone为必填字段"},
+				},
 			},
 		},
 		{
@@ -78,14 +85,14 @@ func TestUserHandler_SendSMSCode(t *testing.T) {
 			before: func(t *testing.T) {
 				ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 				defer cancel()
-				key := "phone_code:login:15212345678"
+				key := "phone_code:login:+8615212345678"
 				err := rdb.Set(ctx, key, "123456", time.Minute*9+time.Second*50).Err()
 				assert.NoError(t, err)
 			},
 			after: func(t *testing.T) {
 				ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 				defer cancel()
-				key := "phone_code:login:15212345678"
+				key := "phone_code:login:+8615212345678"
 				code, err := rdb.GetDel(ctx, key).Result()
 				assert.NoError(t, err)
 				assert.Equal(t, "123456", code)
@@ -102,14 +109,14 @@ func TestUserHandler_SendSMSCode(t *testing.T) {
 			before: func(t *testing.T) {
 				ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 				defer cancel()
-				key := "phone_code:login:15212345678"
+				key := "phone_code:login:+8615212345678"
 				err := rdb.Set(ctx, key, "123456", 0).Err()
 				assert.NoError(t, err)
 			},
 			after: func(t *testing.T) {
 				ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 				defer cancel()
-				key := "phone_code:login:15212345678"
+				key := "phone_code:login:+8615212345678"
 				code, err := rdb.GetDel(ctx, key).Result()
 				assert.NoError(t, err)
 				assert.Equal(t, "123456", code)
@@ -127,11 +134,16 @@ func TestUserHandler_SendSMSCode(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			tc.before(t)
 			defer tc.after(t)
+			// 先准备好图形验证码
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+			err := rdb.Set(ctx, "captcha:test-captcha", "42", time.Minute).Err()
+			cancel()
+			assert.NoError(t, err)
 
 			// 准备Req和记录的 recorder
 			req, err := http.NewRequest(http.MethodPost,
 				"/users/login_sms/code/send",
-				bytes.NewReader([]byte(fmt.Sprintf(`{"phone": "%s"}`, tc.phone))))
+				bytes.NewReader([]byte(fmt.Sprintf(`{"phone": "%s", "captchaId": "test-captcha", "captcha": "42"}`, tc.phone))))
 			req.Header.Set("Content-Type", "application/json")
 			assert.NoError(t, err)
 			recorder := httptest.NewRecorder()
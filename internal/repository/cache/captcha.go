@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+//go:embed lua/incr_failure.lua
+var luaIncrFailure string
+
+type CaptchaCache interface {
+	Set(ctx context.Context, id, answer string, expiration time.Duration) error
+	// GetDel 取出答案并删除，也就是一个挑战只能用一次
+	GetDel(ctx context.Context, id string) (string, error)
+	IncrFailure(ctx context.Context, biz, key string, expiration time.Duration) (int64, error)
+	GetFailure(ctx context.Context, biz, key string) (int64, error)
+	DelFailure(ctx context.Context, biz, key string) error
+}
+
+type RedisCaptchaCache struct {
+	cmd redis.Cmdable
+}
+
+func NewCaptchaCache(cmd redis.Cmdable) CaptchaCache {
+	return &RedisCaptchaCache{
+		cmd: cmd,
+	}
+}
+
+// Set 方法，保存挑战的答案。
+func (c *RedisCaptchaCache) Set(ctx context.Context, id, answer string, expiration time.Duration) error {
+	return c.cmd.Set(ctx, c.key(id), answer, expiration).Err()
+}
+
+// GetDel 方法，取出答案并删除，不存在的时候返回 ErrKeyNotExist。
+func (c *RedisCaptchaCache) GetDel(ctx context.Context, id string) (string, error) {
+	return c.cmd.GetDel(ctx, c.key(id)).Result()
+}
+
+// IncrFailure 方法，记录一次失败，第一次失败的时候设置过期时间，用 Lua 脚本保证两步一起执行。
+func (c *RedisCaptchaCache) IncrFailure(ctx context.Context, biz, key string, expiration time.Duration) (int64, error) {
+	return c.cmd.Eval(ctx, luaIncrFailure, []string{c.failureKey(biz, key)},
+		int64(expiration.Seconds())).Int64()
+}
+
+// GetFailure 方法，获取失败次数，没有记录就是 0。
+func (c *RedisCaptchaCache) GetFailure(ctx context.Context, biz, key string) (int64, error) {
+	cnt, err := c.cmd.Get(ctx, c.failureKey(biz, key)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return cnt, err
+}
+
+// DelFailure 方法，清除失败次数。
+func (c *RedisCaptchaCache) DelFailure(ctx context.Context, biz, key string) error {
+	return c.cmd.Del(ctx, c.failureKey(biz, key)).Err()
+}
+
+func (c *RedisCaptchaCache) key(id string) string {
+	return fmt.Sprintf("captcha:%s", id)
+}
+
+func (c *RedisCaptchaCache) failureKey(biz, key string) string {
+	return fmt.Sprintf("captcha:fail:%s:%s", biz, key)
+}
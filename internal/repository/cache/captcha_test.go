@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"webBook/internal/repository/cache/redismocks"
+)
+
+func TestRedisCaptchaCache_IncrFailure(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) redis.Cmdable
+
+		wantCnt int64
+		wantErr error
+	}{
+		{
+			name: "记录成功",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				res := redismocks.NewMockCmdable(ctrl)
+				cmd := redis.NewCmd(context.Background())
+				cmd.SetVal(int64(1))
+				// 计数和过期时间在同一个脚本里面设置
+				res.EXPECT().Eval(gomock.Any(), luaIncrFailure,
+					[]string{"captcha:fail:login:a@qq.com"},
+					[]any{int64(900)}).Return(cmd)
+				return res
+			},
+			wantCnt: 1,
+		},
+		{
+			name: "redis返回error",
+			mock: func(ctrl *gomock.Controller) redis.Cmdable {
+				res := redismocks.NewMockCmdable(ctrl)
+				cmd := redis.NewCmd(context.Background())
+				cmd.SetErr(errors.New("redis错误"))
+				res.EXPECT().Eval(gomock.Any(), luaIncrFailure,
+					[]string{"captcha:fail:login:a@qq.com"},
+					[]any{int64(900)}).Return(cmd)
+				return res
+			},
+			wantErr: errors.New("redis错误"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			c := NewCaptchaCache(tc.mock(ctrl))
+			cnt, err := c.IncrFailure(context.Background(), "login", "a@qq.com", time.Minute*15)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantCnt, cnt)
+		})
+	}
+}
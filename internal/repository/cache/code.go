@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/redis/go-redis/v9"
+	"webBook/internal/domain"
 )
 
 var (
@@ -19,7 +20,7 @@ var (
 )
 
 type CodeCache interface {
-	Set(ctx context.Context, biz, phone, code string) error
+	Set(ctx context.Context, biz, phone, code string, policy domain.CodePolicy) error
 	Verify(ctx context.Context, biz, phone, code string) (bool, error)
 }
 
@@ -35,9 +36,10 @@ func NewCodeCache(cmd redis.Cmdable) CodeCache {
 }
 
 // Set 方法用于设置验证码。
-func (c *RedisCodeCache) Set(ctx context.Context, biz, phone, code string) error {
-	// 使用Lua脚本和提供的参数设置验证码。
-	res, err := c.cmd.Eval(ctx, luaSetCode, []string{c.key(biz, phone)}, code).Int()
+func (c *RedisCodeCache) Set(ctx context.Context, biz, phone, code string, policy domain.CodePolicy) error {
+	// 使用Lua脚本和提供的参数设置验证码，有效期、重发间隔和验证次数由业务的策略决定。
+	res, err := c.cmd.Eval(ctx, luaSetCode, []string{c.key(biz, phone)}, code,
+		int64(policy.Expiration.Seconds()), int64(policy.ResendInterval.Seconds()), policy.MaxAttempts).Int()
 	if err != nil {
 		// 如果执行Redis命令出错，则返回错误。
 		return err
@@ -106,7 +106,7 @@ func TestRedisCodeCache_Set_e2e(t *testing.T) {
 			tc.before(t)
 			defer tc.after(t)
 			c := NewCodeCache(rdb)
-			err := c.Set(tc.ctx, tc.biz, tc.phone, tc.code)
+			err := c.Set(tc.ctx, tc.biz, tc.phone, tc.code, testPolicy)
 			assert.Equal(t, tc.wantErr, err)
 		})
 	}
@@ -8,8 +8,19 @@ import (
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
+	"webBook/internal/domain"
+	"webBook/internal/repository/cache/redismocks"
 )
 
+var testPolicy = domain.CodePolicy{
+	Length:         6,
+	Alphabet:       "0123456789",
+	Expiration:     time.Minute * 10,
+	ResendInterval: time.Minute,
+	MaxAttempts:    3,
+}
+
 func TestRedisCodeCache_Set(t *testing.T) {
 	keyFunc := func(biz, phone string) string {
 		return fmt.Sprintf("phone_code:%s:%s", biz, phone)
@@ -33,7 +44,7 @@ func TestRedisCodeCache_Set(t *testing.T) {
 				cmd.SetVal(int64(0))
 				res.EXPECT().Eval(gomock.Any(), luaSetCode,
 					[]string{keyFunc("test", "15212345678")},
-					[]any{"123456"}).Return(cmd)
+					[]any{"123456", int64(600), int64(60), 3}).Return(cmd)
 				return res
 			},
 			ctx:     context.Background(),
@@ -51,7 +62,7 @@ func TestRedisCodeCache_Set(t *testing.T) {
 				cmd.SetErr(errors.New("redis错误"))
 				res.EXPECT().Eval(gomock.Any(), luaSetCode,
 					[]string{keyFunc("test", "15212345678")},
-					[]any{"123456"}).Return(cmd)
+					[]any{"123456", int64(600), int64(60), 3}).Return(cmd)
 				return res
 			},
 			ctx:     context.Background(),
@@ -68,7 +79,7 @@ func TestRedisCodeCache_Set(t *testing.T) {
 				cmd.SetVal(int64(-2))
 				res.EXPECT().Eval(gomock.Any(), luaSetCode,
 					[]string{keyFunc("test", "15212345678")},
-					[]any{"123456"}).Return(cmd)
+					[]any{"123456", int64(600), int64(60), 3}).Return(cmd)
 				return res
 			},
 			ctx:     context.Background(),
@@ -85,7 +96,7 @@ func TestRedisCodeCache_Set(t *testing.T) {
 				cmd.SetVal(int64(-1))
 				res.EXPECT().Eval(gomock.Any(), luaSetCode,
 					[]string{keyFunc("test", "15212345678")},
-					[]any{"123456"}).Return(cmd)
+					[]any{"123456", int64(600), int64(60), 3}).Return(cmd)
 				return res
 			},
 			ctx:     context.Background(),
@@ -101,7 +112,7 @@ func TestRedisCodeCache_Set(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 			c := NewCodeCache(tc.mock(ctrl))
-			err := c.Set(tc.ctx, tc.biz, tc.phone, tc.code)
+			err := c.Set(tc.ctx, tc.biz, tc.phone, tc.code, testPolicy)
 			assert.Equal(t, tc.wantErr, err)
 		})
 	}
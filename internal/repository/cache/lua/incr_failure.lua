@@ -0,0 +1,11 @@
+-- 失败次数的 key
+local key = KEYS[1]
+-- 有效期，单位秒
+local expiration = tonumber(ARGV[1])
+
+local cnt = redis.call("incr", key)
+if cnt == 1 then
+    -- 第一次失败，和 incr 一起设置过期时间，不会出现永不过期的计数
+    redis.call("expire", key, expiration)
+end
+return cnt
@@ -2,20 +2,25 @@ local key = KEYS[1]
 local cntKey = key..":cnt"
 -- 你准备的存储的验证码
 local val = ARGV[1]
+-- 有效期，单位秒
+local expiration = tonumber(ARGV[2])
+-- 重发间隔，单位秒
+local interval = tonumber(ARGV[3])
+-- 最多验证几次
+local maxAttempts = tonumber(ARGV[4])
 
 local ttl = tonumber(redis.call("ttl", key))
 if ttl == -1 then
     --    key 存在，但是没有过期时间
     return -2
-elseif ttl == -2 or ttl < 540 then
+elseif ttl == -2 or ttl < expiration - interval then
     --    可以发验证码
     redis.call("set", key, val)
-    -- 600 秒
-    redis.call("expire", key, 600)
-    redis.call("set", cntKey, 3)
-    redis.call("expire", cntKey, 600)
+    redis.call("expire", key, expiration)
+    redis.call("set", cntKey, maxAttempts)
+    redis.call("expire", cntKey, expiration)
     return 0
 else
     -- 发送太频繁
     return -1
-end
\ No newline at end of file
+end
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"webBook/internal/domain"
+	"webBook/internal/repository/cache"
+	"webBook/pkg/prometheusx"
+)
+
+var _ cache.UserCache = &UserCache{}
+
+// UserCache 统计用户缓存的命中率：
+// sum(rate(webook_cache_requests_total{cache="user",op="get",result="hit"}[5m]))
+// / sum(rate(webook_cache_requests_total{cache="user",op="get"}[5m]))
+type UserCache struct {
+	cache    cache.UserCache
+	requests *prometheus.CounterVec
+}
+
+func NewUserCache(c cache.UserCache) cache.UserCache {
+	return &UserCache{
+		cache: c,
+		requests: prometheusx.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "webook",
+			Subsystem: "cache",
+			Name:      "requests_total",
+			Help:      "缓存的访问次数",
+		}, []string{"cache", "op", "result"})),
+	}
+}
+
+func (c *UserCache) Get(ctx context.Context, uid int64) (domain.User, error) {
+	u, err := c.cache.Get(ctx, uid)
+	result := "hit"
+	switch {
+	case errors.Is(err, cache.ErrKeyNotExist):
+		result = "miss"
+	case err != nil:
+		result = "error"
+	}
+	c.requests.WithLabelValues("user", "get", result).Inc()
+	return u, err
+}
+
+func (c *UserCache) Set(ctx context.Context, du domain.User) error {
+	err := c.cache.Set(ctx, du)
+	c.requests.WithLabelValues("user", "set", result(err)).Inc()
+	return err
+}
+
+func (c *UserCache) Del(ctx context.Context, uid int64) error {
+	err := c.cache.Del(ctx, uid)
+	c.requests.WithLabelValues("user", "del", result(err)).Inc()
+	return err
+}
+
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"webBook/internal/domain"
+	"webBook/internal/repository/cache"
+	cachemocks "webBook/internal/repository/cache/mocks"
+)
+
+func TestUserCache_Get(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	uc := cachemocks.NewMockUserCache(ctrl)
+	uc.EXPECT().Get(gomock.Any(), int64(1)).Return(domain.User{Id: 1}, nil).Times(2)
+	uc.EXPECT().Get(gomock.Any(), int64(2)).Return(domain.User{}, cache.ErrKeyNotExist)
+	uc.EXPECT().Get(gomock.Any(), int64(3)).Return(domain.User{}, errors.New("redis 出错"))
+
+	c := NewUserCache(uc).(*UserCache)
+	for _, uid := range []int64{1, 1, 2, 3} {
+		_, _ = c.Get(context.Background(), uid)
+	}
+	assert.Equal(t, float64(2), testutil.ToFloat64(c.requests.WithLabelValues("user", "get", "hit")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(c.requests.WithLabelValues("user", "get", "miss")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(c.requests.WithLabelValues("user", "get", "error")))
+}
@@ -0,0 +1,109 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/repository/cache/captcha.go
+
+// Package cachemocks is a generated GoMock package.
+package cachemocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCaptchaCache is a mock of CaptchaCache interface.
+type MockCaptchaCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockCaptchaCacheMockRecorder
+}
+
+// MockCaptchaCacheMockRecorder is the mock recorder for MockCaptchaCache.
+type MockCaptchaCacheMockRecorder struct {
+	mock *MockCaptchaCache
+}
+
+// NewMockCaptchaCache creates a new mock instance.
+func NewMockCaptchaCache(ctrl *gomock.Controller) *MockCaptchaCache {
+	mock := &MockCaptchaCache{ctrl: ctrl}
+	mock.recorder = &MockCaptchaCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCaptchaCache) EXPECT() *MockCaptchaCacheMockRecorder {
+	return m.recorder
+}
+
+// DelFailure mocks base method.
+func (m *MockCaptchaCache) DelFailure(ctx context.Context, biz, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DelFailure", ctx, biz, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DelFailure indicates an expected call of DelFailure.
+func (mr *MockCaptchaCacheMockRecorder) DelFailure(ctx, biz, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DelFailure", reflect.TypeOf((*MockCaptchaCache)(nil).DelFailure), ctx, biz, key)
+}
+
+// GetDel mocks base method.
+func (m *MockCaptchaCache) GetDel(ctx context.Context, id string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDel", ctx, id)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDel indicates an expected call of GetDel.
+func (mr *MockCaptchaCacheMockRecorder) GetDel(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDel", reflect.TypeOf((*MockCaptchaCache)(nil).GetDel), ctx, id)
+}
+
+// GetFailure mocks base method.
+func (m *MockCaptchaCache) GetFailure(ctx context.Context, biz, key string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFailure", ctx, biz, key)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFailure indicates an expected call of GetFailure.
+func (mr *MockCaptchaCacheMockRecorder) GetFailure(ctx, biz, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFailure", reflect.TypeOf((*MockCaptchaCache)(nil).GetFailure), ctx, biz, key)
+}
+
+// IncrFailure mocks base method.
+func (m *MockCaptchaCache) IncrFailure(ctx context.Context, biz, key string, expiration time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrFailure", ctx, biz, key, expiration)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrFailure indicates an expected call of IncrFailure.
+func (mr *MockCaptchaCacheMockRecorder) IncrFailure(ctx, biz, key, expiration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrFailure", reflect.TypeOf((*MockCaptchaCache)(nil).IncrFailure), ctx, biz, key, expiration)
+}
+
+// Set mocks base method.
+func (m *MockCaptchaCache) Set(ctx context.Context, id, answer string, expiration time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", ctx, id, answer, expiration)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockCaptchaCacheMockRecorder) Set(ctx, id, answer, expiration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockCaptchaCache)(nil).Set), ctx, id, answer, expiration)
+}
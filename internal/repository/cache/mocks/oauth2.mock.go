@@ -0,0 +1,147 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/repository/cache/oauth2.go
+
+// Package cachemocks is a generated GoMock package.
+package cachemocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+	domain "webBook/internal/domain"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockOAuth2StateCache is a mock of OAuth2StateCache interface.
+type MockOAuth2StateCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuth2StateCacheMockRecorder
+}
+
+// MockOAuth2StateCacheMockRecorder is the mock recorder for MockOAuth2StateCache.
+type MockOAuth2StateCacheMockRecorder struct {
+	mock *MockOAuth2StateCache
+}
+
+// NewMockOAuth2StateCache creates a new mock instance.
+func NewMockOAuth2StateCache(ctrl *gomock.Controller) *MockOAuth2StateCache {
+	mock := &MockOAuth2StateCache{ctrl: ctrl}
+	mock.recorder = &MockOAuth2StateCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuth2StateCache) EXPECT() *MockOAuth2StateCacheMockRecorder {
+	return m.recorder
+}
+
+// GetDel mocks base method.
+func (m *MockOAuth2StateCache) GetDel(ctx context.Context, state string) (domain.OAuth2State, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDel", ctx, state)
+	ret0, _ := ret[0].(domain.OAuth2State)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDel indicates an expected call of GetDel.
+func (mr *MockOAuth2StateCacheMockRecorder) GetDel(ctx, state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDel", reflect.TypeOf((*MockOAuth2StateCache)(nil).GetDel), ctx, state)
+}
+
+// Set mocks base method.
+func (m *MockOAuth2StateCache) Set(ctx context.Context, state string, s domain.OAuth2State, expiration time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", ctx, state, s, expiration)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockOAuth2StateCacheMockRecorder) Set(ctx, state, s, expiration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockOAuth2StateCache)(nil).Set), ctx, state, s, expiration)
+}
+
+// MockOAuth2TokenCache is a mock of OAuth2TokenCache interface.
+type MockOAuth2TokenCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuth2TokenCacheMockRecorder
+}
+
+// MockOAuth2TokenCacheMockRecorder is the mock recorder for MockOAuth2TokenCache.
+type MockOAuth2TokenCacheMockRecorder struct {
+	mock *MockOAuth2TokenCache
+}
+
+// NewMockOAuth2TokenCache creates a new mock instance.
+func NewMockOAuth2TokenCache(ctrl *gomock.Controller) *MockOAuth2TokenCache {
+	mock := &MockOAuth2TokenCache{ctrl: ctrl}
+	mock.recorder = &MockOAuth2TokenCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuth2TokenCache) EXPECT() *MockOAuth2TokenCacheMockRecorder {
+	return m.recorder
+}
+
+// GetDelCode mocks base method.
+func (m *MockOAuth2TokenCache) GetDelCode(ctx context.Context, code string) (domain.OAuth2AuthCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDelCode", ctx, code)
+	ret0, _ := ret[0].(domain.OAuth2AuthCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDelCode indicates an expected call of GetDelCode.
+func (mr *MockOAuth2TokenCacheMockRecorder) GetDelCode(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDelCode", reflect.TypeOf((*MockOAuth2TokenCache)(nil).GetDelCode), ctx, code)
+}
+
+// IsRevoked mocks base method.
+func (m *MockOAuth2TokenCache) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsRevoked", ctx, jti)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsRevoked indicates an expected call of IsRevoked.
+func (mr *MockOAuth2TokenCacheMockRecorder) IsRevoked(ctx, jti interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRevoked", reflect.TypeOf((*MockOAuth2TokenCache)(nil).IsRevoked), ctx, jti)
+}
+
+// Revoke mocks base method.
+func (m *MockOAuth2TokenCache) Revoke(ctx context.Context, jti string, expiration time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, jti, expiration)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockOAuth2TokenCacheMockRecorder) Revoke(ctx, jti, expiration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockOAuth2TokenCache)(nil).Revoke), ctx, jti, expiration)
+}
+
+// SetCode mocks base method.
+func (m *MockOAuth2TokenCache) SetCode(ctx context.Context, code string, c domain.OAuth2AuthCode, expiration time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCode", ctx, code, c, expiration)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCode indicates an expected call of SetCode.
+func (mr *MockOAuth2TokenCacheMockRecorder) SetCode(ctx, code, c, expiration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCode", reflect.TypeOf((*MockOAuth2TokenCache)(nil).SetCode), ctx, code, c, expiration)
+}
@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/repository/cache/wechat.go
+
+// Package cachemocks is a generated GoMock package.
+package cachemocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+	domain "webBook/internal/domain"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockWechatSessionCache is a mock of WechatSessionCache interface.
+type MockWechatSessionCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockWechatSessionCacheMockRecorder
+}
+
+// MockWechatSessionCacheMockRecorder is the mock recorder for MockWechatSessionCache.
+type MockWechatSessionCacheMockRecorder struct {
+	mock *MockWechatSessionCache
+}
+
+// NewMockWechatSessionCache creates a new mock instance.
+func NewMockWechatSessionCache(ctrl *gomock.Controller) *MockWechatSessionCache {
+	mock := &MockWechatSessionCache{ctrl: ctrl}
+	mock.recorder = &MockWechatSessionCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWechatSessionCache) EXPECT() *MockWechatSessionCacheMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockWechatSessionCache) Get(ctx context.Context, id string) (domain.WechatSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(domain.WechatSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockWechatSessionCacheMockRecorder) Get(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockWechatSessionCache)(nil).Get), ctx, id)
+}
+
+// Set mocks base method.
+func (m *MockWechatSessionCache) Set(ctx context.Context, id string, s domain.WechatSession, expiration time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", ctx, id, s, expiration)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockWechatSessionCacheMockRecorder) Set(ctx, id, s, expiration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockWechatSessionCache)(nil).Set), ctx, id, s, expiration)
+}
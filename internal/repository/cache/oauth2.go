@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+	"webBook/internal/domain"
+)
+
+type OAuth2StateCache interface {
+	Set(ctx context.Context, state string, s domain.OAuth2State, expiration time.Duration) error
+	// GetDel 取出并删除，也就是一个 state 只能用一次
+	GetDel(ctx context.Context, state string) (domain.OAuth2State, error)
+}
+
+type RedisOAuth2StateCache struct {
+	cmd redis.Cmdable
+}
+
+func NewOAuth2StateCache(cmd redis.Cmdable) OAuth2StateCache {
+	return &RedisOAuth2StateCache{
+		cmd: cmd,
+	}
+}
+
+func (c *RedisOAuth2StateCache) Set(ctx context.Context, state string,
+	s domain.OAuth2State, expiration time.Duration) error {
+	val, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return c.cmd.Set(ctx, c.key(state), val, expiration).Err()
+}
+
+// GetDel 方法，不存在的时候返回 ErrKeyNotExist。
+func (c *RedisOAuth2StateCache) GetDel(ctx context.Context, state string) (domain.OAuth2State, error) {
+	val, err := c.cmd.GetDel(ctx, c.key(state)).Bytes()
+	if err != nil {
+		return domain.OAuth2State{}, err
+	}
+	var s domain.OAuth2State
+	err = json.Unmarshal(val, &s)
+	return s, err
+}
+
+func (c *RedisOAuth2StateCache) key(state string) string {
+	return fmt.Sprintf("oauth2:state:%s", state)
+}
+
+// OAuth2TokenCache 授权服务器的授权码和撤销列表
+type OAuth2TokenCache interface {
+	SetCode(ctx context.Context, code string, c domain.OAuth2AuthCode, expiration time.Duration) error
+	// GetDelCode 取出并删除，授权码只能用一次
+	GetDelCode(ctx context.Context, code string) (domain.OAuth2AuthCode, error)
+	// Revoke 记录撤销的 token，过期时间和 token 一样，token 过期之后就不需要记录了
+	Revoke(ctx context.Context, jti string, expiration time.Duration) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+type RedisOAuth2TokenCache struct {
+	cmd redis.Cmdable
+}
+
+func NewOAuth2TokenCache(cmd redis.Cmdable) OAuth2TokenCache {
+	return &RedisOAuth2TokenCache{
+		cmd: cmd,
+	}
+}
+
+func (c *RedisOAuth2TokenCache) SetCode(ctx context.Context, code string,
+	ac domain.OAuth2AuthCode, expiration time.Duration) error {
+	val, err := json.Marshal(ac)
+	if err != nil {
+		return err
+	}
+	return c.cmd.Set(ctx, c.codeKey(code), val, expiration).Err()
+}
+
+// GetDelCode 方法，不存在的时候返回 ErrKeyNotExist。
+func (c *RedisOAuth2TokenCache) GetDelCode(ctx context.Context, code string) (domain.OAuth2AuthCode, error) {
+	val, err := c.cmd.GetDel(ctx, c.codeKey(code)).Bytes()
+	if err != nil {
+		return domain.OAuth2AuthCode{}, err
+	}
+	var ac domain.OAuth2AuthCode
+	err = json.Unmarshal(val, &ac)
+	return ac, err
+}
+
+func (c *RedisOAuth2TokenCache) Revoke(ctx context.Context, jti string, expiration time.Duration) error {
+	return c.cmd.Set(ctx, c.revokedKey(jti), "", expiration).Err()
+}
+
+func (c *RedisOAuth2TokenCache) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	cnt, err := c.cmd.Exists(ctx, c.revokedKey(jti)).Result()
+	return cnt > 0, err
+}
+
+func (c *RedisOAuth2TokenCache) codeKey(code string) string {
+	return fmt.Sprintf("oauth2:code:%s", code)
+}
+
+func (c *RedisOAuth2TokenCache) revokedKey(jti string) string {
+	return fmt.Sprintf("oauth2:revoked:%s", jti)
+}
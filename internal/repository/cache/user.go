@@ -15,6 +15,7 @@ var ErrKeyNotExist = redis.Nil
 type UserCache interface {
 	Get(ctx context.Context, uid int64) (domain.User, error)
 	Set(ctx context.Context, du domain.User) error
+	Del(ctx context.Context, uid int64) error
 }
 
 type RedisUserCache struct {
@@ -51,6 +52,11 @@ func (c *RedisUserCache) Set(ctx context.Context, du domain.User) error {
 	return c.cmd.Set(ctx, key, data, c.expiration).Err() // 将数据写入Redis，并设置过期时间，返回可能的错误。
 }
 
+// Del 方法，用户信息变更之后删除缓存。
+func (c *RedisUserCache) Del(ctx context.Context, uid int64) error {
+	return c.cmd.Del(ctx, c.key(uid)).Err()
+}
+
 // key 方法，生成Redis键名。
 func (c *RedisUserCache) key(uid int64) string {
 	return fmt.Sprintf("user:info:%d", uid) // 格式化生成特定格式的键名。
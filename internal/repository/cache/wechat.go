@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+	"webBook/internal/domain"
+)
+
+// WechatSessionCache 保存小程序的 session，session key 不能给前端
+type WechatSessionCache interface {
+	Set(ctx context.Context, id string, s domain.WechatSession, expiration time.Duration) error
+	Get(ctx context.Context, id string) (domain.WechatSession, error)
+}
+
+type RedisWechatSessionCache struct {
+	cmd redis.Cmdable
+}
+
+func NewWechatSessionCache(cmd redis.Cmdable) WechatSessionCache {
+	return &RedisWechatSessionCache{
+		cmd: cmd,
+	}
+}
+
+func (c *RedisWechatSessionCache) Set(ctx context.Context, id string,
+	s domain.WechatSession, expiration time.Duration) error {
+	val, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return c.cmd.Set(ctx, c.key(id), val, expiration).Err()
+}
+
+// Get 方法，不存在的时候返回 ErrKeyNotExist。
+func (c *RedisWechatSessionCache) Get(ctx context.Context, id string) (domain.WechatSession, error) {
+	val, err := c.cmd.Get(ctx, c.key(id)).Bytes()
+	if err != nil {
+		return domain.WechatSession{}, err
+	}
+	var s domain.WechatSession
+	err = json.Unmarshal(val, &s)
+	return s, err
+}
+
+func (c *RedisWechatSessionCache) key(id string) string {
+	return fmt.Sprintf("wechat:mini:session:%s", id)
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"webBook/internal/repository/cache"
+)
+
+type CaptchaRepository interface {
+	Store(ctx context.Context, id, answer string, expiration time.Duration) error
+	// Take 取出答案，取出之后就失效了，挑战不存在或者已经过期返回空字符串
+	Take(ctx context.Context, id string) (string, error)
+	IncrFailure(ctx context.Context, biz, key string, expiration time.Duration) (int64, error)
+	GetFailure(ctx context.Context, biz, key string) (int64, error)
+	ResetFailure(ctx context.Context, biz, key string) error
+}
+
+type CachedCaptchaRepository struct {
+	cache cache.CaptchaCache
+}
+
+func NewCaptchaRepository(c cache.CaptchaCache) CaptchaRepository {
+	return &CachedCaptchaRepository{
+		cache: c,
+	}
+}
+
+func (c *CachedCaptchaRepository) Store(ctx context.Context, id, answer string, expiration time.Duration) error {
+	return c.cache.Set(ctx, id, answer, expiration)
+}
+
+func (c *CachedCaptchaRepository) Take(ctx context.Context, id string) (string, error) {
+	answer, err := c.cache.GetDel(ctx, id)
+	if errors.Is(err, cache.ErrKeyNotExist) {
+		return "", nil
+	}
+	return answer, err
+}
+
+func (c *CachedCaptchaRepository) IncrFailure(ctx context.Context, biz, key string, expiration time.Duration) (int64, error) {
+	return c.cache.IncrFailure(ctx, biz, key, expiration)
+}
+
+func (c *CachedCaptchaRepository) GetFailure(ctx context.Context, biz, key string) (int64, error) {
+	return c.cache.GetFailure(ctx, biz, key)
+}
+
+func (c *CachedCaptchaRepository) ResetFailure(ctx context.Context, biz, key string) error {
+	return c.cache.DelFailure(ctx, biz, key)
+}
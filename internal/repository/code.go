@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"webBook/internal/domain"
 	"webBook/internal/repository/cache"
 )
 
@@ -9,7 +10,7 @@ var ErrCodeVerifyTooMany = cache.ErrCodeVerifyTooMany // 导出错误，表明
 var ErrCodeSendTooMany = cache.ErrCodeSendTooMany
 
 type CodeRepository interface {
-	Set(ctx context.Context, biz, phone, code string) error
+	Set(ctx context.Context, biz, phone, code string, policy domain.CodePolicy) error
 	Verify(ctx context.Context, biz, phone, code string) (bool, error)
 }
 
@@ -24,8 +25,8 @@ func NewCodeRepository(c cache.CodeCache) CodeRepository {
 }
 
 // Set 方法，将验证码存储到缓存中。
-func (c *CachedCodeRepository) Set(ctx context.Context, biz, phone, code string) error {
-	return c.cache.Set(ctx, biz, phone, code) // 调用缓存层的Set方法来存储验证码。
+func (c *CachedCodeRepository) Set(ctx context.Context, biz, phone, code string, policy domain.CodePolicy) error {
+	return c.cache.Set(ctx, biz, phone, code, policy) // 调用缓存层的Set方法来存储验证码。
 }
 
 // Verify 方法，验证缓存中的验证码。
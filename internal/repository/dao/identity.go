@@ -0,0 +1,64 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+var ErrDuplicateIdentity = errors.New("第三方身份已经绑定了用户")
+
+type UserIdentityDAO interface {
+	FindByProvider(ctx context.Context, provider, subject string) (UserIdentity, error)
+	// InsertWithUser 在同一个事务里面创建用户和第三方身份，返回用户 ID
+	InsertWithUser(ctx context.Context, u User, identity UserIdentity) (int64, error)
+}
+
+type GORMUserIdentityDAO struct {
+	db *gorm.DB
+}
+
+func NewUserIdentityDAO(db *gorm.DB) UserIdentityDAO {
+	return &GORMUserIdentityDAO{
+		db: db,
+	}
+}
+
+// UserIdentity 用户的第三方身份，一个用户可以绑定多个
+// 单独一张表，而不是在 users 上面加列
+type UserIdentity struct {
+	Id       int64  `gorm:"primaryKey,autoIncrement"`
+	Uid      int64  `gorm:"index"`
+	Provider string `gorm:"type:varchar(64);uniqueIndex:idx_provider_subject"`
+	Subject  string `gorm:"type:varchar(255);uniqueIndex:idx_provider_subject"`
+	Email    string `gorm:"type:varchar(255)"`
+	Ctime    int64
+	Utime    int64
+}
+
+func (dao *GORMUserIdentityDAO) FindByProvider(ctx context.Context, provider, subject string) (UserIdentity, error) {
+	var res UserIdentity
+	err := dao.db.WithContext(ctx).
+		Where("provider = ? AND subject = ?", provider, subject).First(&res).Error
+	return res, err
+}
+
+func (dao *GORMUserIdentityDAO) InsertWithUser(ctx context.Context, u User, identity UserIdentity) (int64, error) {
+	now := time.Now().UnixMilli()
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		u.Ctime = now
+		u.Utime = now
+		if err := tx.Create(&u).Error; err != nil {
+			return err
+		}
+		identity.Uid = u.Id
+		identity.Ctime = now
+		identity.Utime = now
+		return tx.Create(&identity).Error
+	})
+	if isDuplicate(err) {
+		return 0, ErrDuplicateIdentity
+	}
+	return u.Id, err
+}
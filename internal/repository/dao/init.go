@@ -4,5 +4,5 @@ import "gorm.io/gorm"
 
 func InitTables(db *gorm.DB) error {
 	// 严格来说，这个不是优秀实践
-	return db.AutoMigrate(&User{})
+	return db.AutoMigrate(&User{}, &UserIdentity{}, &OAuth2Client{}, &OAuth2Consent{})
 }
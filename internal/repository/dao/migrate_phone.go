@@ -0,0 +1,64 @@
+package dao
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"time"
+)
+
+// PhoneMigrateResult 手机号码迁移的结果
+type PhoneMigrateResult struct {
+	// 更新了的记录
+	Updated int
+	// 不需要更新的
+	Skipped int
+	// 号码不合法的，记录下 id，人工处理
+	Invalid []int64
+	// 规范化之后，和别的用户冲突的，也就是同一个人注册了两次，记录下 id，人工处理
+	Conflicts []int64
+}
+
+// MigratePhoneToE164 一次性的迁移，把已有的手机号码统一成 E.164 格式
+// 按照 id 分批处理，normalize 负责解析号码
+func MigratePhoneToE164(ctx context.Context, db *gorm.DB, batchSize int,
+	normalize func(phone string) (string, error)) (PhoneMigrateResult, error) {
+	var res PhoneMigrateResult
+	var maxId int64
+	for {
+		var users []User
+		err := db.WithContext(ctx).Select("id", "phone").
+			Where("id > ? AND phone IS NOT NULL", maxId).
+			Order("id").Limit(batchSize).Find(&users).Error
+		if err != nil {
+			return res, err
+		}
+		for _, u := range users {
+			maxId = u.Id
+			phone, err := normalize(u.Phone.String)
+			if err != nil {
+				res.Invalid = append(res.Invalid, u.Id)
+				continue
+			}
+			if phone == u.Phone.String {
+				res.Skipped++
+				continue
+			}
+			err = db.WithContext(ctx).Model(&User{}).Where("id = ?", u.Id).
+				Updates(map[string]any{
+					"phone": phone,
+					"utime": time.Now().UnixMilli(),
+				}).Error
+			if isDuplicate(err) {
+				res.Conflicts = append(res.Conflicts, u.Id)
+				continue
+			}
+			if err != nil {
+				return res, err
+			}
+			res.Updated++
+		}
+		if len(users) < batchSize {
+			return res, nil
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	mysqlDriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"strings"
+	"testing"
+)
+
+func TestMigratePhoneToE164(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	rows := sqlmock.NewRows([]string{"id", "phone"}).
+		AddRow(1, "13812345678").
+		AddRow(2, "+8613912345678").
+		AddRow(3, "abc").
+		AddRow(4, "+86 138 1234 5678")
+	mock.ExpectQuery("SELECT .* FROM `users`").WillReturnRows(rows)
+	mock.ExpectExec("UPDATE `users` SET").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE `users` SET").
+		WillReturnError(&mysqlDriver.MySQLError{Number: 1062})
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	res, err := MigratePhoneToE164(context.Background(), db, 10, func(phone string) (string, error) {
+		phone = strings.ReplaceAll(phone, " ", "")
+		switch {
+		case strings.HasPrefix(phone, "+"):
+			return phone, nil
+		case len(phone) == 11:
+			return "+86" + phone, nil
+		default:
+			return "", errors.New("非法号码")
+		}
+	})
+	require.NoError(t, err)
+	assert.Equal(t, PhoneMigrateResult{
+		Updated:   1,
+		Skipped:   1,
+		Invalid:   []int64{3},
+		Conflicts: []int64{4},
+	}, res)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -35,6 +35,20 @@ func (m *MockUserDAO) EXPECT() *MockUserDAOMockRecorder {
 	return m.recorder
 }
 
+// BindWechat mocks base method.
+func (m *MockUserDAO) BindWechat(ctx context.Context, uid int64, openId, miniOpenId, unionId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BindWechat", ctx, uid, openId, miniOpenId, unionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BindWechat indicates an expected call of BindWechat.
+func (mr *MockUserDAOMockRecorder) BindWechat(ctx, uid, openId, miniOpenId, unionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BindWechat", reflect.TypeOf((*MockUserDAO)(nil).BindWechat), ctx, uid, openId, miniOpenId, unionId)
+}
+
 // FindByEmail mocks base method.
 func (m *MockUserDAO) FindByEmail(ctx context.Context, email string) (dao.User, error) {
 	m.ctrl.T.Helper()
@@ -80,6 +94,51 @@ func (mr *MockUserDAOMockRecorder) FindByPhone(ctx, phone interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByPhone", reflect.TypeOf((*MockUserDAO)(nil).FindByPhone), ctx, phone)
 }
 
+// FindByWechat mocks base method.
+func (m *MockUserDAO) FindByWechat(ctx context.Context, openId string) (dao.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByWechat", ctx, openId)
+	ret0, _ := ret[0].(dao.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByWechat indicates an expected call of FindByWechat.
+func (mr *MockUserDAOMockRecorder) FindByWechat(ctx, openId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByWechat", reflect.TypeOf((*MockUserDAO)(nil).FindByWechat), ctx, openId)
+}
+
+// FindByWechatMini mocks base method.
+func (m *MockUserDAO) FindByWechatMini(ctx context.Context, miniOpenId string) (dao.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByWechatMini", ctx, miniOpenId)
+	ret0, _ := ret[0].(dao.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByWechatMini indicates an expected call of FindByWechatMini.
+func (mr *MockUserDAOMockRecorder) FindByWechatMini(ctx, miniOpenId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByWechatMini", reflect.TypeOf((*MockUserDAO)(nil).FindByWechatMini), ctx, miniOpenId)
+}
+
+// FindByWechatUnion mocks base method.
+func (m *MockUserDAO) FindByWechatUnion(ctx context.Context, unionId string) (dao.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByWechatUnion", ctx, unionId)
+	ret0, _ := ret[0].(dao.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByWechatUnion indicates an expected call of FindByWechatUnion.
+func (mr *MockUserDAOMockRecorder) FindByWechatUnion(ctx, unionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByWechatUnion", reflect.TypeOf((*MockUserDAO)(nil).FindByWechatUnion), ctx, unionId)
+}
+
 // Insert mocks base method.
 func (m *MockUserDAO) Insert(ctx context.Context, u dao.User) error {
 	m.ctrl.T.Helper()
@@ -107,3 +166,17 @@ func (mr *MockUserDAOMockRecorder) UpdateById(ctx, entity interface{}) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateById", reflect.TypeOf((*MockUserDAO)(nil).UpdateById), ctx, entity)
 }
+
+// UpdateWechatRefreshToken mocks base method.
+func (m *MockUserDAO) UpdateWechatRefreshToken(ctx context.Context, uid int64, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWechatRefreshToken", ctx, uid, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWechatRefreshToken indicates an expected call of UpdateWechatRefreshToken.
+func (mr *MockUserDAOMockRecorder) UpdateWechatRefreshToken(ctx, uid, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWechatRefreshToken", reflect.TypeOf((*MockUserDAO)(nil).UpdateWechatRefreshToken), ctx, uid, token)
+}
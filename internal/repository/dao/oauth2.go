@@ -0,0 +1,83 @@
+package dao
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type OAuth2ClientDAO interface {
+	FindByClientId(ctx context.Context, clientId string) (OAuth2Client, error)
+	Insert(ctx context.Context, c OAuth2Client) error
+	FindConsent(ctx context.Context, uid int64, clientId string) (OAuth2Consent, error)
+	// UpsertConsent 用户再次同意的时候覆盖 scope
+	UpsertConsent(ctx context.Context, c OAuth2Consent) error
+}
+
+type GORMOAuth2ClientDAO struct {
+	db *gorm.DB
+}
+
+func NewOAuth2ClientDAO(db *gorm.DB) OAuth2ClientDAO {
+	return &GORMOAuth2ClientDAO{
+		db: db,
+	}
+}
+
+// OAuth2Client 接入 webBook 登录的应用
+// 多个值的字段用空格分隔，和 OAuth2 里面 scope 的格式一样
+type OAuth2Client struct {
+	Id         int64  `gorm:"primaryKey,autoIncrement"`
+	ClientId   string `gorm:"type:varchar(64);uniqueIndex"`
+	SecretHash string `gorm:"type:varchar(128)"`
+	Name       string `gorm:"type:varchar(128)"`
+	// RedirectURIs 精确匹配
+	RedirectURIs string `gorm:"type:varchar(4096)"`
+	Scopes       string `gorm:"type:varchar(1024)"`
+	GrantTypes   string `gorm:"type:varchar(256)"`
+	Ctime        int64
+	Utime        int64
+}
+
+// OAuth2Consent 用户同意授权的记录
+type OAuth2Consent struct {
+	Id       int64  `gorm:"primaryKey,autoIncrement"`
+	Uid      int64  `gorm:"uniqueIndex:idx_uid_client"`
+	ClientId string `gorm:"type:varchar(64);uniqueIndex:idx_uid_client"`
+	Scopes   string `gorm:"type:varchar(1024)"`
+	Ctime    int64
+	Utime    int64
+}
+
+func (dao *GORMOAuth2ClientDAO) FindByClientId(ctx context.Context, clientId string) (OAuth2Client, error) {
+	var res OAuth2Client
+	err := dao.db.WithContext(ctx).Where("client_id = ?", clientId).First(&res).Error
+	return res, err
+}
+
+func (dao *GORMOAuth2ClientDAO) Insert(ctx context.Context, c OAuth2Client) error {
+	now := time.Now().UnixMilli()
+	c.Ctime = now
+	c.Utime = now
+	return dao.db.WithContext(ctx).Create(&c).Error
+}
+
+func (dao *GORMOAuth2ClientDAO) FindConsent(ctx context.Context, uid int64, clientId string) (OAuth2Consent, error) {
+	var res OAuth2Consent
+	err := dao.db.WithContext(ctx).
+		Where("uid = ? AND client_id = ?", uid, clientId).First(&res).Error
+	return res, err
+}
+
+func (dao *GORMOAuth2ClientDAO) UpsertConsent(ctx context.Context, c OAuth2Consent) error {
+	now := time.Now().UnixMilli()
+	c.Ctime = now
+	c.Utime = now
+	return dao.db.WithContext(ctx).Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]any{
+			"scopes": c.Scopes,
+			"utime":  now,
+		}),
+	}).Create(&c).Error
+}
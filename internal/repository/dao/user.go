@@ -10,8 +10,9 @@ import (
 )
 
 var (
-	ErrDuplicateEmail = errors.New("邮箱冲突")     // 定义邮箱冲突的错误。
-	ErrRecordNotFound = gorm.ErrRecordNotFound // 将GORM的记录未找到错误直接赋值给ErrRecordNotFound。
+	ErrDuplicateEmail = errors.New("邮箱冲突")          // 定义邮箱冲突的错误。
+	ErrWechatConflict = errors.New("微信账号已经绑定了别的用户") // 绑定的 openid 或者 UnionId 已经是别的用户的了。
+	ErrRecordNotFound = gorm.ErrRecordNotFound      // 将GORM的记录未找到错误直接赋值给ErrRecordNotFound。
 )
 
 type UserDAO interface {
@@ -21,6 +22,11 @@ type UserDAO interface {
 	FindById(ctx context.Context, uid int64) (User, error)
 	FindByPhone(ctx context.Context, phone string) (User, error)
 	FindByWechat(ctx context.Context, openId string) (User, error)
+	FindByWechatMini(ctx context.Context, miniOpenId string) (User, error)
+	FindByWechatUnion(ctx context.Context, unionId string) (User, error)
+	// BindWechat 补上用户在另外一个应用的 openid 和 UnionId，空的不更新
+	BindWechat(ctx context.Context, uid int64, openId, miniOpenId, unionId string) error
+	UpdateWechatRefreshToken(ctx context.Context, uid int64, token string) error
 }
 
 type GORMUserDAO struct {
@@ -38,14 +44,20 @@ type User struct {
 	Id            int64          `gorm:"primaryKey,autoIncrement"` // 主键，自动增长。
 	Email         sql.NullString `gorm:"unique"`                   // Email字段，唯一性约束。
 	Password      string         // 密码字段。
-	Nickname      string         `gorm:"type=varchar(128)"` // 昵称字段，指定类型为varchar(128)。
+	Nickname      string         `gorm:"type:varchar(128)"` // 昵称字段，指定类型为varchar(128)。
+	Avatar        string         `gorm:"type:varchar(1024)"`
 	Birthday      int64          // 生日字段。
-	AboutMe       string         `gorm:"type=varchar(4096)"` // 自我介绍字段，指定类型为varchar(4096)。
+	AboutMe       string         `gorm:"type:varchar(4096)"` // 自我介绍字段，指定类型为varchar(4096)。
 	Phone         sql.NullString `gorm:"unique"`             // 电话字段，唯一性约束。
+	Locale        string         `gorm:"type:varchar(16)"`   // 用户设置的语言。
 	Ctime         int64          // 创建时间。
 	Utime         int64          // 更新时间。
 	WechatOpenId  sql.NullString `gorm:"unique"`
-	WechatUnionId sql.NullString
+	WechatUnionId sql.NullString `gorm:"unique"`
+	// WechatMiniOpenId 小程序的 openid
+	WechatMiniOpenId sql.NullString `gorm:"unique"`
+	// WechatRefreshToken 加密之后的微信 refresh token，用来重新拉取用户资料
+	WechatRefreshToken string `gorm:"type:varchar(1024)"`
 }
 
 // Insert Insert方法，插入新的用户记录。
@@ -54,12 +66,8 @@ func (dao *GORMUserDAO) Insert(ctx context.Context, u User) error {
 	u.Ctime = now
 	u.Utime = now
 	err := dao.db.WithContext(ctx).Create(&u).Error // 在数据库中创建新的用户记录。
-	var me *mysql.MySQLError
-	if errors.As(err, &me) { // 错误类型断言。
-		const duplicateErr uint16 = 1062 // MySQL的重复键错误码。
-		if me.Number == duplicateErr {   // 判断是否是因为邮箱冲突。
-			return ErrDuplicateEmail // 返回邮箱冲突的错误。
-		}
+	if isDuplicate(err) {                           // 判断是否是因为邮箱冲突。
+		return ErrDuplicateEmail // 返回邮箱冲突的错误。
 	}
 	return err // 返回其他类型的错误。
 }
@@ -80,6 +88,7 @@ func (dao *GORMUserDAO) UpdateById(ctx context.Context, entity User) error {
 			"nickname": entity.Nickname,
 			"birthday": entity.Birthday,
 			"about_me": entity.AboutMe,
+			"locale":   entity.Locale,
 		}).Error
 }
 
@@ -102,3 +111,53 @@ func (dao *GORMUserDAO) FindByWechat(ctx context.Context, openId string) (User,
 	err := dao.db.WithContext(ctx).Where("wechat_open_id=?", openId).First(&u).Error
 	return u, err
 }
+
+func (dao *GORMUserDAO) FindByWechatMini(ctx context.Context, miniOpenId string) (User, error) {
+	var u User
+	err := dao.db.WithContext(ctx).Where("wechat_mini_open_id=?", miniOpenId).First(&u).Error
+	return u, err
+}
+
+func (dao *GORMUserDAO) FindByWechatUnion(ctx context.Context, unionId string) (User, error) {
+	var u User
+	err := dao.db.WithContext(ctx).Where("wechat_union_id=?", unionId).First(&u).Error
+	return u, err
+}
+
+func (dao *GORMUserDAO) BindWechat(ctx context.Context, uid int64, openId, miniOpenId, unionId string) error {
+	updates := map[string]any{
+		"utime": time.Now().UnixMilli(),
+	}
+	if openId != "" {
+		updates["wechat_open_id"] = openId
+	}
+	if miniOpenId != "" {
+		updates["wechat_mini_open_id"] = miniOpenId
+	}
+	if unionId != "" {
+		updates["wechat_union_id"] = unionId
+	}
+	err := dao.db.WithContext(ctx).Model(&User{}).Where("id = ?", uid).Updates(updates).Error
+	if isDuplicate(err) {
+		return ErrWechatConflict
+	}
+	return err
+}
+
+func (dao *GORMUserDAO) UpdateWechatRefreshToken(ctx context.Context, uid int64, token string) error {
+	return dao.db.WithContext(ctx).Model(&User{}).Where("id = ?", uid).
+		Updates(map[string]any{
+			"utime":                time.Now().UnixMilli(),
+			"wechat_refresh_token": token,
+		}).Error
+}
+
+// isDuplicate 是否是唯一索引冲突
+func isDuplicate(err error) bool {
+	var me *mysql.MySQLError
+	if errors.As(err, &me) { // 错误类型断言。
+		const duplicateErr uint16 = 1062 // MySQL的重复键错误码。
+		return me.Number == duplicateErr
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"webBook/internal/domain"
+	"webBook/internal/repository/dao"
+)
+
+var ErrDuplicateIdentity = dao.ErrDuplicateIdentity // 导出错误，表示第三方身份已经绑定了用户。
+
+type UserIdentityRepository interface {
+	// FindUid 查找第三方身份绑定的用户，没有绑定返回 ErrUserNotFound
+	FindUid(ctx context.Context, provider, subject string) (int64, error)
+	// CreateUser 创建用户并且绑定第三方身份
+	CreateUser(ctx context.Context, u domain.User, identity domain.UserIdentity) (int64, error)
+}
+
+type GORMUserIdentityRepository struct {
+	dao dao.UserIdentityDAO
+}
+
+func NewUserIdentityRepository(d dao.UserIdentityDAO) UserIdentityRepository {
+	return &GORMUserIdentityRepository{
+		dao: d,
+	}
+}
+
+func (repo *GORMUserIdentityRepository) FindUid(ctx context.Context, provider, subject string) (int64, error) {
+	identity, err := repo.dao.FindByProvider(ctx, provider, subject)
+	if err != nil {
+		return 0, err
+	}
+	return identity.Uid, nil
+}
+
+func (repo *GORMUserIdentityRepository) CreateUser(ctx context.Context, u domain.User, identity domain.UserIdentity) (int64, error) {
+	return repo.dao.InsertWithUser(ctx, dao.User{
+		Nickname: u.Nickname,
+		Email: sql.NullString{
+			String: u.Email,
+			Valid:  u.Email != "",
+		},
+		Phone: sql.NullString{
+			String: u.Phone,
+			Valid:  u.Phone != "",
+		},
+	}, dao.UserIdentity{
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	})
+}
@@ -0,0 +1,109 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/repository/captcha.go
+
+// Package repomocks is a generated GoMock package.
+package repomocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCaptchaRepository is a mock of CaptchaRepository interface.
+type MockCaptchaRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCaptchaRepositoryMockRecorder
+}
+
+// MockCaptchaRepositoryMockRecorder is the mock recorder for MockCaptchaRepository.
+type MockCaptchaRepositoryMockRecorder struct {
+	mock *MockCaptchaRepository
+}
+
+// NewMockCaptchaRepository creates a new mock instance.
+func NewMockCaptchaRepository(ctrl *gomock.Controller) *MockCaptchaRepository {
+	mock := &MockCaptchaRepository{ctrl: ctrl}
+	mock.recorder = &MockCaptchaRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCaptchaRepository) EXPECT() *MockCaptchaRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetFailure mocks base method.
+func (m *MockCaptchaRepository) GetFailure(ctx context.Context, biz, key string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFailure", ctx, biz, key)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFailure indicates an expected call of GetFailure.
+func (mr *MockCaptchaRepositoryMockRecorder) GetFailure(ctx, biz, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFailure", reflect.TypeOf((*MockCaptchaRepository)(nil).GetFailure), ctx, biz, key)
+}
+
+// IncrFailure mocks base method.
+func (m *MockCaptchaRepository) IncrFailure(ctx context.Context, biz, key string, expiration time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrFailure", ctx, biz, key, expiration)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrFailure indicates an expected call of IncrFailure.
+func (mr *MockCaptchaRepositoryMockRecorder) IncrFailure(ctx, biz, key, expiration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrFailure", reflect.TypeOf((*MockCaptchaRepository)(nil).IncrFailure), ctx, biz, key, expiration)
+}
+
+// ResetFailure mocks base method.
+func (m *MockCaptchaRepository) ResetFailure(ctx context.Context, biz, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetFailure", ctx, biz, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetFailure indicates an expected call of ResetFailure.
+func (mr *MockCaptchaRepositoryMockRecorder) ResetFailure(ctx, biz, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetFailure", reflect.TypeOf((*MockCaptchaRepository)(nil).ResetFailure), ctx, biz, key)
+}
+
+// Store mocks base method.
+func (m *MockCaptchaRepository) Store(ctx context.Context, id, answer string, expiration time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Store", ctx, id, answer, expiration)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Store indicates an expected call of Store.
+func (mr *MockCaptchaRepositoryMockRecorder) Store(ctx, id, answer, expiration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Store", reflect.TypeOf((*MockCaptchaRepository)(nil).Store), ctx, id, answer, expiration)
+}
+
+// Take mocks base method.
+func (m *MockCaptchaRepository) Take(ctx context.Context, id string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Take", ctx, id)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Take indicates an expected call of Take.
+func (mr *MockCaptchaRepositoryMockRecorder) Take(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Take", reflect.TypeOf((*MockCaptchaRepository)(nil).Take), ctx, id)
+}
@@ -7,6 +7,7 @@ package repomocks
 import (
 	context "context"
 	reflect "reflect"
+	domain "webBook/internal/domain"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -35,17 +36,17 @@ func (m *MockCodeRepository) EXPECT() *MockCodeRepositoryMockRecorder {
 }
 
 // Set mocks base method.
-func (m *MockCodeRepository) Set(ctx context.Context, biz, phone, code string) error {
+func (m *MockCodeRepository) Set(ctx context.Context, biz, phone, code string, policy domain.CodePolicy) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Set", ctx, biz, phone, code)
+	ret := m.ctrl.Call(m, "Set", ctx, biz, phone, code, policy)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Set indicates an expected call of Set.
-func (mr *MockCodeRepositoryMockRecorder) Set(ctx, biz, phone, code interface{}) *gomock.Call {
+func (mr *MockCodeRepositoryMockRecorder) Set(ctx, biz, phone, code, policy interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockCodeRepository)(nil).Set), ctx, biz, phone, code)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockCodeRepository)(nil).Set), ctx, biz, phone, code, policy)
 }
 
 // Verify mocks base method.
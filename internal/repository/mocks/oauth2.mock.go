@@ -0,0 +1,228 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/repository/oauth2.go
+
+// Package repomocks is a generated GoMock package.
+package repomocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+	domain "webBook/internal/domain"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockOAuth2StateRepository is a mock of OAuth2StateRepository interface.
+type MockOAuth2StateRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuth2StateRepositoryMockRecorder
+}
+
+// MockOAuth2StateRepositoryMockRecorder is the mock recorder for MockOAuth2StateRepository.
+type MockOAuth2StateRepositoryMockRecorder struct {
+	mock *MockOAuth2StateRepository
+}
+
+// NewMockOAuth2StateRepository creates a new mock instance.
+func NewMockOAuth2StateRepository(ctrl *gomock.Controller) *MockOAuth2StateRepository {
+	mock := &MockOAuth2StateRepository{ctrl: ctrl}
+	mock.recorder = &MockOAuth2StateRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuth2StateRepository) EXPECT() *MockOAuth2StateRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Store mocks base method.
+func (m *MockOAuth2StateRepository) Store(ctx context.Context, state string, s domain.OAuth2State, expiration time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Store", ctx, state, s, expiration)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Store indicates an expected call of Store.
+func (mr *MockOAuth2StateRepositoryMockRecorder) Store(ctx, state, s, expiration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Store", reflect.TypeOf((*MockOAuth2StateRepository)(nil).Store), ctx, state, s, expiration)
+}
+
+// Take mocks base method.
+func (m *MockOAuth2StateRepository) Take(ctx context.Context, state string) (domain.OAuth2State, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Take", ctx, state)
+	ret0, _ := ret[0].(domain.OAuth2State)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Take indicates an expected call of Take.
+func (mr *MockOAuth2StateRepositoryMockRecorder) Take(ctx, state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Take", reflect.TypeOf((*MockOAuth2StateRepository)(nil).Take), ctx, state)
+}
+
+// MockOAuth2ClientRepository is a mock of OAuth2ClientRepository interface.
+type MockOAuth2ClientRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuth2ClientRepositoryMockRecorder
+}
+
+// MockOAuth2ClientRepositoryMockRecorder is the mock recorder for MockOAuth2ClientRepository.
+type MockOAuth2ClientRepositoryMockRecorder struct {
+	mock *MockOAuth2ClientRepository
+}
+
+// NewMockOAuth2ClientRepository creates a new mock instance.
+func NewMockOAuth2ClientRepository(ctrl *gomock.Controller) *MockOAuth2ClientRepository {
+	mock := &MockOAuth2ClientRepository{ctrl: ctrl}
+	mock.recorder = &MockOAuth2ClientRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuth2ClientRepository) EXPECT() *MockOAuth2ClientRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateClient mocks base method.
+func (m *MockOAuth2ClientRepository) CreateClient(ctx context.Context, c domain.OAuth2Client) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateClient", ctx, c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateClient indicates an expected call of CreateClient.
+func (mr *MockOAuth2ClientRepositoryMockRecorder) CreateClient(ctx, c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateClient", reflect.TypeOf((*MockOAuth2ClientRepository)(nil).CreateClient), ctx, c)
+}
+
+// FindClient mocks base method.
+func (m *MockOAuth2ClientRepository) FindClient(ctx context.Context, clientId string) (domain.OAuth2Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindClient", ctx, clientId)
+	ret0, _ := ret[0].(domain.OAuth2Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindClient indicates an expected call of FindClient.
+func (mr *MockOAuth2ClientRepositoryMockRecorder) FindClient(ctx, clientId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindClient", reflect.TypeOf((*MockOAuth2ClientRepository)(nil).FindClient), ctx, clientId)
+}
+
+// FindConsent mocks base method.
+func (m *MockOAuth2ClientRepository) FindConsent(ctx context.Context, uid int64, clientId string) (domain.OAuth2Consent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindConsent", ctx, uid, clientId)
+	ret0, _ := ret[0].(domain.OAuth2Consent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindConsent indicates an expected call of FindConsent.
+func (mr *MockOAuth2ClientRepositoryMockRecorder) FindConsent(ctx, uid, clientId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindConsent", reflect.TypeOf((*MockOAuth2ClientRepository)(nil).FindConsent), ctx, uid, clientId)
+}
+
+// SaveConsent mocks base method.
+func (m *MockOAuth2ClientRepository) SaveConsent(ctx context.Context, c domain.OAuth2Consent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveConsent", ctx, c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveConsent indicates an expected call of SaveConsent.
+func (mr *MockOAuth2ClientRepositoryMockRecorder) SaveConsent(ctx, c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveConsent", reflect.TypeOf((*MockOAuth2ClientRepository)(nil).SaveConsent), ctx, c)
+}
+
+// MockOAuth2TokenRepository is a mock of OAuth2TokenRepository interface.
+type MockOAuth2TokenRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuth2TokenRepositoryMockRecorder
+}
+
+// MockOAuth2TokenRepositoryMockRecorder is the mock recorder for MockOAuth2TokenRepository.
+type MockOAuth2TokenRepositoryMockRecorder struct {
+	mock *MockOAuth2TokenRepository
+}
+
+// NewMockOAuth2TokenRepository creates a new mock instance.
+func NewMockOAuth2TokenRepository(ctrl *gomock.Controller) *MockOAuth2TokenRepository {
+	mock := &MockOAuth2TokenRepository{ctrl: ctrl}
+	mock.recorder = &MockOAuth2TokenRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOAuth2TokenRepository) EXPECT() *MockOAuth2TokenRepositoryMockRecorder {
+	return m.recorder
+}
+
+// IsRevoked mocks base method.
+func (m *MockOAuth2TokenRepository) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsRevoked", ctx, jti)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsRevoked indicates an expected call of IsRevoked.
+func (mr *MockOAuth2TokenRepositoryMockRecorder) IsRevoked(ctx, jti interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRevoked", reflect.TypeOf((*MockOAuth2TokenRepository)(nil).IsRevoked), ctx, jti)
+}
+
+// Revoke mocks base method.
+func (m *MockOAuth2TokenRepository) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, jti, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockOAuth2TokenRepositoryMockRecorder) Revoke(ctx, jti, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockOAuth2TokenRepository)(nil).Revoke), ctx, jti, expiresAt)
+}
+
+// StoreCode mocks base method.
+func (m *MockOAuth2TokenRepository) StoreCode(ctx context.Context, code string, c domain.OAuth2AuthCode, expiration time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreCode", ctx, code, c, expiration)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreCode indicates an expected call of StoreCode.
+func (mr *MockOAuth2TokenRepositoryMockRecorder) StoreCode(ctx, code, c, expiration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreCode", reflect.TypeOf((*MockOAuth2TokenRepository)(nil).StoreCode), ctx, code, c, expiration)
+}
+
+// TakeCode mocks base method.
+func (m *MockOAuth2TokenRepository) TakeCode(ctx context.Context, code string) (domain.OAuth2AuthCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeCode", ctx, code)
+	ret0, _ := ret[0].(domain.OAuth2AuthCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeCode indicates an expected call of TakeCode.
+func (mr *MockOAuth2TokenRepositoryMockRecorder) TakeCode(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeCode", reflect.TypeOf((*MockOAuth2TokenRepository)(nil).TakeCode), ctx, code)
+}
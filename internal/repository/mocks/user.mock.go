@@ -35,6 +35,20 @@ func (m *MockUserRepository) EXPECT() *MockUserRepositoryMockRecorder {
 	return m.recorder
 }
 
+// BindWechat mocks base method.
+func (m *MockUserRepository) BindWechat(ctx context.Context, uid int64, info domain.WechatInfo) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BindWechat", ctx, uid, info)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BindWechat indicates an expected call of BindWechat.
+func (mr *MockUserRepositoryMockRecorder) BindWechat(ctx, uid, info interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BindWechat", reflect.TypeOf((*MockUserRepository)(nil).BindWechat), ctx, uid, info)
+}
+
 // Create mocks base method.
 func (m *MockUserRepository) Create(ctx context.Context, u domain.User) error {
 	m.ctrl.T.Helper()
@@ -94,6 +108,66 @@ func (mr *MockUserRepositoryMockRecorder) FindByPhone(ctx, phone interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByPhone", reflect.TypeOf((*MockUserRepository)(nil).FindByPhone), ctx, phone)
 }
 
+// FindByWechat mocks base method.
+func (m *MockUserRepository) FindByWechat(ctx context.Context, openId string) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByWechat", ctx, openId)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByWechat indicates an expected call of FindByWechat.
+func (mr *MockUserRepositoryMockRecorder) FindByWechat(ctx, openId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByWechat", reflect.TypeOf((*MockUserRepository)(nil).FindByWechat), ctx, openId)
+}
+
+// FindByWechatMini mocks base method.
+func (m *MockUserRepository) FindByWechatMini(ctx context.Context, miniOpenId string) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByWechatMini", ctx, miniOpenId)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByWechatMini indicates an expected call of FindByWechatMini.
+func (mr *MockUserRepositoryMockRecorder) FindByWechatMini(ctx, miniOpenId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByWechatMini", reflect.TypeOf((*MockUserRepository)(nil).FindByWechatMini), ctx, miniOpenId)
+}
+
+// FindByWechatUnion mocks base method.
+func (m *MockUserRepository) FindByWechatUnion(ctx context.Context, unionId string) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByWechatUnion", ctx, unionId)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByWechatUnion indicates an expected call of FindByWechatUnion.
+func (mr *MockUserRepositoryMockRecorder) FindByWechatUnion(ctx, unionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByWechatUnion", reflect.TypeOf((*MockUserRepository)(nil).FindByWechatUnion), ctx, unionId)
+}
+
+// FindWechatRefreshToken mocks base method.
+func (m *MockUserRepository) FindWechatRefreshToken(ctx context.Context, uid int64) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindWechatRefreshToken", ctx, uid)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindWechatRefreshToken indicates an expected call of FindWechatRefreshToken.
+func (mr *MockUserRepositoryMockRecorder) FindWechatRefreshToken(ctx, uid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindWechatRefreshToken", reflect.TypeOf((*MockUserRepository)(nil).FindWechatRefreshToken), ctx, uid)
+}
+
 // UpdateNonZeroFields mocks base method.
 func (m *MockUserRepository) UpdateNonZeroFields(ctx context.Context, user domain.User) error {
 	m.ctrl.T.Helper()
@@ -107,3 +181,17 @@ func (mr *MockUserRepositoryMockRecorder) UpdateNonZeroFields(ctx, user interfac
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNonZeroFields", reflect.TypeOf((*MockUserRepository)(nil).UpdateNonZeroFields), ctx, user)
 }
+
+// UpdateWechatRefreshToken mocks base method.
+func (m *MockUserRepository) UpdateWechatRefreshToken(ctx context.Context, uid int64, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWechatRefreshToken", ctx, uid, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWechatRefreshToken indicates an expected call of UpdateWechatRefreshToken.
+func (mr *MockUserRepositoryMockRecorder) UpdateWechatRefreshToken(ctx, uid, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWechatRefreshToken", reflect.TypeOf((*MockUserRepository)(nil).UpdateWechatRefreshToken), ctx, uid, token)
+}
@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/repository/wechat.go
+
+// Package repomocks is a generated GoMock package.
+package repomocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+	domain "webBook/internal/domain"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockWechatSessionRepository is a mock of WechatSessionRepository interface.
+type MockWechatSessionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWechatSessionRepositoryMockRecorder
+}
+
+// MockWechatSessionRepositoryMockRecorder is the mock recorder for MockWechatSessionRepository.
+type MockWechatSessionRepositoryMockRecorder struct {
+	mock *MockWechatSessionRepository
+}
+
+// NewMockWechatSessionRepository creates a new mock instance.
+func NewMockWechatSessionRepository(ctrl *gomock.Controller) *MockWechatSessionRepository {
+	mock := &MockWechatSessionRepository{ctrl: ctrl}
+	mock.recorder = &MockWechatSessionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWechatSessionRepository) EXPECT() *MockWechatSessionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Find mocks base method.
+func (m *MockWechatSessionRepository) Find(ctx context.Context, id string) (domain.WechatSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Find", ctx, id)
+	ret0, _ := ret[0].(domain.WechatSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Find indicates an expected call of Find.
+func (mr *MockWechatSessionRepositoryMockRecorder) Find(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockWechatSessionRepository)(nil).Find), ctx, id)
+}
+
+// Store mocks base method.
+func (m *MockWechatSessionRepository) Store(ctx context.Context, id string, s domain.WechatSession, expiration time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Store", ctx, id, s, expiration)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Store indicates an expected call of Store.
+func (mr *MockWechatSessionRepositoryMockRecorder) Store(ctx, id, s, expiration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Store", reflect.TypeOf((*MockWechatSessionRepository)(nil).Store), ctx, id, s, expiration)
+}
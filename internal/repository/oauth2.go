@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"webBook/internal/domain"
+	"webBook/internal/repository/cache"
+	"webBook/internal/repository/dao"
+)
+
+var ErrOAuth2StateNotFound = errors.New("state 不存在、已经过期或者已经用过了")
+
+type OAuth2StateRepository interface {
+	Store(ctx context.Context, state string, s domain.OAuth2State, expiration time.Duration) error
+	// Take 取出之后就失效了，不存在返回 ErrOAuth2StateNotFound
+	Take(ctx context.Context, state string) (domain.OAuth2State, error)
+}
+
+type CachedOAuth2StateRepository struct {
+	cache cache.OAuth2StateCache
+}
+
+func NewOAuth2StateRepository(c cache.OAuth2StateCache) OAuth2StateRepository {
+	return &CachedOAuth2StateRepository{
+		cache: c,
+	}
+}
+
+func (repo *CachedOAuth2StateRepository) Store(ctx context.Context, state string,
+	s domain.OAuth2State, expiration time.Duration) error {
+	return repo.cache.Set(ctx, state, s, expiration)
+}
+
+func (repo *CachedOAuth2StateRepository) Take(ctx context.Context, state string) (domain.OAuth2State, error) {
+	s, err := repo.cache.GetDel(ctx, state)
+	if errors.Is(err, cache.ErrKeyNotExist) {
+		return domain.OAuth2State{}, ErrOAuth2StateNotFound
+	}
+	return s, err
+}
+
+var (
+	ErrOAuth2ClientNotFound = dao.ErrRecordNotFound // 导出错误，表示 client 不存在。
+	ErrOAuth2CodeNotFound   = errors.New("授权码不存在、已经过期或者已经用过了")
+)
+
+type OAuth2ClientRepository interface {
+	FindClient(ctx context.Context, clientId string) (domain.OAuth2Client, error)
+	CreateClient(ctx context.Context, c domain.OAuth2Client) error
+	// FindConsent 没有同意过返回空的 scope
+	FindConsent(ctx context.Context, uid int64, clientId string) (domain.OAuth2Consent, error)
+	SaveConsent(ctx context.Context, c domain.OAuth2Consent) error
+}
+
+type GORMOAuth2ClientRepository struct {
+	dao dao.OAuth2ClientDAO
+}
+
+func NewOAuth2ClientRepository(d dao.OAuth2ClientDAO) OAuth2ClientRepository {
+	return &GORMOAuth2ClientRepository{
+		dao: d,
+	}
+}
+
+func (repo *GORMOAuth2ClientRepository) FindClient(ctx context.Context, clientId string) (domain.OAuth2Client, error) {
+	c, err := repo.dao.FindByClientId(ctx, clientId)
+	if err != nil {
+		return domain.OAuth2Client{}, err
+	}
+	return domain.OAuth2Client{
+		ClientId:     c.ClientId,
+		SecretHash:   c.SecretHash,
+		Name:         c.Name,
+		RedirectURIs: strings.Fields(c.RedirectURIs),
+		Scopes:       strings.Fields(c.Scopes),
+		GrantTypes:   strings.Fields(c.GrantTypes),
+	}, nil
+}
+
+func (repo *GORMOAuth2ClientRepository) CreateClient(ctx context.Context, c domain.OAuth2Client) error {
+	return repo.dao.Insert(ctx, dao.OAuth2Client{
+		ClientId:     c.ClientId,
+		SecretHash:   c.SecretHash,
+		Name:         c.Name,
+		RedirectURIs: strings.Join(c.RedirectURIs, " "),
+		Scopes:       strings.Join(c.Scopes, " "),
+		GrantTypes:   strings.Join(c.GrantTypes, " "),
+	})
+}
+
+func (repo *GORMOAuth2ClientRepository) FindConsent(ctx context.Context, uid int64, clientId string) (domain.OAuth2Consent, error) {
+	c, err := repo.dao.FindConsent(ctx, uid, clientId)
+	if errors.Is(err, dao.ErrRecordNotFound) {
+		return domain.OAuth2Consent{Uid: uid, ClientId: clientId}, nil
+	}
+	if err != nil {
+		return domain.OAuth2Consent{}, err
+	}
+	return domain.OAuth2Consent{
+		Uid:      c.Uid,
+		ClientId: c.ClientId,
+		Scopes:   strings.Fields(c.Scopes),
+	}, nil
+}
+
+func (repo *GORMOAuth2ClientRepository) SaveConsent(ctx context.Context, c domain.OAuth2Consent) error {
+	return repo.dao.UpsertConsent(ctx, dao.OAuth2Consent{
+		Uid:      c.Uid,
+		ClientId: c.ClientId,
+		Scopes:   strings.Join(c.Scopes, " "),
+	})
+}
+
+type OAuth2TokenRepository interface {
+	StoreCode(ctx context.Context, code string, c domain.OAuth2AuthCode, expiration time.Duration) error
+	// TakeCode 取出之后就失效了，不存在返回 ErrOAuth2CodeNotFound
+	TakeCode(ctx context.Context, code string) (domain.OAuth2AuthCode, error)
+	// Revoke 撤销 token，expiresAt 是 token 本身的过期时间
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+type CachedOAuth2TokenRepository struct {
+	cache cache.OAuth2TokenCache
+}
+
+func NewOAuth2TokenRepository(c cache.OAuth2TokenCache) OAuth2TokenRepository {
+	return &CachedOAuth2TokenRepository{
+		cache: c,
+	}
+}
+
+func (repo *CachedOAuth2TokenRepository) StoreCode(ctx context.Context, code string,
+	c domain.OAuth2AuthCode, expiration time.Duration) error {
+	return repo.cache.SetCode(ctx, code, c, expiration)
+}
+
+func (repo *CachedOAuth2TokenRepository) TakeCode(ctx context.Context, code string) (domain.OAuth2AuthCode, error) {
+	c, err := repo.cache.GetDelCode(ctx, code)
+	if errors.Is(err, cache.ErrKeyNotExist) {
+		return domain.OAuth2AuthCode{}, ErrOAuth2CodeNotFound
+	}
+	return c, err
+}
+
+func (repo *CachedOAuth2TokenRepository) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		// 已经过期了，不需要记录
+		return nil
+	}
+	return repo.cache.Revoke(ctx, jti, ttl)
+}
+
+func (repo *CachedOAuth2TokenRepository) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	return repo.cache.IsRevoked(ctx, jti)
+}
@@ -3,16 +3,18 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"log"
 	"time"
 	"webBook/internal/domain"
 	"webBook/internal/repository/cache"
 	"webBook/internal/repository/dao"
+	"webBook/pkg/cryptox"
+	"webBook/pkg/logger"
 )
 
 var (
-	ErrDuplicateUser = dao.ErrDuplicateEmail // 导出错误，表示用户信息冲突（如邮箱重复）。
-	ErrUserNotFound  = dao.ErrRecordNotFound // 导出错误，表示未找到用户记录。
+	ErrDuplicateUser  = dao.ErrDuplicateEmail // 导出错误，表示用户信息冲突（如邮箱重复）。
+	ErrUserNotFound   = dao.ErrRecordNotFound // 导出错误，表示未找到用户记录。
+	ErrWechatConflict = dao.ErrWechatConflict // 导出错误，表示微信账号已经绑定了别的用户。
 )
 
 type UserRepository interface {
@@ -22,23 +24,41 @@ type UserRepository interface {
 	FindByPhone(ctx context.Context, phone string) (domain.User, error)
 	FindById(ctx context.Context, uid int64) (domain.User, error)
 	FindByWechat(ctx context.Context, openId string) (domain.User, error)
+	FindByWechatMini(ctx context.Context, miniOpenId string) (domain.User, error)
+	FindByWechatUnion(ctx context.Context, unionId string) (domain.User, error)
+	// BindWechat 补上用户在网站应用或者小程序的 openid 和 UnionId，空的不更新，
+	// 已经绑定在别的用户上返回 ErrWechatConflict
+	BindWechat(ctx context.Context, uid int64, info domain.WechatInfo) error
+	// UpdateWechatRefreshToken 加密之后保存微信的 refresh token
+	UpdateWechatRefreshToken(ctx context.Context, uid int64, token string) error
+	// FindWechatRefreshToken 解密之后的微信 refresh token
+	FindWechatRefreshToken(ctx context.Context, uid int64) (string, error)
 }
 
 type CachedUserRepository struct {
 	dao   dao.UserDAO     // dao字段，指向UserDAO结构体实例，用于数据库操作。
 	cache cache.UserCache // cache字段，指向UserCache结构体实例，用于缓存操作。
+	// cipher 加密第三方的 token
+	cipher cryptox.Cipher
 }
 
-func NewCachedUserRepository(dao dao.UserDAO, c cache.UserCache) UserRepository {
+func NewCachedUserRepository(dao dao.UserDAO, c cache.UserCache, cipher cryptox.Cipher) UserRepository {
 	return &CachedUserRepository{
-		dao:   dao,
-		cache: c,
+		dao:    dao,
+		cache:  c,
+		cipher: cipher,
 	}
 }
 
 // Create 方法，创建新用户。
 func (repo *CachedUserRepository) Create(ctx context.Context, u domain.User) error {
-	return repo.dao.Insert(ctx, repo.toEntity(u))
+	entity := repo.toEntity(u)
+	token, err := repo.encrypt(u.WechatInfo.RefreshToken)
+	if err != nil {
+		return err
+	}
+	entity.WechatRefreshToken = token
+	return repo.dao.Insert(ctx, entity)
 }
 
 // FindByEmail 方法，通过邮箱查找用户。
@@ -59,11 +79,14 @@ func (repo *CachedUserRepository) toDomain(u dao.User) domain.User {
 		Password: u.Password,
 		AboutMe:  u.AboutMe,
 		Nickname: u.Nickname,
+		Avatar:   u.Avatar,
+		Locale:   u.Locale,
 		Birthday: time.UnixMilli(u.Birthday), // 将Unix时间毫秒数转换为time.Time对象。
 		Ctime:    time.UnixMilli(u.Ctime),
 		WechatInfo: domain.WechatInfo{
-			OpenId:  u.WechatOpenId.String,
-			UnionId: u.WechatUnionId.String,
+			OpenId:     u.WechatOpenId.String,
+			MiniOpenId: u.WechatMiniOpenId.String,
+			UnionId:    u.WechatUnionId.String,
 		},
 	}
 }
@@ -84,6 +107,8 @@ func (repo *CachedUserRepository) toEntity(u domain.User) dao.User {
 		Birthday: u.Birthday.UnixMilli(), // 将time.Time对象转换为Unix时间毫秒数。
 		AboutMe:  u.AboutMe,
 		Nickname: u.Nickname,
+		Avatar:   u.Avatar,
+		Locale:   u.Locale,
 		WechatUnionId: sql.NullString{
 			String: u.WechatInfo.UnionId,
 			Valid:  u.WechatInfo.UnionId != "",
@@ -92,12 +117,21 @@ func (repo *CachedUserRepository) toEntity(u domain.User) dao.User {
 			String: u.WechatInfo.OpenId,
 			Valid:  u.WechatInfo.OpenId != "",
 		},
+		WechatMiniOpenId: sql.NullString{
+			String: u.WechatInfo.MiniOpenId,
+			Valid:  u.WechatInfo.MiniOpenId != "",
+		},
 	}
 }
 
 // UpdateNonZeroFields 方法，更新用户信息中的非零字段。
 func (repo *CachedUserRepository) UpdateNonZeroFields(ctx context.Context, user domain.User) error {
-	return repo.dao.UpdateById(ctx, repo.toEntity(user))
+	err := repo.dao.UpdateById(ctx, repo.toEntity(user))
+	if err != nil {
+		return err
+	}
+	// 语言设置是每个请求都从缓存里面读的，不删掉的话要等缓存过期才生效
+	return repo.cache.Del(ctx, user.Id)
 }
 
 // FindById 方法，通过ID查找用户，首先尝试从缓存中获取，失败则从数据库获取。
@@ -114,7 +148,7 @@ func (repo *CachedUserRepository) FindById(ctx context.Context, uid int64) (doma
 	du = repo.toDomain(u)
 	err = repo.cache.Set(ctx, du)
 	if err != nil {
-		log.Println(err)
+		logger.FromContext(ctx).Warn("回写用户缓存失败", logger.Field{Key: "err", Val: err})
 	}
 	return du, nil
 }
@@ -135,3 +169,55 @@ func (repo *CachedUserRepository) FindByWechat(ctx context.Context, openId strin
 	}
 	return repo.toDomain(ue), nil
 }
+
+func (repo *CachedUserRepository) FindByWechatMini(ctx context.Context, miniOpenId string) (domain.User, error) {
+	ue, err := repo.dao.FindByWechatMini(ctx, miniOpenId)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return repo.toDomain(ue), nil
+}
+
+func (repo *CachedUserRepository) FindByWechatUnion(ctx context.Context, unionId string) (domain.User, error) {
+	ue, err := repo.dao.FindByWechatUnion(ctx, unionId)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return repo.toDomain(ue), nil
+}
+
+func (repo *CachedUserRepository) BindWechat(ctx context.Context, uid int64, info domain.WechatInfo) error {
+	err := repo.dao.BindWechat(ctx, uid, info.OpenId, info.MiniOpenId, info.UnionId)
+	if err != nil {
+		return err
+	}
+	// 缓存里面的用户信息已经过时了
+	return repo.cache.Del(ctx, uid)
+}
+
+func (repo *CachedUserRepository) UpdateWechatRefreshToken(ctx context.Context, uid int64, token string) error {
+	encrypted, err := repo.encrypt(token)
+	if err != nil {
+		return err
+	}
+	return repo.dao.UpdateWechatRefreshToken(ctx, uid, encrypted)
+}
+
+func (repo *CachedUserRepository) FindWechatRefreshToken(ctx context.Context, uid int64) (string, error) {
+	// 缓存里面没有 token，直接查数据库
+	u, err := repo.dao.FindById(ctx, uid)
+	if err != nil {
+		return "", err
+	}
+	if u.WechatRefreshToken == "" {
+		return "", nil
+	}
+	return repo.cipher.Decrypt(u.WechatRefreshToken)
+}
+
+func (repo *CachedUserRepository) encrypt(token string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+	return repo.cipher.Encrypt(token)
+}
@@ -4,11 +4,17 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"testing"
 	"time"
 	"webBook/internal/domain"
 	"webBook/internal/repository/cache"
+	cachemocks "webBook/internal/repository/cache/mocks"
 	"webBook/internal/repository/dao"
+	daomocks "webBook/internal/repository/dao/mocks"
+	"webBook/pkg/cryptox"
 )
 
 func TestCachedUserRepository_FindById(t *testing.T) {
@@ -180,10 +186,43 @@ func TestCachedUserRepository_FindById(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 			uc, ud := tc.mock(ctrl)
-			svc := NewCachedUserRepository(ud, uc)
+			svc := NewCachedUserRepository(ud, uc, nil)
 			user, err := svc.FindById(tc.ctx, tc.uid)
 			assert.Equal(t, tc.wantErr, err)
 			assert.Equal(t, tc.wantUser, user)
 		})
 	}
 }
+
+func TestCachedUserRepository_WechatRefreshToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cipher, err := cryptox.NewAESGCM([]byte("FqkeVd2Ka5jHFEG7FPtP6K7d9y3EXsUK"))
+	require.NoError(t, err)
+	d := daomocks.NewMockUserDAO(ctrl)
+	var stored string
+	d.EXPECT().Insert(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, u dao.User) error {
+		// 落库的是密文
+		assert.NotEmpty(t, u.WechatRefreshToken)
+		assert.NotEqual(t, "refresh", u.WechatRefreshToken)
+		assert.Equal(t, "Tom", u.Nickname)
+		assert.Equal(t, "avatar.png", u.Avatar)
+		stored = u.WechatRefreshToken
+		return nil
+	})
+	repo := NewCachedUserRepository(d, cachemocks.NewMockUserCache(ctrl), cipher)
+	err = repo.Create(context.Background(), domain.User{
+		Nickname: "Tom",
+		Avatar:   "avatar.png",
+		WechatInfo: domain.WechatInfo{
+			OpenId:       "openid",
+			RefreshToken: "refresh",
+		},
+	})
+	require.NoError(t, err)
+
+	d.EXPECT().FindById(gomock.Any(), int64(1)).Return(dao.User{Id: 1, WechatRefreshToken: stored}, nil)
+	token, err := repo.FindWechatRefreshToken(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "refresh", token)
+}
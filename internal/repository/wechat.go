@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"webBook/internal/domain"
+	"webBook/internal/repository/cache"
+)
+
+var ErrWechatSessionNotFound = errors.New("小程序 session 不存在或者已经过期")
+
+type WechatSessionRepository interface {
+	Store(ctx context.Context, id string, s domain.WechatSession, expiration time.Duration) error
+	// Find 不存在或者已经过期返回 ErrWechatSessionNotFound
+	Find(ctx context.Context, id string) (domain.WechatSession, error)
+}
+
+type CachedWechatSessionRepository struct {
+	cache cache.WechatSessionCache
+}
+
+func NewWechatSessionRepository(c cache.WechatSessionCache) WechatSessionRepository {
+	return &CachedWechatSessionRepository{
+		cache: c,
+	}
+}
+
+func (repo *CachedWechatSessionRepository) Store(ctx context.Context, id string,
+	s domain.WechatSession, expiration time.Duration) error {
+	return repo.cache.Set(ctx, id, s, expiration)
+}
+
+func (repo *CachedWechatSessionRepository) Find(ctx context.Context, id string) (domain.WechatSession, error) {
+	s, err := repo.cache.Get(ctx, id)
+	if errors.Is(err, cache.ErrKeyNotExist) {
+		return domain.WechatSession{}, ErrWechatSessionNotFound
+	}
+	return s, err
+}
@@ -0,0 +1,118 @@
+package captcha
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+	"webBook/internal/domain"
+	"webBook/internal/repository"
+)
+
+const (
+	// KindDigits 看图输入数字
+	KindDigits = "digits"
+	// KindMath 看图计算算术题
+	KindMath = "math"
+)
+
+// Config 本地挑战的配置
+type Config struct {
+	// Kind 挑战的类型，digits 或者 math
+	Kind string
+	// Length digits 类型的数字个数
+	Length int
+	// Expiration 挑战的有效期
+	Expiration time.Duration
+	// MaxFailures 连续失败多少次之后需要挑战
+	MaxFailures int64
+	// FailureWindow 失败次数的统计周期
+	FailureWindow time.Duration
+}
+
+var _ Service = &LocalService{}
+
+// LocalService 在本地生成挑战，答案存放在 Redis 里面
+type LocalService struct {
+	repo repository.CaptchaRepository
+	cfg  Config
+}
+
+func NewLocalService(repo repository.CaptchaRepository, cfg Config) *LocalService {
+	return &LocalService{
+		repo: repo,
+		cfg:  cfg,
+	}
+}
+
+func (s *LocalService) Generate(ctx context.Context) (domain.Captcha, error) {
+	question, answer := s.challenge()
+	img, err := render(question)
+	if err != nil {
+		return domain.Captcha{}, err
+	}
+	id := uuid.New().String()
+	err = s.repo.Store(ctx, id, answer, s.cfg.Expiration)
+	if err != nil {
+		return domain.Captcha{}, err
+	}
+	return domain.Captcha{
+		Id:    id,
+		Image: img,
+	}, nil
+}
+
+func (s *LocalService) Verify(ctx context.Context, id, answer string) (bool, error) {
+	if id == "" || answer == "" {
+		return false, nil
+	}
+	expected, err := s.repo.Take(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return expected != "" && strings.TrimSpace(answer) == expected, nil
+}
+
+func (s *LocalService) Required(ctx context.Context, biz, key string) (bool, error) {
+	cnt, err := s.repo.GetFailure(ctx, biz, key)
+	if err != nil {
+		return false, err
+	}
+	return cnt >= s.cfg.MaxFailures, nil
+}
+
+func (s *LocalService) RecordFailure(ctx context.Context, biz, key string) error {
+	_, err := s.repo.IncrFailure(ctx, biz, key, s.cfg.FailureWindow)
+	return err
+}
+
+func (s *LocalService) ResetFailure(ctx context.Context, biz, key string) error {
+	return s.repo.ResetFailure(ctx, biz, key)
+}
+
+// challenge 生成题目和答案
+func (s *LocalService) challenge() (string, string) {
+	if s.cfg.Kind == KindMath {
+		a, b := rand.Intn(10)+1, rand.Intn(10)+1
+		switch rand.Intn(3) {
+		case 0:
+			return fmt.Sprintf("%d+%d=?", a, b), strconv.Itoa(a + b)
+		case 1:
+			// 保证结果不是负数
+			if a < b {
+				a, b = b, a
+			}
+			return fmt.Sprintf("%d-%d=?", a, b), strconv.Itoa(a - b)
+		default:
+			return fmt.Sprintf("%dx%d=?", a, b), strconv.Itoa(a * b)
+		}
+	}
+	digits := make([]byte, s.cfg.Length)
+	for i := range digits {
+		digits[i] = byte('0' + rand.Intn(10))
+	}
+	return string(digits), string(digits)
+}
@@ -0,0 +1,175 @@
+package captcha
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"image/png"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"webBook/internal/repository"
+	repomocks "webBook/internal/repository/mocks"
+)
+
+var testCfg = Config{
+	Kind:          KindDigits,
+	Length:        4,
+	Expiration:    time.Minute * 5,
+	MaxFailures:   3,
+	FailureWindow: time.Minute * 15,
+}
+
+func TestLocalService_Generate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := repomocks.NewMockCaptchaRepository(ctrl)
+	var answer string
+	repo.EXPECT().Store(gomock.Any(), gomock.Any(), gomock.Any(), time.Minute*5).
+		DoAndReturn(func(ctx context.Context, id, a string, exp time.Duration) error {
+			answer = a
+			return nil
+		})
+	svc := NewLocalService(repo, testCfg)
+	c, err := svc.Generate(context.Background())
+	require.NoError(t, err)
+	assert.NotEmpty(t, c.Id)
+	assert.Len(t, answer, 4)
+
+	// 图片必须是合法的 PNG
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(c.Image, "data:image/png;base64,"))
+	require.NoError(t, err)
+	_, err = png.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+}
+
+func TestLocalService_challenge(t *testing.T) {
+	svc := NewLocalService(nil, Config{Kind: KindMath})
+	for i := 0; i < 100; i++ {
+		question, answer := svc.challenge()
+		require.True(t, strings.HasSuffix(question, "=?"))
+		expr := strings.TrimSuffix(question, "=?")
+		var want int
+		switch {
+		case strings.Contains(expr, "+"):
+			a, b := splitInts(t, expr, "+")
+			want = a + b
+		case strings.Contains(expr, "-"):
+			a, b := splitInts(t, expr, "-")
+			want = a - b
+		default:
+			a, b := splitInts(t, expr, "x")
+			want = a * b
+		}
+		assert.True(t, want >= 0)
+		assert.Equal(t, strconv.Itoa(want), answer)
+		// 所有的字符都能画出来
+		for _, r := range question {
+			_, ok := glyphs[r]
+			assert.True(t, ok)
+		}
+	}
+}
+
+func TestLocalService_Verify(t *testing.T) {
+	testCases := []struct {
+		name   string
+		mock   func(ctrl *gomock.Controller) repository.CaptchaRepository
+		id     string
+		answer string
+
+		wantOk  bool
+		wantErr error
+	}{
+		{
+			name: "答案正确",
+			mock: func(ctrl *gomock.Controller) repository.CaptchaRepository {
+				repo := repomocks.NewMockCaptchaRepository(ctrl)
+				repo.EXPECT().Take(gomock.Any(), "abc").Return("1234", nil)
+				return repo
+			},
+			id:     "abc",
+			answer: " 1234 ",
+			wantOk: true,
+		},
+		{
+			name: "答案错误",
+			mock: func(ctrl *gomock.Controller) repository.CaptchaRepository {
+				repo := repomocks.NewMockCaptchaRepository(ctrl)
+				repo.EXPECT().Take(gomock.Any(), "abc").Return("1234", nil)
+				return repo
+			},
+			id:     "abc",
+			answer: "4321",
+		},
+		{
+			name: "挑战不存在或者已经用过了",
+			mock: func(ctrl *gomock.Controller) repository.CaptchaRepository {
+				repo := repomocks.NewMockCaptchaRepository(ctrl)
+				repo.EXPECT().Take(gomock.Any(), "abc").Return("", nil)
+				return repo
+			},
+			id:     "abc",
+			answer: "1234",
+		},
+		{
+			name: "没有提交挑战",
+			mock: func(ctrl *gomock.Controller) repository.CaptchaRepository {
+				return repomocks.NewMockCaptchaRepository(ctrl)
+			},
+		},
+		{
+			name: "redis 错误",
+			mock: func(ctrl *gomock.Controller) repository.CaptchaRepository {
+				repo := repomocks.NewMockCaptchaRepository(ctrl)
+				repo.EXPECT().Take(gomock.Any(), "abc").Return("", errors.New("redis错误"))
+				return repo
+			},
+			id:      "abc",
+			answer:  "1234",
+			wantErr: errors.New("redis错误"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewLocalService(tc.mock(ctrl), testCfg)
+			ok, err := svc.Verify(context.Background(), tc.id, tc.answer)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantOk, ok)
+		})
+	}
+}
+
+func TestLocalService_Required(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := repomocks.NewMockCaptchaRepository(ctrl)
+	repo.EXPECT().GetFailure(gomock.Any(), "login", "a@qq.com").Return(int64(2), nil)
+	repo.EXPECT().GetFailure(gomock.Any(), "login", "b@qq.com").Return(int64(3), nil)
+	svc := NewLocalService(repo, testCfg)
+
+	need, err := svc.Required(context.Background(), "login", "a@qq.com")
+	require.NoError(t, err)
+	assert.False(t, need)
+	need, err = svc.Required(context.Background(), "login", "b@qq.com")
+	require.NoError(t, err)
+	assert.True(t, need)
+}
+
+func splitInts(t *testing.T, expr, op string) (int, int) {
+	segs := strings.Split(expr, op)
+	require.Len(t, segs, 2)
+	a, err := strconv.Atoi(segs[0])
+	require.NoError(t, err)
+	b, err := strconv.Atoi(segs[1])
+	require.NoError(t, err)
+	return a, b
+}
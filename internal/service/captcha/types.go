@@ -0,0 +1,27 @@
+package captcha
+
+import (
+	"context"
+	"webBook/internal/domain"
+)
+
+// Verifier 校验用户提交的挑战答案
+// 本地实现里面 id 是挑战的 ID，answer 是用户的答案；
+// 接入第三方验证码的时候，可以分别对应第三方的 ticket 和 randstr
+type Verifier interface {
+	// Verify 不管对不对，同一个挑战只能校验一次
+	Verify(ctx context.Context, id, answer string) (bool, error)
+}
+
+type Service interface {
+	Verifier
+	// Generate 生成一个新的挑战
+	Generate(ctx context.Context) (domain.Captcha, error)
+
+	// Required 连续失败太多次之后，需要先通过挑战
+	Required(ctx context.Context, biz, key string) (bool, error)
+	// RecordFailure 记录一次失败，例如密码输错
+	RecordFailure(ctx context.Context, biz, key string) error
+	// ResetFailure 成功之后清空失败次数
+	ResetFailure(ctx context.Context, biz, key string) error
+}
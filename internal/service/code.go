@@ -5,39 +5,149 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"webBook/internal/domain"
+	"webBook/internal/i18n"
 	"webBook/internal/repository"
-	"webBook/internal/service/sms"
+	"webBook/internal/service/sms/auth"
+	"webBook/pkg/limiter"
+	"webBook/pkg/logger"
 )
 
-var ErrCodeSendTooMany = repository.ErrCodeSendTooMany // 导出错误，表示验证码发送过于频繁。
+var (
+	ErrCodeSendTooMany  = repository.ErrCodeSendTooMany // 导出错误，表示验证码发送过于频繁。
+	ErrUnknownCodeBiz   = errors.New("未配置该业务的验证码")      // 业务没有配置验证码策略。
+	ErrCodeQuotaExhaust = errors.New("验证码发送额度已用完")      // 触发了每日的发送额度。
+)
 
 type CodeService interface {
-	Send(ctx context.Context, biz, phone string) error
+	// Send 发送验证码，ip 是发起请求的客户端 IP，内部调用可以传空字符串
+	Send(ctx context.Context, biz, phone, ip string) error
 	Verify(ctx context.Context,
 		biz, phone, inputCode string) (bool, error)
 }
 
+// CodeBizConfig 某个业务的验证码配置
+type CodeBizConfig struct {
+	// TplToken 短信服务授权的模板 token，而不是直接使用模板 ID
+	TplToken auth.TokenSource
+	// LocaleTplTokens 每种语言的模板 token，key 是 en-US 这种格式，没有配置的语言用 TplToken
+	LocaleTplTokens map[string]auth.TokenSource
+	domain.CodePolicy
+}
+
+// tplToken 按照 ctx 里面的语言选择模板
+func (c CodeBizConfig) tplToken(ctx context.Context) auth.TokenSource {
+	if token, ok := c.LocaleTplTokens[i18n.FromContext(ctx).String()]; ok {
+		return token
+	}
+	return c.TplToken
+}
+
+// CodeQuota 每日发送额度，每个维度一个限流器，nil 代表这个维度不限制
+type CodeQuota struct {
+	Phone limiter.Limiter // 每个手机号码
+	IP    limiter.Limiter // 每个客户端 IP
+	Biz   limiter.Limiter // 每个业务
+}
+
 type codeService struct {
 	repo repository.CodeRepository // repo字段，指向CodeRepository结构体实例，用于仓库层操作。
-	sms  sms.Service               // sms字段，指向sms.Service结构体实例，用于短信服务层操作。
+	sms  auth.Service              // sms字段，用模板 token 发短信，token 要是签发给对应业务的。
+	// bizs 每个业务的验证码配置，key 是 biz
+	bizs  map[string]CodeBizConfig
+	quota CodeQuota
 }
 
-func NewCodeService(repo repository.CodeRepository, smsSvc sms.Service) CodeService {
+func NewCodeService(repo repository.CodeRepository, smsSvc auth.Service,
+	bizs map[string]CodeBizConfig, quota CodeQuota) CodeService {
 	return &codeService{
-		repo: repo,
-		sms:  smsSvc,
+		repo:  repo,
+		sms:   smsSvc,
+		bizs:  bizs,
+		quota: quota,
 	}
 }
 
 // Send 方法，发送短信验证码。
-func (svc *codeService) Send(ctx context.Context, biz, phone string) error {
-	code := svc.generate()                     // 生成验证码。
-	err := svc.repo.Set(ctx, biz, phone, code) // 将验证码存储到redis中。
+func (svc *codeService) Send(ctx context.Context, biz, phone, ip string) error {
+	cfg, ok := svc.bizs[biz]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownCodeBiz, biz)
+	}
+	quotas, err := svc.takeQuota(ctx, biz, phone, ip) // 占用每日的发送额度。
+	if err != nil {
+		return err
+	}
+	err = svc.send(ctx, biz, phone, cfg)
+	if err != nil {
+		// 发送太频繁或者没有发出去，额度还回去
+		svc.refundQuota(ctx, quotas)
+	}
+	return err
+}
+
+func (svc *codeService) send(ctx context.Context, biz, phone string, cfg CodeBizConfig) error {
+	code := svc.generate(cfg.CodePolicy)                       // 按照业务的策略生成验证码。
+	err := svc.repo.Set(ctx, biz, phone, code, cfg.CodePolicy) // 将验证码存储到redis中。
 	if err != nil {
 		return err // 如果存储过程中出现错误，直接返回错误。
 	}
-	const codeTplId = "1877556"                                // 短信模板ID，通常从配置中获取。
-	return svc.sms.Send(ctx, codeTplId, []string{code}, phone) // 发送短信。
+	token, err := cfg.tplToken(ctx).Token() // 按照用户的语言选择模板。
+	if err != nil {
+		return err
+	}
+	return svc.sms.Send(ctx, biz, token, []string{code}, phone)
+}
+
+// quotaKey 一个维度的额度，ticket 是占用之后拿到的，退还的时候用
+type quotaKey struct {
+	l      limiter.Limiter
+	key    string
+	ticket limiter.Ticket
+}
+
+// takeQuota 方法，依次占用 IP、手机号码和业务的每日额度，任何一个用完了都不能发送，
+// 并且把前面已经占用的还回去。IP 放在最前面，已经超额的 IP 不会消耗别人的手机号码和业务的额度。
+func (svc *codeService) takeQuota(ctx context.Context, biz, phone, ip string) ([]quotaKey, error) {
+	var keys []quotaKey
+	if ip != "" {
+		// 内部调用没有 IP
+		keys = append(keys, quotaKey{l: svc.quota.IP, key: "code_quota:ip:" + ip})
+	}
+	keys = append(keys,
+		quotaKey{l: svc.quota.Phone, key: "code_quota:phone:" + phone},
+		quotaKey{l: svc.quota.Biz, key: "code_quota:biz:" + biz})
+	taken := make([]quotaKey, 0, len(keys))
+	for _, k := range keys {
+		if k.l == nil {
+			continue
+		}
+		ticket, limited, err := limiter.Take(ctx, k.l, k.key)
+		if err == nil && !limited {
+			k.ticket = ticket
+			taken = append(taken, k)
+			continue
+		}
+		svc.refundQuota(ctx, taken)
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrCodeQuotaExhaust
+	}
+	return taken, nil
+}
+
+// refundQuota 方法，退还额度。退还失败只是少发几条，记录一下就可以。
+func (svc *codeService) refundQuota(ctx context.Context, keys []quotaKey) {
+	// 请求超时了也要退还
+	ctx = context.WithoutCancel(ctx)
+	for _, k := range keys {
+		err := limiter.Refund(ctx, k.l, k.ticket)
+		if err != nil {
+			logger.FromContext(ctx).Error("退还验证码额度失败",
+				logger.Field{Key: "key", Val: k.key}, logger.Field{Key: "err", Val: err})
+		}
+	}
 }
 
 // Verify 方法，验证输入的验证码是否正确。
@@ -50,8 +160,11 @@ func (svc *codeService) Verify(ctx context.Context, biz, phone, inputCode string
 	return ok, err // 返回验证结果和可能的错误。
 }
 
-// generate 方法，生成六位随机验证码。
-func (svc *codeService) generate() string {
-	code := rand.Intn(1000000)       // 生成一个0到999999之间的随机数。
-	return fmt.Sprintf("%06d", code) // 格式化为六位字符串，不足前面补零。
+// generate 方法，从 Alphabet 中随机挑选字符，生成 Length 位的验证码。
+func (svc *codeService) generate(policy domain.CodePolicy) string {
+	code := make([]byte, policy.Length)
+	for i := range code {
+		code[i] = policy.Alphabet[rand.Intn(len(policy.Alphabet))]
+	}
+	return string(code)
 }
@@ -1,10 +1,361 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+	"strings"
 	"testing"
+	"time"
+	"webBook/internal/domain"
+	"webBook/internal/i18n"
+	"webBook/internal/repository"
+	repomocks "webBook/internal/repository/mocks"
+	"webBook/internal/service/sms/auth"
+	authmocks "webBook/internal/service/sms/auth/mocks"
+	"webBook/pkg/limiter"
+	limitermocks "webBook/pkg/limiter/mocks"
 )
 
 func TestCodeGenerate(t *testing.T) {
 	t.Log(fmt.Sprintf("%06d", 1))
 }
+
+func Test_codeService_generate(t *testing.T) {
+	svc := &codeService{}
+	code := svc.generate(domain.CodePolicy{
+		Length:   8,
+		Alphabet: "ABC",
+	})
+	assert.Len(t, code, 8)
+	for _, c := range code {
+		assert.True(t, strings.ContainsRune("ABC", c))
+	}
+}
+
+func Test_codeService_Send(t *testing.T) {
+	loginPolicy := domain.CodePolicy{
+		Length:         6,
+		Alphabet:       "0123456789",
+		Expiration:     time.Minute * 10,
+		ResendInterval: time.Minute,
+		MaxAttempts:    3,
+	}
+	resetPolicy := domain.CodePolicy{
+		Length:         4,
+		Alphabet:       "7",
+		Expiration:     time.Minute * 5,
+		ResendInterval: time.Minute * 2,
+		MaxAttempts:    5,
+	}
+	bizs := map[string]CodeBizConfig{
+		"login": {TplToken: auth.StaticToken("login-token"), CodePolicy: loginPolicy,
+			LocaleTplTokens: map[string]auth.TokenSource{"en-US": auth.StaticToken("login-token-en")}},
+		"reset_password": {TplToken: auth.StaticToken("reset-token"), CodePolicy: resetPolicy},
+	}
+	testCases := []struct {
+		name   string
+		mock   func(ctrl *gomock.Controller) (repository.CodeRepository, auth.Service)
+		biz    string
+		locale language.Tag
+
+		wantErr error
+	}{
+		{
+			name: "发送成功",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, auth.Service) {
+				repo := repomocks.NewMockCodeRepository(ctrl)
+				smsSvc := authmocks.NewMockService(ctrl)
+				repo.EXPECT().Set(gomock.Any(), "login", "15212345678",
+					gomock.Any(), loginPolicy).Return(nil)
+				smsSvc.EXPECT().Send(gomock.Any(), "login", "login-token",
+					gomock.Any(), "15212345678").Return(nil)
+				return repo, smsSvc
+			},
+			biz: "login",
+		},
+		{
+			name: "不同业务使用不同的策略和模板",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, auth.Service) {
+				repo := repomocks.NewMockCodeRepository(ctrl)
+				smsSvc := authmocks.NewMockService(ctrl)
+				repo.EXPECT().Set(gomock.Any(), "reset_password", "15212345678",
+					"7777", resetPolicy).Return(nil)
+				smsSvc.EXPECT().Send(gomock.Any(), "reset_password", "reset-token",
+					[]string{"7777"}, "15212345678").Return(nil)
+				return repo, smsSvc
+			},
+			biz: "reset_password",
+		},
+		{
+			name: "按照语言选择模板",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, auth.Service) {
+				repo := repomocks.NewMockCodeRepository(ctrl)
+				smsSvc := authmocks.NewMockService(ctrl)
+				repo.EXPECT().Set(gomock.Any(), "login", "15212345678",
+					gomock.Any(), loginPolicy).Return(nil)
+				smsSvc.EXPECT().Send(gomock.Any(), "login", "login-token-en",
+					gomock.Any(), "15212345678").Return(nil)
+				return repo, smsSvc
+			},
+			biz:    "login",
+			locale: i18n.EnUS,
+		},
+		{
+			name: "没有配置这个语言的模板",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, auth.Service) {
+				repo := repomocks.NewMockCodeRepository(ctrl)
+				smsSvc := authmocks.NewMockService(ctrl)
+				repo.EXPECT().Set(gomock.Any(), "reset_password", "15212345678",
+					"7777", resetPolicy).Return(nil)
+				smsSvc.EXPECT().Send(gomock.Any(), "reset_password", "reset-token",
+					[]string{"7777"}, "15212345678").Return(nil)
+				return repo, smsSvc
+			},
+			biz:    "reset_password",
+			locale: i18n.EnUS,
+		},
+		{
+			name: "未配置的业务",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, auth.Service) {
+				return repomocks.NewMockCodeRepository(ctrl), authmocks.NewMockService(ctrl)
+			},
+			biz:     "bind_phone",
+			wantErr: ErrUnknownCodeBiz,
+		},
+		{
+			name: "发送太频繁",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, auth.Service) {
+				repo := repomocks.NewMockCodeRepository(ctrl)
+				repo.EXPECT().Set(gomock.Any(), "login", "15212345678",
+					gomock.Any(), loginPolicy).Return(repository.ErrCodeSendTooMany)
+				return repo, authmocks.NewMockService(ctrl)
+			},
+			biz:     "login",
+			wantErr: ErrCodeSendTooMany,
+		},
+		{
+			name: "短信发送失败",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, auth.Service) {
+				repo := repomocks.NewMockCodeRepository(ctrl)
+				smsSvc := authmocks.NewMockService(ctrl)
+				repo.EXPECT().Set(gomock.Any(), "login", "15212345678",
+					gomock.Any(), loginPolicy).Return(nil)
+				smsSvc.EXPECT().Send(gomock.Any(), "login", "login-token",
+					gomock.Any(), "15212345678").Return(errors.New("短信服务错误"))
+				return repo, smsSvc
+			},
+			biz:     "login",
+			wantErr: errors.New("短信服务错误"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repo, smsSvc := tc.mock(ctrl)
+			svc := NewCodeService(repo, smsSvc, bizs, CodeQuota{})
+			ctx := context.Background()
+			if tc.locale != language.Und {
+				ctx = i18n.WithLocale(ctx, tc.locale)
+			}
+			err := svc.Send(ctx, tc.biz, "15212345678", "")
+			if errors.Is(tc.wantErr, ErrUnknownCodeBiz) {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+// refundLimiter 可以退还额度的限流器
+type refundLimiter struct {
+	*limitermocks.MockLimiter
+	*limitermocks.MockRefunder
+}
+
+func newRefundLimiter(ctrl *gomock.Controller) refundLimiter {
+	return refundLimiter{
+		MockLimiter:  limitermocks.NewMockLimiter(ctrl),
+		MockRefunder: limitermocks.NewMockRefunder(ctrl),
+	}
+}
+
+// ticket 测试里面限流器返回的 Ticket
+func ticket(key string) limiter.Ticket {
+	return limiter.Ticket{Key: key, Window: "20240101"}
+}
+
+func Test_codeService_SendQuota(t *testing.T) {
+	bizs := map[string]CodeBizConfig{
+		"login": {
+			TplToken: auth.StaticToken("login-token"),
+			CodePolicy: domain.CodePolicy{
+				Length:   6,
+				Alphabet: "0123456789",
+			},
+		},
+	}
+	const (
+		ipKey    = "code_quota:ip:127.0.0.1"
+		phoneKey = "code_quota:phone:15212345678"
+		bizKey   = "code_quota:biz:login"
+	)
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (repository.CodeRepository, auth.Service, CodeQuota)
+		ip   string
+
+		wantErr error
+	}{
+		{
+			name: "额度充足",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, auth.Service, CodeQuota) {
+				ipL := newRefundLimiter(ctrl)
+				ipL.MockRefunder.EXPECT().Take(gomock.Any(), ipKey).Return(ticket(ipKey), false, nil)
+				phoneL := newRefundLimiter(ctrl)
+				phoneL.MockRefunder.EXPECT().Take(gomock.Any(), phoneKey).Return(ticket(phoneKey), false, nil)
+				bizL := newRefundLimiter(ctrl)
+				bizL.MockRefunder.EXPECT().Take(gomock.Any(), bizKey).Return(ticket(bizKey), false, nil)
+				repo := repomocks.NewMockCodeRepository(ctrl)
+				repo.EXPECT().Set(gomock.Any(), "login", "15212345678",
+					gomock.Any(), gomock.Any()).Return(nil)
+				smsSvc := authmocks.NewMockService(ctrl)
+				smsSvc.EXPECT().Send(gomock.Any(), "login", "login-token",
+					gomock.Any(), "15212345678").Return(nil)
+				return repo, smsSvc, CodeQuota{Phone: phoneL, IP: ipL, Biz: bizL}
+			},
+			ip: "127.0.0.1",
+		},
+		{
+			name: "IP 额度用完，不占用手机号码和业务的额度",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, auth.Service, CodeQuota) {
+				ipL := newRefundLimiter(ctrl)
+				ipL.MockRefunder.EXPECT().Take(gomock.Any(), ipKey).Return(ticket(ipKey), true, nil)
+				return repomocks.NewMockCodeRepository(ctrl), authmocks.NewMockService(ctrl),
+					CodeQuota{IP: ipL, Phone: newRefundLimiter(ctrl), Biz: newRefundLimiter(ctrl)}
+			},
+			ip:      "127.0.0.1",
+			wantErr: ErrCodeQuotaExhaust,
+		},
+		{
+			name: "手机号码额度用完，退还 IP 的额度",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, auth.Service, CodeQuota) {
+				ipL := newRefundLimiter(ctrl)
+				ipL.MockRefunder.EXPECT().Take(gomock.Any(), ipKey).Return(ticket(ipKey), false, nil)
+				ipL.MockRefunder.EXPECT().Refund(gomock.Any(), ticket(ipKey)).Return(nil)
+				phoneL := newRefundLimiter(ctrl)
+				phoneL.MockRefunder.EXPECT().Take(gomock.Any(), phoneKey).Return(ticket(phoneKey), true, nil)
+				return repomocks.NewMockCodeRepository(ctrl), authmocks.NewMockService(ctrl),
+					CodeQuota{IP: ipL, Phone: phoneL, Biz: newRefundLimiter(ctrl)}
+			},
+			ip:      "127.0.0.1",
+			wantErr: ErrCodeQuotaExhaust,
+		},
+		{
+			name: "业务额度用完，退还 IP 和手机号码的额度",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, auth.Service, CodeQuota) {
+				ipL := newRefundLimiter(ctrl)
+				ipL.MockRefunder.EXPECT().Take(gomock.Any(), ipKey).Return(ticket(ipKey), false, nil)
+				ipL.MockRefunder.EXPECT().Refund(gomock.Any(), ticket(ipKey)).Return(nil)
+				phoneL := newRefundLimiter(ctrl)
+				phoneL.MockRefunder.EXPECT().Take(gomock.Any(), phoneKey).Return(ticket(phoneKey), false, nil)
+				phoneL.MockRefunder.EXPECT().Refund(gomock.Any(), ticket(phoneKey)).Return(nil)
+				bizL := newRefundLimiter(ctrl)
+				bizL.MockRefunder.EXPECT().Take(gomock.Any(), bizKey).Return(ticket(bizKey), true, nil)
+				return repomocks.NewMockCodeRepository(ctrl), authmocks.NewMockService(ctrl),
+					CodeQuota{IP: ipL, Phone: phoneL, Biz: bizL}
+			},
+			ip:      "127.0.0.1",
+			wantErr: ErrCodeQuotaExhaust,
+		},
+		{
+			name: "没有 IP 的内部调用不检查 IP 额度",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, auth.Service, CodeQuota) {
+				repo := repomocks.NewMockCodeRepository(ctrl)
+				repo.EXPECT().Set(gomock.Any(), "login", "15212345678",
+					gomock.Any(), gomock.Any()).Return(nil)
+				smsSvc := authmocks.NewMockService(ctrl)
+				smsSvc.EXPECT().Send(gomock.Any(), "login", "login-token",
+					gomock.Any(), "15212345678").Return(nil)
+				return repo, smsSvc, CodeQuota{IP: newRefundLimiter(ctrl)}
+			},
+		},
+		{
+			name: "发送太频繁，退还额度",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, auth.Service, CodeQuota) {
+				phoneL := newRefundLimiter(ctrl)
+				phoneL.MockRefunder.EXPECT().Take(gomock.Any(), phoneKey).Return(ticket(phoneKey), false, nil)
+				phoneL.MockRefunder.EXPECT().Refund(gomock.Any(), ticket(phoneKey)).Return(nil)
+				bizL := newRefundLimiter(ctrl)
+				bizL.MockRefunder.EXPECT().Take(gomock.Any(), bizKey).Return(ticket(bizKey), false, nil)
+				bizL.MockRefunder.EXPECT().Refund(gomock.Any(), ticket(bizKey)).Return(nil)
+				repo := repomocks.NewMockCodeRepository(ctrl)
+				repo.EXPECT().Set(gomock.Any(), "login", "15212345678",
+					gomock.Any(), gomock.Any()).Return(repository.ErrCodeSendTooMany)
+				return repo, authmocks.NewMockService(ctrl), CodeQuota{Phone: phoneL, Biz: bizL}
+			},
+			wantErr: ErrCodeSendTooMany,
+		},
+		{
+			name: "短信发送失败，退还额度",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, auth.Service, CodeQuota) {
+				ipL := newRefundLimiter(ctrl)
+				ipL.MockRefunder.EXPECT().Take(gomock.Any(), ipKey).Return(ticket(ipKey), false, nil)
+				ipL.MockRefunder.EXPECT().Refund(gomock.Any(), ticket(ipKey)).Return(nil)
+				phoneL := newRefundLimiter(ctrl)
+				phoneL.MockRefunder.EXPECT().Take(gomock.Any(), phoneKey).Return(ticket(phoneKey), false, nil)
+				// 退还失败不影响返回的错误
+				phoneL.MockRefunder.EXPECT().Refund(gomock.Any(), ticket(phoneKey)).Return(errors.New("redis错误"))
+				repo := repomocks.NewMockCodeRepository(ctrl)
+				repo.EXPECT().Set(gomock.Any(), "login", "15212345678",
+					gomock.Any(), gomock.Any()).Return(nil)
+				smsSvc := authmocks.NewMockService(ctrl)
+				smsSvc.EXPECT().Send(gomock.Any(), "login", "login-token",
+					gomock.Any(), "15212345678").Return(errors.New("短信服务错误"))
+				return repo, smsSvc, CodeQuota{IP: ipL, Phone: phoneL}
+			},
+			ip:      "127.0.0.1",
+			wantErr: errors.New("短信服务错误"),
+		},
+		{
+			name: "限流器不支持退还",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, auth.Service, CodeQuota) {
+				phoneL := limitermocks.NewMockLimiter(ctrl)
+				phoneL.EXPECT().Limit(gomock.Any(), phoneKey).Return(false, nil)
+				repo := repomocks.NewMockCodeRepository(ctrl)
+				repo.EXPECT().Set(gomock.Any(), "login", "15212345678",
+					gomock.Any(), gomock.Any()).Return(repository.ErrCodeSendTooMany)
+				return repo, authmocks.NewMockService(ctrl), CodeQuota{Phone: phoneL}
+			},
+			wantErr: ErrCodeSendTooMany,
+		},
+		{
+			name: "限流器出错",
+			mock: func(ctrl *gomock.Controller) (repository.CodeRepository, auth.Service, CodeQuota) {
+				phoneL := newRefundLimiter(ctrl)
+				phoneL.MockRefunder.EXPECT().Take(gomock.Any(), gomock.Any()).
+					Return(limiter.Ticket{}, false, errors.New("redis错误"))
+				return repomocks.NewMockCodeRepository(ctrl), authmocks.NewMockService(ctrl),
+					CodeQuota{Phone: phoneL}
+			},
+			wantErr: errors.New("redis错误"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repo, smsSvc, quota := tc.mock(ctrl)
+			svc := NewCodeService(repo, smsSvc, bizs, quota)
+			err := svc.Send(context.Background(), "login", "15212345678", tc.ip)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
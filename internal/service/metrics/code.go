@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"webBook/internal/service"
+	"webBook/pkg/prometheusx"
+)
+
+var _ service.CodeService = &CodeService{}
+
+// CodeService 按照业务统计验证码发送和验证的结果
+type CodeService struct {
+	svc      service.CodeService
+	sends    *prometheus.CounterVec
+	verifies *prometheus.CounterVec
+}
+
+func NewCodeService(svc service.CodeService) service.CodeService {
+	return &CodeService{
+		svc: svc,
+		sends: prometheusx.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "webook",
+			Subsystem: "code",
+			Name:      "send_total",
+			Help:      "发送验证码的次数",
+		}, []string{"biz", "outcome"})),
+		verifies: prometheusx.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "webook",
+			Subsystem: "code",
+			Name:      "verify_total",
+			Help:      "验证验证码的次数",
+		}, []string{"biz", "outcome"})),
+	}
+}
+
+func (s *CodeService) Send(ctx context.Context, biz, phone, ip string) error {
+	err := s.svc.Send(ctx, biz, phone, ip)
+	var oc string
+	switch {
+	case err == nil:
+		oc = "success"
+	case errors.Is(err, service.ErrCodeSendTooMany):
+		oc = "too_many"
+	case errors.Is(err, service.ErrCodeQuotaExhaust):
+		oc = "quota_exhausted"
+	case errors.Is(err, service.ErrUnknownCodeBiz):
+		// biz 是调用方传进来的，不能作为标签，不然标签数量没有上限
+		biz = "unknown"
+		oc = "unknown_biz"
+	default:
+		oc = "error"
+	}
+	s.sends.WithLabelValues(biz, oc).Inc()
+	return err
+}
+
+func (s *CodeService) Verify(ctx context.Context, biz, phone, inputCode string) (bool, error) {
+	ok, err := s.svc.Verify(ctx, biz, phone, inputCode)
+	var oc string
+	switch {
+	case err != nil:
+		oc = "error"
+	case ok:
+		oc = "success"
+	default:
+		// 验证码不对，或者验证次数太多
+		oc = "failure"
+	}
+	s.verifies.WithLabelValues(biz, oc).Inc()
+	return ok, err
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"webBook/internal/service"
+	svcmocks "webBook/internal/service/mocks"
+)
+
+func TestCodeService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	svc := svcmocks.NewMockCodeService(ctrl)
+	svc.EXPECT().Send(gomock.Any(), "login", gomock.Any(), gomock.Any()).Return(nil)
+	svc.EXPECT().Send(gomock.Any(), "login", gomock.Any(), gomock.Any()).Return(service.ErrCodeSendTooMany)
+	svc.EXPECT().Send(gomock.Any(), "login", gomock.Any(), gomock.Any()).Return(service.ErrCodeQuotaExhaust)
+	svc.EXPECT().Send(gomock.Any(), "abc", gomock.Any(), gomock.Any()).Return(service.ErrUnknownCodeBiz)
+	svc.EXPECT().Verify(gomock.Any(), "login", gomock.Any(), gomock.Any()).Return(true, nil)
+	svc.EXPECT().Verify(gomock.Any(), "login", gomock.Any(), gomock.Any()).Return(false, nil)
+	svc.EXPECT().Verify(gomock.Any(), "login", gomock.Any(), gomock.Any()).Return(false, errors.New("redis 出错"))
+
+	s := NewCodeService(svc).(*CodeService)
+	ctx := context.Background()
+	for _, biz := range []string{"login", "login", "login", "abc"} {
+		_ = s.Send(ctx, biz, "15212345678", "127.0.0.1")
+	}
+	for i := 0; i < 3; i++ {
+		_, _ = s.Verify(ctx, "login", "15212345678", "123456")
+	}
+
+	sends := map[[2]string]float64{
+		{"login", "success"}:         1,
+		{"login", "too_many"}:        1,
+		{"login", "quota_exhausted"}: 1,
+		{"unknown", "unknown_biz"}:   1,
+	}
+	for lvs, val := range sends {
+		assert.Equal(t, val, testutil.ToFloat64(s.sends.WithLabelValues(lvs[0], lvs[1])), lvs)
+	}
+	for _, oc := range []string{"success", "failure", "error"} {
+		assert.Equal(t, float64(1), testutil.ToFloat64(s.verifies.WithLabelValues("login", oc)), oc)
+	}
+}
@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/service/oauth2/state.go
+
+// Package oauth2mocks is a generated GoMock package.
+package oauth2mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockStateService is a mock of StateService interface.
+type MockStateService struct {
+	ctrl     *gomock.Controller
+	recorder *MockStateServiceMockRecorder
+}
+
+// MockStateServiceMockRecorder is the mock recorder for MockStateService.
+type MockStateServiceMockRecorder struct {
+	mock *MockStateService
+}
+
+// NewMockStateService creates a new mock instance.
+func NewMockStateService(ctrl *gomock.Controller) *MockStateService {
+	mock := &MockStateService{ctrl: ctrl}
+	mock.recorder = &MockStateServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStateService) EXPECT() *MockStateServiceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockStateService) Create(ctx context.Context, provider, returnURL string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, provider, returnURL)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockStateServiceMockRecorder) Create(ctx, provider, returnURL interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockStateService)(nil).Create), ctx, provider, returnURL)
+}
+
+// Take mocks base method.
+func (m *MockStateService) Take(ctx context.Context, provider, state string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Take", ctx, provider, state)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Take indicates an expected call of Take.
+func (mr *MockStateServiceMockRecorder) Take(ctx, provider, state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Take", reflect.TypeOf((*MockStateService)(nil).Take), ctx, provider, state)
+}
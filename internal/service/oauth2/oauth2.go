@@ -0,0 +1,172 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"webBook/internal/domain"
+)
+
+var ErrExchangeFailed = errors.New("授权码换取 token 失败")
+
+// Config 普通 OAuth2 提供方的配置，例如 GitHub
+type Config struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
+	Scopes       []string
+	// 用户信息里面各个字段的名字，不同的提供方不一样
+	SubjectField string
+	EmailField   string
+	NameField    string
+}
+
+// GenericProvider 没有 OIDC 的提供方，用户信息只能调用 UserInfoURL 来拿
+type GenericProvider struct {
+	cfg    Config
+	client *http.Client
+}
+
+func NewGenericProvider(cfg Config, client *http.Client) *GenericProvider {
+	if cfg.SubjectField == "" {
+		cfg.SubjectField = "id"
+	}
+	if cfg.EmailField == "" {
+		cfg.EmailField = "email"
+	}
+	if cfg.NameField == "" {
+		cfg.NameField = "name"
+	}
+	return &GenericProvider{
+		cfg:    cfg,
+		client: client,
+	}
+}
+
+func (p *GenericProvider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *GenericProvider) AuthURL(ctx context.Context, state string) (string, error) {
+	return buildAuthURL(p.cfg.AuthURL, url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.cfg.ClientID},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"scope":         {strings.Join(p.cfg.Scopes, " ")},
+		"state":         {state},
+	})
+}
+
+func (p *GenericProvider) Exchange(ctx context.Context, code string) (Token, error) {
+	return exchange(ctx, p.client, p.cfg.TokenURL, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"client_id":     {p.cfg.ClientID},
+		"client_secret": {p.cfg.ClientSecret},
+	})
+}
+
+func (p *GenericProvider) UserInfo(ctx context.Context, token Token) (domain.UserIdentity, error) {
+	info, err := fetchUserInfo(ctx, p.client, p.cfg.UserInfoURL, token.AccessToken)
+	if err != nil {
+		return domain.UserIdentity{}, err
+	}
+	sub := field(info, p.cfg.SubjectField)
+	if sub == "" {
+		return domain.UserIdentity{}, fmt.Errorf("用户信息缺少字段 %s", p.cfg.SubjectField)
+	}
+	return domain.UserIdentity{
+		Provider: p.cfg.Name,
+		Subject:  sub,
+		Email:    field(info, p.cfg.EmailField),
+		Name:     field(info, p.cfg.NameField),
+	}, nil
+}
+
+func buildAuthURL(endpoint string, params url.Values) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func exchange(ctx context.Context, client *http.Client, tokenURL string, form url.Values) (Token, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL,
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// GitHub 默认返回的是表单格式
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return Token{}, err
+	}
+	defer resp.Body.Close()
+	var token Token
+	err = json.NewDecoder(resp.Body).Decode(&token)
+	if err != nil {
+		return Token{}, fmt.Errorf("%w: %w", ErrExchangeFailed, err)
+	}
+	if resp.StatusCode != http.StatusOK || token.Error != "" {
+		return Token{}, fmt.Errorf("%w: status %d, error %s %s", ErrExchangeFailed,
+			resp.StatusCode, token.Error, token.ErrorDescription)
+	}
+	if token.AccessToken == "" && token.IDToken == "" {
+		return Token{}, fmt.Errorf("%w: 响应里面没有 token", ErrExchangeFailed)
+	}
+	return token, nil
+}
+
+func fetchUserInfo(ctx context.Context, client *http.Client,
+	endpoint string, accessToken string) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("获取用户信息失败 status %d, body %s", resp.StatusCode, body)
+	}
+	dec := json.NewDecoder(resp.Body)
+	// GitHub 的 id 是数字，避免变成浮点数
+	dec.UseNumber()
+	var info map[string]any
+	err = dec.Decode(&info)
+	return info, err
+}
+
+func field(info map[string]any, name string) string {
+	val, ok := info[name]
+	if !ok || val == nil {
+		return ""
+	}
+	if str, ok := val.(string); ok {
+		return str
+	}
+	return fmt.Sprint(val)
+}
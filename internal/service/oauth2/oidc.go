@@ -0,0 +1,253 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"webBook/internal/domain"
+)
+
+var ErrInvalidIDToken = errors.New("非法的 ID token")
+
+// OIDCConfig OIDC 提供方的配置，端点都从 Issuer 的 discovery 文档里面拿
+type OIDCConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// 不需要包含 openid，会自动加上
+	Scopes []string
+}
+
+// IDTokenClaims ID token 里面我们关心的字段
+type IDTokenClaims struct {
+	jwt.RegisteredClaims
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCProvider 通过 discovery 文档找到各个端点，用 JWKS 校验 ID token
+// discovery 文档和公钥都是第一次用到的时候才去拿，提供方挂了不影响启动
+type OIDCProvider struct {
+	cfg    OIDCConfig
+	client *http.Client
+	// keysRefreshInterval 两次拉取 JWKS 至少间隔多久，
+	// 避免随便编一个 kid 就能让我们不停地请求提供方
+	keysRefreshInterval time.Duration
+
+	mu   sync.Mutex
+	doc  *discovery
+	keys map[string]*rsa.PublicKey
+	// keysRefreshAt 上一次开始拉取 JWKS 的时间
+	keysRefreshAt time.Time
+}
+
+func NewOIDCProvider(cfg OIDCConfig, client *http.Client) *OIDCProvider {
+	return &OIDCProvider{
+		cfg:                 cfg,
+		client:              client,
+		keysRefreshInterval: time.Minute,
+	}
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *OIDCProvider) AuthURL(ctx context.Context, state string) (string, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	scopes := append([]string{"openid"}, p.cfg.Scopes...)
+	return buildAuthURL(doc.AuthorizationEndpoint, url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.cfg.ClientID},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"scope":         {strings.Join(scopes, " ")},
+		"state":         {state},
+	})
+}
+
+func (p *OIDCProvider) Exchange(ctx context.Context, code string) (Token, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return Token{}, err
+	}
+	token, err := exchange(ctx, p.client, doc.TokenEndpoint, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"client_id":     {p.cfg.ClientID},
+		"client_secret": {p.cfg.ClientSecret},
+	})
+	if err != nil {
+		return Token{}, err
+	}
+	if token.IDToken == "" {
+		return Token{}, fmt.Errorf("%w: 响应里面没有 id_token", ErrInvalidIDToken)
+	}
+	claims, err := p.verify(ctx, token.IDToken)
+	if err != nil {
+		return Token{}, err
+	}
+	token.Claims = &claims
+	return token, nil
+}
+
+func (p *OIDCProvider) UserInfo(ctx context.Context, token Token) (domain.UserIdentity, error) {
+	if token.Claims == nil {
+		return domain.UserIdentity{}, fmt.Errorf("%w: 未校验的 token", ErrInvalidIDToken)
+	}
+	identity := domain.UserIdentity{
+		Provider: p.cfg.Name,
+		Subject:  token.Claims.Subject,
+		Name:     token.Claims.Name,
+	}
+	if token.Claims.EmailVerified {
+		identity.Email = token.Claims.Email
+	}
+	return identity, nil
+}
+
+// verify 校验签名、issuer、audience 和过期时间
+func (p *OIDCProvider) verify(ctx context.Context, idToken string) (IDTokenClaims, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return IDTokenClaims{}, err
+	}
+	var claims IDTokenClaims
+	_, err = jwt.ParseWithClaims(idToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, doc.JWKSURI, kid)
+	}, jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(doc.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired())
+	if err != nil {
+		return IDTokenClaims{}, fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+	if claims.Subject == "" {
+		return IDTokenClaims{}, fmt.Errorf("%w: 缺少 sub", ErrInvalidIDToken)
+	}
+	return claims, nil
+}
+
+func (p *OIDCProvider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.doc != nil {
+		return p.doc, nil
+	}
+	var doc discovery
+	err := p.getJSON(ctx, strings.TrimSuffix(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", &doc)
+	if err != nil {
+		return nil, fmt.Errorf("获取 discovery 文档失败 %w", err)
+	}
+	if doc.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("discovery 文档的 issuer %s 和配置的 %s 不一致", doc.Issuer, p.cfg.Issuer)
+	}
+	p.doc = &doc
+	return p.doc, nil
+}
+
+// key 找 kid 对应的公钥，找不到就重新拉一次 JWKS，提供方可能轮换了密钥。
+// 拉取的时候不持有锁，并且限制频率，不认识的 kid 在间隔之内直接拒绝
+func (p *OIDCProvider) key(ctx context.Context, jwksURI string, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	if key, ok := p.keys[kid]; ok {
+		p.mu.Unlock()
+		return key, nil
+	}
+	now := time.Now()
+	if now.Sub(p.keysRefreshAt) < p.keysRefreshInterval {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("找不到 kid %s 对应的公钥", kid)
+	}
+	// 先占住这一次拉取，其他请求不用再拉
+	p.keysRefreshAt = now
+	p.mu.Unlock()
+
+	keys, err := p.fetchKeys(ctx, jwksURI)
+	if err != nil {
+		return nil, fmt.Errorf("获取 JWKS 失败 %w", err)
+	}
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+	key, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("找不到 kid %s 对应的公钥", kid)
+	}
+	return key, nil
+}
+
+func (p *OIDCProvider) fetchKeys(ctx context.Context, jwksURI string) (map[string]*rsa.PublicKey, error) {
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	err := p.getJSON(ctx, jwksURI, &set)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, endpoint string, val any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(val)
+}
@@ -0,0 +1,299 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+	"webBook/internal/domain"
+)
+
+// fakeIdP 本地的 OIDC 提供方，签发什么样的 ID token 由 claims 决定
+type fakeIdP struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+	claims func(issuer string) jwt.Claims
+	// 签名用的 key，默认是 key
+	signKey *rsa.PrivateKey
+	// jwksHits 拉取 JWKS 的次数
+	jwksHits atomic.Int32
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	idp := &fakeIdP{key: key, kid: "key-1"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.URL,
+			"authorization_endpoint": idp.URL + "/authorize",
+			"token_endpoint":         idp.URL + "/token",
+			"jwks_uri":               idp.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		idp.jwksHits.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kid": idp.kid,
+				"kty": "RSA",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("code") != "good-code" || r.PostFormValue("client_secret") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.claims(idp.URL))
+		token.Header["kid"] = idp.kid
+		signKey := idp.signKey
+		if signKey == nil {
+			signKey = idp.key
+		}
+		idToken, err := token.SignedString(signKey)
+		require.NoError(t, err)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     idToken,
+		})
+	})
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+func validClaims(issuer string) jwt.Claims {
+	return IDTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   "user-123",
+			Audience:  jwt.ClaimStrings{"client"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+		Email:         "123@qq.com",
+		EmailVerified: true,
+		Name:          "Tom",
+	}
+}
+
+func TestOIDCProvider(t *testing.T) {
+	testCases := []struct {
+		name    string
+		code    string
+		claims  func(issuer string) jwt.Claims
+		signKey func(t *testing.T) *rsa.PrivateKey
+
+		wantErr      error
+		wantIdentity domain.UserIdentity
+	}{
+		{
+			name:   "登录成功",
+			code:   "good-code",
+			claims: validClaims,
+			wantIdentity: domain.UserIdentity{
+				Provider: "corp",
+				Subject:  "user-123",
+				Email:    "123@qq.com",
+				Name:     "Tom",
+			},
+		},
+		{
+			name: "邮箱没有验证过",
+			code: "good-code",
+			claims: func(issuer string) jwt.Claims {
+				c := validClaims(issuer).(IDTokenClaims)
+				c.EmailVerified = false
+				return c
+			},
+			wantIdentity: domain.UserIdentity{
+				Provider: "corp",
+				Subject:  "user-123",
+				Name:     "Tom",
+			},
+		},
+		{
+			name:    "授权码不对",
+			code:    "bad-code",
+			claims:  validClaims,
+			wantErr: ErrExchangeFailed,
+		},
+		{
+			name: "ID token 过期",
+			code: "good-code",
+			claims: func(issuer string) jwt.Claims {
+				c := validClaims(issuer).(IDTokenClaims)
+				c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+				return c
+			},
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name: "audience 不是我们",
+			code: "good-code",
+			claims: func(issuer string) jwt.Claims {
+				c := validClaims(issuer).(IDTokenClaims)
+				c.Audience = jwt.ClaimStrings{"another-client"}
+				return c
+			},
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name: "issuer 不对",
+			code: "good-code",
+			claims: func(issuer string) jwt.Claims {
+				c := validClaims(issuer).(IDTokenClaims)
+				c.Issuer = "https://evil.com"
+				return c
+			},
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name:   "签名不对",
+			code:   "good-code",
+			claims: validClaims,
+			signKey: func(t *testing.T) *rsa.PrivateKey {
+				key, err := rsa.GenerateKey(rand.Reader, 2048)
+				require.NoError(t, err)
+				return key
+			},
+			wantErr: ErrInvalidIDToken,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			idp := newFakeIdP(t)
+			idp.claims = tc.claims
+			if tc.signKey != nil {
+				idp.signKey = tc.signKey(t)
+			}
+			p := NewOIDCProvider(OIDCConfig{
+				Name:         "corp",
+				Issuer:       idp.URL,
+				ClientID:     "client",
+				ClientSecret: "secret",
+				RedirectURL:  "https://meoying.com/oauth2/corp/callback",
+			}, idp.Client())
+			ctx := context.Background()
+			token, err := p.Exchange(ctx, tc.code)
+			assert.ErrorIs(t, err, tc.wantErr)
+			if err != nil {
+				return
+			}
+			identity, err := p.UserInfo(ctx, token)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantIdentity, identity)
+		})
+	}
+}
+
+func TestOIDCProvider_KeyRefresh(t *testing.T) {
+	idp := newFakeIdP(t)
+	p := NewOIDCProvider(OIDCConfig{Issuer: idp.URL}, idp.Client())
+	ctx := context.Background()
+	jwksURI := idp.URL + "/jwks"
+
+	_, err := p.key(ctx, jwksURI, "unknown")
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), idp.jwksHits.Load())
+	// 不认识的 kid 在间隔之内不会再去拉
+	for i := 0; i < 10; i++ {
+		_, err = p.key(ctx, jwksURI, "random-kid")
+		assert.Error(t, err)
+	}
+	assert.Equal(t, int32(1), idp.jwksHits.Load())
+	// 已经拉到的 key 直接用
+	key, err := p.key(ctx, jwksURI, "key-1")
+	require.NoError(t, err)
+	assert.Equal(t, idp.key.N, key.N)
+
+	// 过了间隔之后提供方轮换了密钥，可以重新拉
+	p.keysRefreshInterval = 0
+	idp.kid = "key-2"
+	_, err = p.key(ctx, jwksURI, "key-2")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), idp.jwksHits.Load())
+}
+
+func TestOIDCProvider_AuthURL(t *testing.T) {
+	idp := newFakeIdP(t)
+	p := NewOIDCProvider(OIDCConfig{
+		Name:        "corp",
+		Issuer:      idp.URL,
+		ClientID:    "client",
+		RedirectURL: "https://meoying.com/oauth2/corp/callback",
+		Scopes:      []string{"email", "profile"},
+	}, idp.Client())
+	val, err := p.AuthURL(context.Background(), "my-state")
+	require.NoError(t, err)
+	u, err := url.Parse(val)
+	require.NoError(t, err)
+	assert.Equal(t, idp.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	q := u.Query()
+	assert.Equal(t, "openid email profile", q.Get("scope"))
+	assert.Equal(t, "my-state", q.Get("state"))
+	assert.Equal(t, "client", q.Get("client_id"))
+	assert.Equal(t, "https://meoying.com/oauth2/corp/callback", q.Get("redirect_uri"))
+}
+
+func TestGenericProvider(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		// GitHub 出错也是 200
+		if r.PostFormValue("code") != "good-code" {
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "bad_verification_code"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "access", "token_type": "bearer"})
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id": 12345678901, "login": "tom", "email": null}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	p := NewGenericProvider(Config{
+		Name:        "github",
+		TokenURL:    server.URL + "/token",
+		UserInfoURL: server.URL + "/user",
+		NameField:   "login",
+	}, server.Client())
+	ctx := context.Background()
+	_, err := p.Exchange(ctx, "bad-code")
+	assert.ErrorIs(t, err, ErrExchangeFailed)
+
+	token, err := p.Exchange(ctx, "good-code")
+	require.NoError(t, err)
+	identity, err := p.UserInfo(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, domain.UserIdentity{
+		Provider: "github",
+		Subject:  "12345678901",
+		Name:     "tom",
+	}, identity)
+}
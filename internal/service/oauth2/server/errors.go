@@ -0,0 +1,24 @@
+package server
+
+// Error OAuth2 协议里面的错误，Code 就是返回给 client 的 error 字段
+type Error struct {
+	Code        string
+	Description string
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+var (
+	ErrInvalidRequest          = &Error{Code: "invalid_request", Description: "请求参数不对"}
+	ErrInvalidClient           = &Error{Code: "invalid_client", Description: "client 不存在或者认证失败"}
+	ErrInvalidRedirectURI      = &Error{Code: "invalid_request", Description: "redirect_uri 没有注册"}
+	ErrInvalidGrant            = &Error{Code: "invalid_grant", Description: "授权码不对、已经过期或者 code_verifier 不对"}
+	ErrUnauthorizedClient      = &Error{Code: "unauthorized_client", Description: "client 不允许使用这种授权方式"}
+	ErrUnsupportedGrantType    = &Error{Code: "unsupported_grant_type", Description: "只支持 authorization_code 和 client_credentials"}
+	ErrUnsupportedResponseType = &Error{Code: "unsupported_response_type", Description: "只支持 code"}
+	ErrInvalidScope            = &Error{Code: "invalid_scope", Description: "申请的 scope 超出了 client 的范围"}
+	ErrAccessDenied            = &Error{Code: "access_denied", Description: "用户拒绝了授权"}
+	ErrInvalidClientMetadata   = &Error{Code: "invalid_client_metadata", Description: "注册 client 的信息不对"}
+)
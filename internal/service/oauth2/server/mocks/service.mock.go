@@ -0,0 +1,158 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/service/oauth2/server/service.go
+
+// Package servermocks is a generated GoMock package.
+package servermocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+	domain "webBook/internal/domain"
+	server "webBook/internal/service/oauth2/server"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockService is a mock of Service interface.
+type MockService struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceMockRecorder
+}
+
+// MockServiceMockRecorder is the mock recorder for MockService.
+type MockServiceMockRecorder struct {
+	mock *MockService
+}
+
+// NewMockService creates a new mock instance.
+func NewMockService(ctrl *gomock.Controller) *MockService {
+	mock := &MockService{ctrl: ctrl}
+	mock.recorder = &MockServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockService) EXPECT() *MockServiceMockRecorder {
+	return m.recorder
+}
+
+// AuthenticateClient mocks base method.
+func (m *MockService) AuthenticateClient(ctx context.Context, clientId, clientSecret string) (domain.OAuth2Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateClient", ctx, clientId, clientSecret)
+	ret0, _ := ret[0].(domain.OAuth2Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateClient indicates an expected call of AuthenticateClient.
+func (mr *MockServiceMockRecorder) AuthenticateClient(ctx, clientId, clientSecret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateClient", reflect.TypeOf((*MockService)(nil).AuthenticateClient), ctx, clientId, clientSecret)
+}
+
+// Authorize mocks base method.
+func (m *MockService) Authorize(ctx context.Context, uid int64, req server.AuthorizeRequest) (server.Authorization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authorize", ctx, uid, req)
+	ret0, _ := ret[0].(server.Authorization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authorize indicates an expected call of Authorize.
+func (mr *MockServiceMockRecorder) Authorize(ctx, uid, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authorize", reflect.TypeOf((*MockService)(nil).Authorize), ctx, uid, req)
+}
+
+// ClientCredentials mocks base method.
+func (m *MockService) ClientCredentials(ctx context.Context, clientId, clientSecret, scope string) (domain.OAuth2Grant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientCredentials", ctx, clientId, clientSecret, scope)
+	ret0, _ := ret[0].(domain.OAuth2Grant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientCredentials indicates an expected call of ClientCredentials.
+func (mr *MockServiceMockRecorder) ClientCredentials(ctx, clientId, clientSecret, scope interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientCredentials", reflect.TypeOf((*MockService)(nil).ClientCredentials), ctx, clientId, clientSecret, scope)
+}
+
+// ExchangeCode mocks base method.
+func (m *MockService) ExchangeCode(ctx context.Context, clientId, clientSecret, code, redirectURI, codeVerifier string) (domain.OAuth2Grant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExchangeCode", ctx, clientId, clientSecret, code, redirectURI, codeVerifier)
+	ret0, _ := ret[0].(domain.OAuth2Grant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExchangeCode indicates an expected call of ExchangeCode.
+func (mr *MockServiceMockRecorder) ExchangeCode(ctx, clientId, clientSecret, code, redirectURI, codeVerifier interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExchangeCode", reflect.TypeOf((*MockService)(nil).ExchangeCode), ctx, clientId, clientSecret, code, redirectURI, codeVerifier)
+}
+
+// IsRevoked mocks base method.
+func (m *MockService) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsRevoked", ctx, jti)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsRevoked indicates an expected call of IsRevoked.
+func (mr *MockServiceMockRecorder) IsRevoked(ctx, jti interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRevoked", reflect.TypeOf((*MockService)(nil).IsRevoked), ctx, jti)
+}
+
+// IssueCode mocks base method.
+func (m *MockService) IssueCode(ctx context.Context, uid int64, req server.AuthorizeRequest, consent bool) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueCode", ctx, uid, req, consent)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssueCode indicates an expected call of IssueCode.
+func (mr *MockServiceMockRecorder) IssueCode(ctx, uid, req, consent interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueCode", reflect.TypeOf((*MockService)(nil).IssueCode), ctx, uid, req, consent)
+}
+
+// RegisterClient mocks base method.
+func (m *MockService) RegisterClient(ctx context.Context, c domain.OAuth2Client, confidential bool) (domain.OAuth2Client, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterClient", ctx, c, confidential)
+	ret0, _ := ret[0].(domain.OAuth2Client)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RegisterClient indicates an expected call of RegisterClient.
+func (mr *MockServiceMockRecorder) RegisterClient(ctx, c, confidential interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterClient", reflect.TypeOf((*MockService)(nil).RegisterClient), ctx, c, confidential)
+}
+
+// Revoke mocks base method.
+func (m *MockService) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, jti, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockServiceMockRecorder) Revoke(ctx, jti, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockService)(nil).Revoke), ctx, jti, expiresAt)
+}
@@ -0,0 +1,338 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"webBook/internal/domain"
+	"webBook/internal/repository"
+)
+
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantClientCredentials = "client_credentials"
+)
+
+// AuthorizeRequest /oauth2/authorize 的参数
+type AuthorizeRequest struct {
+	ResponseType        string
+	ClientId            string
+	RedirectURI         string
+	Scope               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// Authorization 校验通过的授权请求
+type Authorization struct {
+	Client domain.OAuth2Client
+	Scopes []string
+	// NeedConsent 用户之前没有同意过这些 scope
+	NeedConsent bool
+}
+
+// Service webBook 作为 OAuth2 授权服务器，支持 authorization_code + PKCE 和 client_credentials
+// 用户认证复用已有的登录接口，这里只管授权
+type Service interface {
+	// Authorize 校验授权请求，ErrInvalidClient 和 ErrInvalidRedirectURI 不能跳回 client
+	Authorize(ctx context.Context, uid int64, req AuthorizeRequest) (Authorization, error)
+	// IssueCode 颁发授权码，consent 为 true 的时候记录用户同意
+	IssueCode(ctx context.Context, uid int64, req AuthorizeRequest, consent bool) (string, error)
+	// ExchangeCode authorization_code 换 token，公开客户端的 clientSecret 为空
+	ExchangeCode(ctx context.Context, clientId, clientSecret, code, redirectURI, codeVerifier string) (domain.OAuth2Grant, error)
+	ClientCredentials(ctx context.Context, clientId, clientSecret, scope string) (domain.OAuth2Grant, error)
+	// AuthenticateClient 内省和撤销的时候认证 client，只有机密客户端可以
+	AuthenticateClient(ctx context.Context, clientId, clientSecret string) (domain.OAuth2Client, error)
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	// RegisterClient 注册 client，ClientId 为空的时候自动生成。
+	// confidential 为 true 是机密客户端，返回生成的 secret，只有这一次能拿到明文
+	RegisterClient(ctx context.Context, c domain.OAuth2Client, confidential bool) (domain.OAuth2Client, string, error)
+}
+
+type service struct {
+	clientRepo     repository.OAuth2ClientRepository
+	tokenRepo      repository.OAuth2TokenRepository
+	codeExpiration time.Duration
+	// tokenExpiration access token 的有效期
+	tokenExpiration time.Duration
+}
+
+func NewService(clientRepo repository.OAuth2ClientRepository, tokenRepo repository.OAuth2TokenRepository,
+	codeExpiration time.Duration, tokenExpiration time.Duration) Service {
+	return &service{
+		clientRepo:      clientRepo,
+		tokenRepo:       tokenRepo,
+		codeExpiration:  codeExpiration,
+		tokenExpiration: tokenExpiration,
+	}
+}
+
+func (s *service) Authorize(ctx context.Context, uid int64, req AuthorizeRequest) (Authorization, error) {
+	client, err := s.findClient(ctx, req.ClientId)
+	if err != nil {
+		return Authorization{}, err
+	}
+	if !slices.Contains(client.RedirectURIs, req.RedirectURI) {
+		return Authorization{}, ErrInvalidRedirectURI
+	}
+	// 下面的错误都可以跳回 client
+	if req.ResponseType != "code" {
+		return Authorization{}, ErrUnsupportedResponseType
+	}
+	if !slices.Contains(client.GrantTypes, GrantAuthorizationCode) {
+		return Authorization{}, ErrUnauthorizedClient
+	}
+	if req.CodeChallenge != "" && req.CodeChallengeMethod != "S256" {
+		return Authorization{}, fmt.Errorf("%w: 只支持 S256", ErrInvalidRequest)
+	}
+	if client.Public() && req.CodeChallenge == "" {
+		return Authorization{}, fmt.Errorf("%w: 公开客户端必须使用 PKCE", ErrInvalidRequest)
+	}
+	scopes, err := s.scopes(client, req.Scope)
+	if err != nil {
+		return Authorization{}, err
+	}
+	consent, err := s.clientRepo.FindConsent(ctx, uid, client.ClientId)
+	if err != nil {
+		return Authorization{}, err
+	}
+	return Authorization{
+		Client:      client,
+		Scopes:      scopes,
+		NeedConsent: !subset(scopes, consent.Scopes),
+	}, nil
+}
+
+func (s *service) IssueCode(ctx context.Context, uid int64, req AuthorizeRequest, consent bool) (string, error) {
+	a, err := s.Authorize(ctx, uid, req)
+	if err != nil {
+		return "", err
+	}
+	if consent {
+		err = s.clientRepo.SaveConsent(ctx, domain.OAuth2Consent{
+			Uid:      uid,
+			ClientId: a.Client.ClientId,
+			Scopes:   a.Scopes,
+		})
+		if err != nil {
+			return "", err
+		}
+	} else if a.NeedConsent {
+		return "", ErrAccessDenied
+	}
+	code, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	err = s.tokenRepo.StoreCode(ctx, code, domain.OAuth2AuthCode{
+		ClientId:      a.Client.ClientId,
+		Uid:           uid,
+		RedirectURI:   req.RedirectURI,
+		Scopes:        a.Scopes,
+		CodeChallenge: req.CodeChallenge,
+	}, s.codeExpiration)
+	return code, err
+}
+
+func (s *service) ExchangeCode(ctx context.Context, clientId, clientSecret,
+	code, redirectURI, codeVerifier string) (domain.OAuth2Grant, error) {
+	client, err := s.findClient(ctx, clientId)
+	if err != nil {
+		return domain.OAuth2Grant{}, err
+	}
+	// 公开客户端没有 secret，靠 PKCE 保证安全
+	if !client.Public() {
+		if err = s.checkSecret(client, clientSecret); err != nil {
+			return domain.OAuth2Grant{}, err
+		}
+	}
+	if !slices.Contains(client.GrantTypes, GrantAuthorizationCode) {
+		return domain.OAuth2Grant{}, ErrUnauthorizedClient
+	}
+	ac, err := s.tokenRepo.TakeCode(ctx, code)
+	if errors.Is(err, repository.ErrOAuth2CodeNotFound) {
+		return domain.OAuth2Grant{}, ErrInvalidGrant
+	}
+	if err != nil {
+		return domain.OAuth2Grant{}, err
+	}
+	if ac.ClientId != client.ClientId || ac.RedirectURI != redirectURI {
+		return domain.OAuth2Grant{}, ErrInvalidGrant
+	}
+	if ac.CodeChallenge != "" && !verifyPKCE(ac.CodeChallenge, codeVerifier) {
+		return domain.OAuth2Grant{}, ErrInvalidGrant
+	}
+	return s.grant(client.ClientId, ac.Uid, ac.Scopes), nil
+}
+
+func (s *service) ClientCredentials(ctx context.Context, clientId, clientSecret, scope string) (domain.OAuth2Grant, error) {
+	client, err := s.AuthenticateClient(ctx, clientId, clientSecret)
+	if err != nil {
+		return domain.OAuth2Grant{}, err
+	}
+	if !slices.Contains(client.GrantTypes, GrantClientCredentials) {
+		return domain.OAuth2Grant{}, ErrUnauthorizedClient
+	}
+	scopes, err := s.scopes(client, scope)
+	if err != nil {
+		return domain.OAuth2Grant{}, err
+	}
+	return s.grant(client.ClientId, 0, scopes), nil
+}
+
+func (s *service) AuthenticateClient(ctx context.Context, clientId, clientSecret string) (domain.OAuth2Client, error) {
+	client, err := s.findClient(ctx, clientId)
+	if err != nil {
+		return domain.OAuth2Client{}, err
+	}
+	if client.Public() {
+		return domain.OAuth2Client{}, ErrInvalidClient
+	}
+	return client, s.checkSecret(client, clientSecret)
+}
+
+func (s *service) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	return s.tokenRepo.Revoke(ctx, jti, expiresAt)
+}
+
+func (s *service) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	return s.tokenRepo.IsRevoked(ctx, jti)
+}
+
+func (s *service) RegisterClient(ctx context.Context, c domain.OAuth2Client,
+	confidential bool) (domain.OAuth2Client, string, error) {
+	err := checkClientMetadata(c, confidential)
+	if err != nil {
+		return domain.OAuth2Client{}, "", err
+	}
+	if c.ClientId == "" {
+		c.ClientId = uuid.New().String()
+	}
+	var secret string
+	c.SecretHash = ""
+	if confidential {
+		secret, err = randomString()
+		if err != nil {
+			return domain.OAuth2Client{}, "", err
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+		if err != nil {
+			return domain.OAuth2Client{}, "", err
+		}
+		c.SecretHash = string(hash)
+	}
+	err = s.clientRepo.CreateClient(ctx, c)
+	if err != nil {
+		return domain.OAuth2Client{}, "", err
+	}
+	return c, secret, nil
+}
+
+// checkClientMetadata authorization_code 要有 redirect_uri，client_credentials 只给机密客户端
+func checkClientMetadata(c domain.OAuth2Client, confidential bool) error {
+	if c.Name == "" || len(c.GrantTypes) == 0 {
+		return ErrInvalidClientMetadata
+	}
+	for _, grantType := range c.GrantTypes {
+		switch grantType {
+		case GrantAuthorizationCode:
+			if len(c.RedirectURIs) == 0 {
+				return fmt.Errorf("%w: authorization_code 需要 redirect_uri", ErrInvalidClientMetadata)
+			}
+		case GrantClientCredentials:
+			if !confidential {
+				return fmt.Errorf("%w: 公开客户端不能用 client_credentials", ErrInvalidClientMetadata)
+			}
+		default:
+			return fmt.Errorf("%w: 不支持 %s", ErrInvalidClientMetadata, grantType)
+		}
+	}
+	for _, uri := range c.RedirectURIs {
+		u, err := url.Parse(uri)
+		// 必须是绝对地址，并且不能带 fragment
+		if err != nil || !u.IsAbs() || u.Host == "" || u.Fragment != "" {
+			return fmt.Errorf("%w: redirect_uri %s", ErrInvalidClientMetadata, uri)
+		}
+	}
+	return nil
+}
+
+func (s *service) findClient(ctx context.Context, clientId string) (domain.OAuth2Client, error) {
+	if clientId == "" {
+		return domain.OAuth2Client{}, ErrInvalidClient
+	}
+	client, err := s.clientRepo.FindClient(ctx, clientId)
+	if errors.Is(err, repository.ErrOAuth2ClientNotFound) {
+		return domain.OAuth2Client{}, ErrInvalidClient
+	}
+	return client, err
+}
+
+func (s *service) checkSecret(client domain.OAuth2Client, secret string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(client.SecretHash), []byte(secret))
+	if err != nil {
+		return ErrInvalidClient
+	}
+	return nil
+}
+
+// scopes 没有申请 scope 的时候给 client 全部的 scope
+func (s *service) scopes(client domain.OAuth2Client, scope string) ([]string, error) {
+	scopes := strings.Fields(scope)
+	if len(scopes) == 0 {
+		return client.Scopes, nil
+	}
+	if !subset(scopes, client.Scopes) {
+		return nil, ErrInvalidScope
+	}
+	return scopes, nil
+}
+
+func (s *service) grant(clientId string, uid int64, scopes []string) domain.OAuth2Grant {
+	return domain.OAuth2Grant{
+		Id:        uuid.New().String(),
+		ClientId:  clientId,
+		Uid:       uid,
+		Scopes:    scopes,
+		ExpiresAt: time.Now().Add(s.tokenExpiration),
+	}
+}
+
+// verifyPKCE BASE64URL(SHA256(code_verifier)) == code_challenge
+func verifyPKCE(challenge, verifier string) bool {
+	// RFC 7636 规定 43 到 128 个字符
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+func subset(scopes, allowed []string) bool {
+	for _, scope := range scopes {
+		if !slices.Contains(allowed, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,484 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"testing"
+	"time"
+	"webBook/internal/domain"
+	"webBook/internal/repository"
+	repomocks "webBook/internal/repository/mocks"
+)
+
+const redirectURI = "https://app.example.com/callback"
+
+var verifier = strings.Repeat("v", 43)
+
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func confidentialClient(t *testing.T) domain.OAuth2Client {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	return domain.OAuth2Client{
+		ClientId:     "app",
+		SecretHash:   string(hash),
+		RedirectURIs: []string{redirectURI},
+		Scopes:       []string{"profile", "email"},
+		GrantTypes:   []string{GrantAuthorizationCode, GrantClientCredentials},
+	}
+}
+
+func publicClient() domain.OAuth2Client {
+	return domain.OAuth2Client{
+		ClientId:     "spa",
+		RedirectURIs: []string{redirectURI},
+		Scopes:       []string{"profile"},
+		GrantTypes:   []string{GrantAuthorizationCode},
+	}
+}
+
+func TestService_Authorize(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.OAuth2ClientRepository
+		req  AuthorizeRequest
+
+		wantConsent bool
+		wantScopes  []string
+		wantErr     error
+	}{
+		{
+			name: "client 不存在",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2ClientRepository {
+				repo := repomocks.NewMockOAuth2ClientRepository(ctrl)
+				repo.EXPECT().FindClient(gomock.Any(), "app").
+					Return(domain.OAuth2Client{}, repository.ErrOAuth2ClientNotFound)
+				return repo
+			},
+			req:     AuthorizeRequest{ResponseType: "code", ClientId: "app", RedirectURI: redirectURI},
+			wantErr: ErrInvalidClient,
+		},
+		{
+			name: "redirect_uri 没有注册",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2ClientRepository {
+				repo := repomocks.NewMockOAuth2ClientRepository(ctrl)
+				repo.EXPECT().FindClient(gomock.Any(), "app").Return(confidentialClient(t), nil)
+				return repo
+			},
+			req:     AuthorizeRequest{ResponseType: "code", ClientId: "app", RedirectURI: "https://evil.com/callback"},
+			wantErr: ErrInvalidRedirectURI,
+		},
+		{
+			name: "公开客户端没有 PKCE",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2ClientRepository {
+				repo := repomocks.NewMockOAuth2ClientRepository(ctrl)
+				repo.EXPECT().FindClient(gomock.Any(), "spa").Return(publicClient(), nil)
+				return repo
+			},
+			req:     AuthorizeRequest{ResponseType: "code", ClientId: "spa", RedirectURI: redirectURI},
+			wantErr: ErrInvalidRequest,
+		},
+		{
+			name: "不支持 plain",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2ClientRepository {
+				repo := repomocks.NewMockOAuth2ClientRepository(ctrl)
+				repo.EXPECT().FindClient(gomock.Any(), "spa").Return(publicClient(), nil)
+				return repo
+			},
+			req: AuthorizeRequest{ResponseType: "code", ClientId: "spa", RedirectURI: redirectURI,
+				CodeChallenge: verifier, CodeChallengeMethod: "plain"},
+			wantErr: ErrInvalidRequest,
+		},
+		{
+			name: "scope 超出范围",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2ClientRepository {
+				repo := repomocks.NewMockOAuth2ClientRepository(ctrl)
+				repo.EXPECT().FindClient(gomock.Any(), "app").Return(confidentialClient(t), nil)
+				return repo
+			},
+			req:     AuthorizeRequest{ResponseType: "code", ClientId: "app", RedirectURI: redirectURI, Scope: "admin"},
+			wantErr: ErrInvalidScope,
+		},
+		{
+			name: "第一次授权",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2ClientRepository {
+				repo := repomocks.NewMockOAuth2ClientRepository(ctrl)
+				repo.EXPECT().FindClient(gomock.Any(), "app").Return(confidentialClient(t), nil)
+				repo.EXPECT().FindConsent(gomock.Any(), int64(123), "app").Return(domain.OAuth2Consent{}, nil)
+				return repo
+			},
+			req:         AuthorizeRequest{ResponseType: "code", ClientId: "app", RedirectURI: redirectURI, Scope: "profile"},
+			wantConsent: true,
+			wantScopes:  []string{"profile"},
+		},
+		{
+			name: "已经同意过",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2ClientRepository {
+				repo := repomocks.NewMockOAuth2ClientRepository(ctrl)
+				repo.EXPECT().FindClient(gomock.Any(), "app").Return(confidentialClient(t), nil)
+				repo.EXPECT().FindConsent(gomock.Any(), int64(123), "app").Return(domain.OAuth2Consent{
+					Uid:      123,
+					ClientId: "app",
+					Scopes:   []string{"profile", "email"},
+				}, nil)
+				return repo
+			},
+			req:        AuthorizeRequest{ResponseType: "code", ClientId: "app", RedirectURI: redirectURI},
+			wantScopes: []string{"profile", "email"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewService(tc.mock(ctrl), repomocks.NewMockOAuth2TokenRepository(ctrl), time.Minute, time.Hour)
+			a, err := svc.Authorize(context.Background(), 123, tc.req)
+			assert.ErrorIs(t, err, tc.wantErr)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantConsent, a.NeedConsent)
+			assert.Equal(t, tc.wantScopes, a.Scopes)
+		})
+	}
+}
+
+func TestService_ExchangeCode(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (repository.OAuth2ClientRepository, repository.OAuth2TokenRepository)
+
+		clientId     string
+		clientSecret string
+		code         string
+		redirectURI  string
+		codeVerifier string
+
+		wantUid int64
+		wantErr error
+	}{
+		{
+			name: "机密客户端成功",
+			mock: func(ctrl *gomock.Controller) (repository.OAuth2ClientRepository, repository.OAuth2TokenRepository) {
+				clientRepo := repomocks.NewMockOAuth2ClientRepository(ctrl)
+				tokenRepo := repomocks.NewMockOAuth2TokenRepository(ctrl)
+				clientRepo.EXPECT().FindClient(gomock.Any(), "app").Return(confidentialClient(t), nil)
+				tokenRepo.EXPECT().TakeCode(gomock.Any(), "code").Return(domain.OAuth2AuthCode{
+					ClientId:    "app",
+					Uid:         123,
+					RedirectURI: redirectURI,
+					Scopes:      []string{"profile"},
+				}, nil)
+				return clientRepo, tokenRepo
+			},
+			clientId:     "app",
+			clientSecret: "secret",
+			code:         "code",
+			redirectURI:  redirectURI,
+			wantUid:      123,
+		},
+		{
+			name: "secret 错误",
+			mock: func(ctrl *gomock.Controller) (repository.OAuth2ClientRepository, repository.OAuth2TokenRepository) {
+				clientRepo := repomocks.NewMockOAuth2ClientRepository(ctrl)
+				clientRepo.EXPECT().FindClient(gomock.Any(), "app").Return(confidentialClient(t), nil)
+				return clientRepo, repomocks.NewMockOAuth2TokenRepository(ctrl)
+			},
+			clientId:     "app",
+			clientSecret: "wrong",
+			code:         "code",
+			redirectURI:  redirectURI,
+			wantErr:      ErrInvalidClient,
+		},
+		{
+			name: "授权码已经用过",
+			mock: func(ctrl *gomock.Controller) (repository.OAuth2ClientRepository, repository.OAuth2TokenRepository) {
+				clientRepo := repomocks.NewMockOAuth2ClientRepository(ctrl)
+				tokenRepo := repomocks.NewMockOAuth2TokenRepository(ctrl)
+				clientRepo.EXPECT().FindClient(gomock.Any(), "app").Return(confidentialClient(t), nil)
+				tokenRepo.EXPECT().TakeCode(gomock.Any(), "code").
+					Return(domain.OAuth2AuthCode{}, repository.ErrOAuth2CodeNotFound)
+				return clientRepo, tokenRepo
+			},
+			clientId:     "app",
+			clientSecret: "secret",
+			code:         "code",
+			redirectURI:  redirectURI,
+			wantErr:      ErrInvalidGrant,
+		},
+		{
+			name: "redirect_uri 不一致",
+			mock: func(ctrl *gomock.Controller) (repository.OAuth2ClientRepository, repository.OAuth2TokenRepository) {
+				clientRepo := repomocks.NewMockOAuth2ClientRepository(ctrl)
+				tokenRepo := repomocks.NewMockOAuth2TokenRepository(ctrl)
+				clientRepo.EXPECT().FindClient(gomock.Any(), "app").Return(confidentialClient(t), nil)
+				tokenRepo.EXPECT().TakeCode(gomock.Any(), "code").Return(domain.OAuth2AuthCode{
+					ClientId:    "app",
+					Uid:         123,
+					RedirectURI: redirectURI,
+				}, nil)
+				return clientRepo, tokenRepo
+			},
+			clientId:     "app",
+			clientSecret: "secret",
+			code:         "code",
+			redirectURI:  "https://app.example.com/other",
+			wantErr:      ErrInvalidGrant,
+		},
+		{
+			name: "授权码是别的 client 的",
+			mock: func(ctrl *gomock.Controller) (repository.OAuth2ClientRepository, repository.OAuth2TokenRepository) {
+				clientRepo := repomocks.NewMockOAuth2ClientRepository(ctrl)
+				tokenRepo := repomocks.NewMockOAuth2TokenRepository(ctrl)
+				clientRepo.EXPECT().FindClient(gomock.Any(), "spa").Return(publicClient(), nil)
+				tokenRepo.EXPECT().TakeCode(gomock.Any(), "code").Return(domain.OAuth2AuthCode{
+					ClientId:    "app",
+					Uid:         123,
+					RedirectURI: redirectURI,
+				}, nil)
+				return clientRepo, tokenRepo
+			},
+			clientId:     "spa",
+			code:         "code",
+			redirectURI:  redirectURI,
+			codeVerifier: verifier,
+			wantErr:      ErrInvalidGrant,
+		},
+		{
+			name: "PKCE 成功",
+			mock: func(ctrl *gomock.Controller) (repository.OAuth2ClientRepository, repository.OAuth2TokenRepository) {
+				clientRepo := repomocks.NewMockOAuth2ClientRepository(ctrl)
+				tokenRepo := repomocks.NewMockOAuth2TokenRepository(ctrl)
+				clientRepo.EXPECT().FindClient(gomock.Any(), "spa").Return(publicClient(), nil)
+				tokenRepo.EXPECT().TakeCode(gomock.Any(), "code").Return(domain.OAuth2AuthCode{
+					ClientId:      "spa",
+					Uid:           123,
+					RedirectURI:   redirectURI,
+					CodeChallenge: challenge(verifier),
+				}, nil)
+				return clientRepo, tokenRepo
+			},
+			clientId:     "spa",
+			code:         "code",
+			redirectURI:  redirectURI,
+			codeVerifier: verifier,
+			wantUid:      123,
+		},
+		{
+			name: "PKCE verifier 不对",
+			mock: func(ctrl *gomock.Controller) (repository.OAuth2ClientRepository, repository.OAuth2TokenRepository) {
+				clientRepo := repomocks.NewMockOAuth2ClientRepository(ctrl)
+				tokenRepo := repomocks.NewMockOAuth2TokenRepository(ctrl)
+				clientRepo.EXPECT().FindClient(gomock.Any(), "spa").Return(publicClient(), nil)
+				tokenRepo.EXPECT().TakeCode(gomock.Any(), "code").Return(domain.OAuth2AuthCode{
+					ClientId:      "spa",
+					Uid:           123,
+					RedirectURI:   redirectURI,
+					CodeChallenge: challenge(verifier),
+				}, nil)
+				return clientRepo, tokenRepo
+			},
+			clientId:     "spa",
+			code:         "code",
+			redirectURI:  redirectURI,
+			codeVerifier: strings.Repeat("x", 43),
+			wantErr:      ErrInvalidGrant,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			clientRepo, tokenRepo := tc.mock(ctrl)
+			svc := NewService(clientRepo, tokenRepo, time.Minute, time.Hour)
+			grant, err := svc.ExchangeCode(context.Background(), tc.clientId, tc.clientSecret,
+				tc.code, tc.redirectURI, tc.codeVerifier)
+			assert.ErrorIs(t, err, tc.wantErr)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantUid, grant.Uid)
+			assert.Equal(t, tc.clientId, grant.ClientId)
+			assert.NotEmpty(t, grant.Id)
+		})
+	}
+}
+
+func TestService_ClientCredentials(t *testing.T) {
+	testCases := []struct {
+		name   string
+		mock   func(ctrl *gomock.Controller) repository.OAuth2ClientRepository
+		secret string
+		scope  string
+
+		wantScopes []string
+		wantErr    error
+	}{
+		{
+			name: "成功",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2ClientRepository {
+				repo := repomocks.NewMockOAuth2ClientRepository(ctrl)
+				repo.EXPECT().FindClient(gomock.Any(), "app").Return(confidentialClient(t), nil)
+				return repo
+			},
+			secret:     "secret",
+			scope:      "email",
+			wantScopes: []string{"email"},
+		},
+		{
+			name: "公开客户端不能用",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2ClientRepository {
+				repo := repomocks.NewMockOAuth2ClientRepository(ctrl)
+				client := publicClient()
+				client.ClientId = "app"
+				repo.EXPECT().FindClient(gomock.Any(), "app").Return(client, nil)
+				return repo
+			},
+			wantErr: ErrInvalidClient,
+		},
+		{
+			name: "没有开通 client_credentials",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2ClientRepository {
+				repo := repomocks.NewMockOAuth2ClientRepository(ctrl)
+				client := confidentialClient(t)
+				client.GrantTypes = []string{GrantAuthorizationCode}
+				repo.EXPECT().FindClient(gomock.Any(), "app").Return(client, nil)
+				return repo
+			},
+			secret:  "secret",
+			wantErr: ErrUnauthorizedClient,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewService(tc.mock(ctrl), repomocks.NewMockOAuth2TokenRepository(ctrl), time.Minute, time.Hour)
+			grant, err := svc.ClientCredentials(context.Background(), "app", tc.secret, tc.scope)
+			assert.ErrorIs(t, err, tc.wantErr)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, int64(0), grant.Uid)
+			assert.Equal(t, tc.wantScopes, grant.Scopes)
+		})
+	}
+}
+
+func TestService_RegisterClient(t *testing.T) {
+	testCases := []struct {
+		name         string
+		mock         func(ctrl *gomock.Controller) repository.OAuth2ClientRepository
+		client       domain.OAuth2Client
+		confidential bool
+
+		wantErr error
+	}{
+		{
+			name: "机密客户端，生成 secret",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2ClientRepository {
+				repo := repomocks.NewMockOAuth2ClientRepository(ctrl)
+				repo.EXPECT().CreateClient(gomock.Any(), gomock.Any()).Return(nil)
+				return repo
+			},
+			client: domain.OAuth2Client{
+				Name:         "app",
+				RedirectURIs: []string{redirectURI},
+				Scopes:       []string{"profile"},
+				GrantTypes:   []string{GrantAuthorizationCode, GrantClientCredentials},
+			},
+			confidential: true,
+		},
+		{
+			name: "公开客户端，没有 secret",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2ClientRepository {
+				repo := repomocks.NewMockOAuth2ClientRepository(ctrl)
+				repo.EXPECT().CreateClient(gomock.Any(), gomock.Any()).Return(nil)
+				return repo
+			},
+			client: domain.OAuth2Client{
+				ClientId:     "spa",
+				Name:         "spa",
+				RedirectURIs: []string{redirectURI},
+				GrantTypes:   []string{GrantAuthorizationCode},
+			},
+		},
+		{
+			name: "公开客户端不能用 client_credentials",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2ClientRepository {
+				return repomocks.NewMockOAuth2ClientRepository(ctrl)
+			},
+			client: domain.OAuth2Client{
+				Name:       "spa",
+				GrantTypes: []string{GrantClientCredentials},
+			},
+			wantErr: ErrInvalidClientMetadata,
+		},
+		{
+			name: "authorization_code 没有 redirect_uri",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2ClientRepository {
+				return repomocks.NewMockOAuth2ClientRepository(ctrl)
+			},
+			client: domain.OAuth2Client{
+				Name:       "app",
+				GrantTypes: []string{GrantAuthorizationCode},
+			},
+			confidential: true,
+			wantErr:      ErrInvalidClientMetadata,
+		},
+		{
+			name: "redirect_uri 不是绝对地址",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2ClientRepository {
+				return repomocks.NewMockOAuth2ClientRepository(ctrl)
+			},
+			client: domain.OAuth2Client{
+				Name:         "app",
+				RedirectURIs: []string{"/callback"},
+				GrantTypes:   []string{GrantAuthorizationCode},
+			},
+			wantErr: ErrInvalidClientMetadata,
+		},
+		{
+			name: "不支持的授权方式",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2ClientRepository {
+				return repomocks.NewMockOAuth2ClientRepository(ctrl)
+			},
+			client: domain.OAuth2Client{
+				Name:       "app",
+				GrantTypes: []string{"password"},
+			},
+			confidential: true,
+			wantErr:      ErrInvalidClientMetadata,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewService(tc.mock(ctrl), repomocks.NewMockOAuth2TokenRepository(ctrl), time.Minute, time.Hour)
+			client, secret, err := svc.RegisterClient(context.Background(), tc.client, tc.confidential)
+			assert.ErrorIs(t, err, tc.wantErr)
+			if err != nil {
+				return
+			}
+			assert.NotEmpty(t, client.ClientId)
+			if !tc.confidential {
+				assert.Empty(t, secret)
+				assert.True(t, client.Public())
+				return
+			}
+			// 落库的是 hash，明文只返回一次
+			assert.NotEmpty(t, secret)
+			assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(client.SecretHash), []byte(secret)))
+		})
+	}
+}
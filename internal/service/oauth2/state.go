@@ -0,0 +1,97 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	uuid "github.com/lithammer/shortuuid/v4"
+	"net/url"
+	"strings"
+	"time"
+	"webBook/internal/domain"
+	"webBook/internal/repository"
+)
+
+var (
+	ErrInvalidState        = errors.New("非法的 state")
+	ErrReturnURLNotAllowed = errors.New("登录之后跳转的地址不在白名单里面")
+)
+
+// StateService 管理第三方登录的 state，state 保存在 Redis，只能用一次
+type StateService interface {
+	// Create 生成 state，returnURL 是登录成功之后跳回去的地址，可以为空
+	Create(ctx context.Context, provider, returnURL string) (string, error)
+	// Take 校验并且消费 state，返回创建的时候绑定的 returnURL
+	Take(ctx context.Context, provider, state string) (string, error)
+}
+
+type stateService struct {
+	repo       repository.OAuth2StateRepository
+	expiration time.Duration
+	// allowed 允许跳转的 origin，例如 https://meoying.com
+	allowed map[string]struct{}
+}
+
+// NewStateService returnURLs 是允许跳转的 origin，站内的相对路径总是允许的
+func NewStateService(repo repository.OAuth2StateRepository,
+	expiration time.Duration, returnURLs []string) StateService {
+	allowed := make(map[string]struct{}, len(returnURLs))
+	for _, u := range returnURLs {
+		allowed[strings.TrimSuffix(u, "/")] = struct{}{}
+	}
+	return &stateService{
+		repo:       repo,
+		expiration: expiration,
+		allowed:    allowed,
+	}
+}
+
+func (s *stateService) Create(ctx context.Context, provider, returnURL string) (string, error) {
+	if !s.allow(returnURL) {
+		return "", fmt.Errorf("%w: %s", ErrReturnURLNotAllowed, returnURL)
+	}
+	state := uuid.New()
+	err := s.repo.Store(ctx, state, domain.OAuth2State{
+		Provider:  provider,
+		ReturnURL: returnURL,
+	}, s.expiration)
+	return state, err
+}
+
+func (s *stateService) Take(ctx context.Context, provider, state string) (string, error) {
+	if state == "" {
+		return "", ErrInvalidState
+	}
+	st, err := s.repo.Take(ctx, state)
+	if errors.Is(err, repository.ErrOAuth2StateNotFound) {
+		return "", ErrInvalidState
+	}
+	if err != nil {
+		return "", err
+	}
+	if st.Provider != provider {
+		return "", fmt.Errorf("%w: 提供方不匹配", ErrInvalidState)
+	}
+	return st.ReturnURL, nil
+}
+
+// allow 站内的相对路径或者白名单里面的 origin
+func (s *stateService) allow(returnURL string) bool {
+	if returnURL == "" {
+		return true
+	}
+	u, err := url.Parse(returnURL)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" && u.Host == "" {
+		// //evil.com 和 /\evil.com 这种，浏览器会当成别的域名
+		return strings.HasPrefix(returnURL, "/") &&
+			!strings.HasPrefix(returnURL, "//") && !strings.HasPrefix(returnURL, "/\\")
+	}
+	if u.User != nil {
+		return false
+	}
+	_, ok := s.allowed[u.Scheme+"://"+u.Host]
+	return ok
+}
@@ -0,0 +1,114 @@
+package oauth2
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"webBook/internal/domain"
+	"webBook/internal/repository"
+	repomocks "webBook/internal/repository/mocks"
+)
+
+func TestStateService_Create(t *testing.T) {
+	testCases := []struct {
+		name      string
+		returnURL string
+		wantErr   error
+	}{
+		{name: "不跳转"},
+		{name: "站内相对路径", returnURL: "/articles/1?from=login"},
+		{name: "白名单里面的 origin", returnURL: "https://meoying.com/profile"},
+		{name: "不在白名单", returnURL: "https://evil.com/profile", wantErr: ErrReturnURLNotAllowed},
+		{name: "协议不对", returnURL: "http://meoying.com/profile", wantErr: ErrReturnURLNotAllowed},
+		{name: "子域名", returnURL: "https://meoying.com.evil.com/", wantErr: ErrReturnURLNotAllowed},
+		{name: "协议相对地址", returnURL: "//evil.com/profile", wantErr: ErrReturnURLNotAllowed},
+		{name: "反斜杠", returnURL: "/\\evil.com", wantErr: ErrReturnURLNotAllowed},
+		{name: "带用户信息", returnURL: "https://meoying.com@evil.com/", wantErr: ErrReturnURLNotAllowed},
+		{name: "javascript", returnURL: "javascript:alert(1)", wantErr: ErrReturnURLNotAllowed},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repo := repomocks.NewMockOAuth2StateRepository(ctrl)
+			if tc.wantErr == nil {
+				repo.EXPECT().Store(gomock.Any(), gomock.Any(), domain.OAuth2State{
+					Provider:  "github",
+					ReturnURL: tc.returnURL,
+				}, time.Minute*10).Return(nil)
+			}
+			svc := NewStateService(repo, time.Minute*10, []string{"https://meoying.com/"})
+			state, err := svc.Create(context.Background(), "github", tc.returnURL)
+			assert.ErrorIs(t, err, tc.wantErr)
+			if err == nil {
+				assert.NotEmpty(t, state)
+			}
+		})
+	}
+}
+
+func TestStateService_Take(t *testing.T) {
+	testCases := []struct {
+		name  string
+		mock  func(ctrl *gomock.Controller) repository.OAuth2StateRepository
+		state string
+
+		wantURL string
+		wantErr error
+	}{
+		{
+			name: "校验通过",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2StateRepository {
+				repo := repomocks.NewMockOAuth2StateRepository(ctrl)
+				repo.EXPECT().Take(gomock.Any(), "state").Return(domain.OAuth2State{
+					Provider:  "github",
+					ReturnURL: "/profile",
+				}, nil)
+				return repo
+			},
+			state:   "state",
+			wantURL: "/profile",
+		},
+		{
+			name: "已经用过或者过期",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2StateRepository {
+				repo := repomocks.NewMockOAuth2StateRepository(ctrl)
+				repo.EXPECT().Take(gomock.Any(), "state").
+					Return(domain.OAuth2State{}, repository.ErrOAuth2StateNotFound)
+				return repo
+			},
+			state:   "state",
+			wantErr: ErrInvalidState,
+		},
+		{
+			name: "别的提供方的 state",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2StateRepository {
+				repo := repomocks.NewMockOAuth2StateRepository(ctrl)
+				repo.EXPECT().Take(gomock.Any(), "state").
+					Return(domain.OAuth2State{Provider: "wechat"}, nil)
+				return repo
+			},
+			state:   "state",
+			wantErr: ErrInvalidState,
+		},
+		{
+			name: "没有 state",
+			mock: func(ctrl *gomock.Controller) repository.OAuth2StateRepository {
+				return repomocks.NewMockOAuth2StateRepository(ctrl)
+			},
+			wantErr: ErrInvalidState,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewStateService(tc.mock(ctrl), time.Minute, nil)
+			returnURL, err := svc.Take(context.Background(), "github", tc.state)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Equal(t, tc.wantURL, returnURL)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package oauth2
+
+import (
+	"context"
+	"webBook/internal/domain"
+)
+
+// Provider 第三方登录的提供方，GitHub、Google 或者公司内部的 OIDC 都实现这个接口
+type Provider interface {
+	// Name 提供方的名字，也是路由 /oauth2/:provider 里面的 provider
+	Name() string
+	// AuthURL 构造跳转到提供方授权页面的 URL
+	AuthURL(ctx context.Context, state string) (string, error)
+	// Exchange 用授权码换 token
+	Exchange(ctx context.Context, code string) (Token, error)
+	// UserInfo 拿到用户在提供方那边的身份
+	UserInfo(ctx context.Context, token Token) (domain.UserIdentity, error)
+}
+
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	// 单位是秒
+	ExpiresIn int64  `json:"expires_in"`
+	Scope     string `json:"scope"`
+	// IDToken 只有 OIDC 才有
+	IDToken string `json:"id_token"`
+
+	// 错误返回，GitHub 出错的时候也是 200
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+
+	// Claims 校验通过之后的 ID token
+	Claims *IDTokenClaims `json:"-"`
+}
@@ -0,0 +1,182 @@
+package wechat
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	uuid "github.com/lithammer/shortuuid/v4"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"webBook/internal/domain"
+	"webBook/internal/repository"
+)
+
+var (
+	ErrSessionNotFound      = repository.ErrWechatSessionNotFound // 导出错误，表示 session 不存在或者已经过期。
+	ErrInvalidEncryptedData = errors.New("非法的加密数据")               // 解密失败或者不是这个小程序的数据。
+)
+
+// MiniProgramService 小程序登录，前端先用 wx.login 的 code 换 session ID，
+// 再用 session ID 登录，需要手机号码的时候带上 getPhoneNumber 的加密数据
+type MiniProgramService interface {
+	// Code2Session 调用 jscode2session，session key 保存在服务端，返回 session ID
+	Code2Session(ctx context.Context, code string) (string, error)
+	// Info session 对应的微信身份
+	Info(ctx context.Context, sessionId string) (domain.WechatInfo, error)
+	// DecryptPhone 用 session key 解密 getPhoneNumber 的数据，返回带国家码的号码
+	DecryptPhone(ctx context.Context, sessionId, encryptedData, iv string) (string, error)
+}
+
+type miniProgramService struct {
+	appID      string
+	appSecret  string
+	baseURL    string
+	client     *http.Client
+	repo       repository.WechatSessionRepository
+	expiration time.Duration
+}
+
+func NewMiniProgramService(appID string, appSecret string, baseURL string,
+	repo repository.WechatSessionRepository, expiration time.Duration) MiniProgramService {
+	return &miniProgramService{
+		appID:      appID,
+		appSecret:  appSecret,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		client:     http.DefaultClient,
+		repo:       repo,
+		expiration: expiration,
+	}
+}
+
+func (s *miniProgramService) Code2Session(ctx context.Context, code string) (string, error) {
+	query := url.Values{
+		"appid":      {s.appID},
+		"secret":     {s.appSecret},
+		"js_code":    {code},
+		"grant_type": {"authorization_code"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		s.baseURL+"/sns/jscode2session?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	httpResp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer httpResp.Body.Close()
+	var res SessionResult
+	err = json.NewDecoder(httpResp.Body).Decode(&res)
+	if err != nil {
+		return "", err
+	}
+	if res.ErrCode != 0 {
+		return "", fmt.Errorf("调用微信接口失败 errcode %d, errmsg %s", res.ErrCode, res.ErrMsg)
+	}
+	id := uuid.New()
+	err = s.repo.Store(ctx, id, domain.WechatSession{
+		OpenId:     res.OpenId,
+		UnionId:    res.UnionId,
+		SessionKey: res.SessionKey,
+	}, s.expiration)
+	return id, err
+}
+
+func (s *miniProgramService) Info(ctx context.Context, sessionId string) (domain.WechatInfo, error) {
+	sess, err := s.repo.Find(ctx, sessionId)
+	if err != nil {
+		return domain.WechatInfo{}, err
+	}
+	return domain.WechatInfo{
+		UnionId:    sess.UnionId,
+		MiniOpenId: sess.OpenId,
+	}, nil
+}
+
+func (s *miniProgramService) DecryptPhone(ctx context.Context,
+	sessionId, encryptedData, iv string) (string, error) {
+	sess, err := s.repo.Find(ctx, sessionId)
+	if err != nil {
+		return "", err
+	}
+	plain, err := decrypt(sess.SessionKey, encryptedData, iv)
+	if err != nil {
+		return "", err
+	}
+	var info PhoneInfo
+	err = json.Unmarshal(plain, &info)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidEncryptedData, err)
+	}
+	// 防止拿别的小程序的数据来登录
+	if info.Watermark.AppId != s.appID {
+		return "", fmt.Errorf("%w: appid 不匹配", ErrInvalidEncryptedData)
+	}
+	if info.CountryCode == "" || info.PurePhoneNumber == "" {
+		return "", fmt.Errorf("%w: 没有手机号码", ErrInvalidEncryptedData)
+	}
+	return "+" + info.CountryCode + info.PurePhoneNumber, nil
+}
+
+// decrypt 微信开放数据的解密算法，AES-128-CBC，PKCS#7 填充，参数都是 base64
+func decrypt(sessionKey, encryptedData, iv string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(sessionKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEncryptedData, err)
+	}
+	ivBytes, err := base64.StdEncoding.DecodeString(iv)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEncryptedData, err)
+	}
+	data, err := base64.StdEncoding.DecodeString(encryptedData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEncryptedData, err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEncryptedData, err)
+	}
+	if len(ivBytes) != block.BlockSize() || len(data) == 0 || len(data)%block.BlockSize() != 0 {
+		return nil, ErrInvalidEncryptedData
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, ivBytes).CryptBlocks(plain, data)
+	// 去掉 PKCS#7 填充
+	pad := int(plain[len(plain)-1])
+	if pad == 0 || pad > block.BlockSize() ||
+		!bytes.Equal(plain[len(plain)-pad:], bytes.Repeat([]byte{byte(pad)}, pad)) {
+		return nil, ErrInvalidEncryptedData
+	}
+	return plain[:len(plain)-pad], nil
+}
+
+type SessionResult struct {
+	OpenId     string `json:"openid"`
+	SessionKey string `json:"session_key"`
+	// 小程序绑定到开放平台之后才有
+	UnionId string `json:"unionid"`
+
+	// 错误返回
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// PhoneInfo getPhoneNumber 解密之后的数据
+type PhoneInfo struct {
+	// 有国家码的号码，外国手机号码会有区号
+	PhoneNumber string `json:"phoneNumber"`
+	// 没有国家码的号码
+	PurePhoneNumber string `json:"purePhoneNumber"`
+	CountryCode     string `json:"countryCode"`
+	Watermark       struct {
+		AppId     string `json:"appid"`
+		Timestamp int64  `json:"timestamp"`
+	} `json:"watermark"`
+}
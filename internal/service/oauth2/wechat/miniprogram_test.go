@@ -0,0 +1,149 @@
+package wechat
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"webBook/internal/domain"
+	"webBook/internal/repository"
+	repomocks "webBook/internal/repository/mocks"
+)
+
+var (
+	testSessionKey = base64.StdEncoding.EncodeToString([]byte("0123456789abcdef"))
+	testIV         = base64.StdEncoding.EncodeToString([]byte("fedcba9876543210"))
+)
+
+func TestMiniProgramService_Code2Session(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/sns/jscode2session", r.URL.Path)
+		if r.URL.Query().Get("js_code") != "good-code" {
+			_ = json.NewEncoder(w).Encode(SessionResult{ErrCode: 40029, ErrMsg: "invalid code"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(SessionResult{
+			OpenId:     "mini-openid",
+			SessionKey: testSessionKey,
+			UnionId:    "unionid",
+		})
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := repomocks.NewMockWechatSessionRepository(ctrl)
+	repo.EXPECT().Store(gomock.Any(), gomock.Any(), domain.WechatSession{
+		OpenId:     "mini-openid",
+		UnionId:    "unionid",
+		SessionKey: testSessionKey,
+	}, time.Hour).Return(nil)
+	svc := NewMiniProgramService("appid", "secret", server.URL, repo, time.Hour)
+
+	id, err := svc.Code2Session(context.Background(), "good-code")
+	require.NoError(t, err)
+	assert.NotEmpty(t, id)
+	// session key 不能作为 session ID 给前端
+	assert.NotEqual(t, testSessionKey, id)
+
+	_, err = svc.Code2Session(context.Background(), "bad-code")
+	assert.Error(t, err)
+}
+
+func TestMiniProgramService_DecryptPhone(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.WechatSessionRepository
+		data func(t *testing.T) string
+
+		wantPhone string
+		wantErr   error
+	}{
+		{
+			name: "解密成功",
+			mock: sessionRepo,
+			data: func(t *testing.T) string {
+				return encryptPhone(t, "appid")
+			},
+			wantPhone: "+8615212345678",
+		},
+		{
+			name: "别的小程序的数据",
+			mock: sessionRepo,
+			data: func(t *testing.T) string {
+				return encryptPhone(t, "another-appid")
+			},
+			wantErr: ErrInvalidEncryptedData,
+		},
+		{
+			name: "数据被篡改",
+			mock: sessionRepo,
+			data: func(t *testing.T) string {
+				return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+			},
+			wantErr: ErrInvalidEncryptedData,
+		},
+		{
+			name: "session 过期",
+			mock: func(ctrl *gomock.Controller) repository.WechatSessionRepository {
+				repo := repomocks.NewMockWechatSessionRepository(ctrl)
+				repo.EXPECT().Find(gomock.Any(), "session-id").
+					Return(domain.WechatSession{}, repository.ErrWechatSessionNotFound)
+				return repo
+			},
+			data: func(t *testing.T) string {
+				return encryptPhone(t, "appid")
+			},
+			wantErr: ErrSessionNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewMiniProgramService("appid", "secret", "", tc.mock(ctrl), time.Hour)
+			phone, err := svc.DecryptPhone(context.Background(), "session-id", tc.data(t), testIV)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Equal(t, tc.wantPhone, phone)
+		})
+	}
+}
+
+func sessionRepo(ctrl *gomock.Controller) repository.WechatSessionRepository {
+	repo := repomocks.NewMockWechatSessionRepository(ctrl)
+	repo.EXPECT().Find(gomock.Any(), "session-id").Return(domain.WechatSession{
+		OpenId:     "mini-openid",
+		SessionKey: testSessionKey,
+	}, nil)
+	return repo
+}
+
+// encryptPhone 模拟微信加密 getPhoneNumber 的数据
+func encryptPhone(t *testing.T, appId string) string {
+	var info PhoneInfo
+	info.PhoneNumber = "15212345678"
+	info.PurePhoneNumber = "15212345678"
+	info.CountryCode = "86"
+	info.Watermark.AppId = appId
+	info.Watermark.Timestamp = time.Now().Unix()
+	plain, err := json.Marshal(info)
+	require.NoError(t, err)
+	key, _ := base64.StdEncoding.DecodeString(testSessionKey)
+	iv, _ := base64.StdEncoding.DecodeString(testIV)
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	pad := block.BlockSize() - len(plain)%block.BlockSize()
+	plain = append(plain, bytes.Repeat([]byte{byte(pad)}, pad)...)
+	data := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, plain)
+	return base64.StdEncoding.EncodeToString(data)
+}
@@ -0,0 +1,81 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/service/oauth2/wechat/miniprogram.go
+
+// Package wechatmocks is a generated GoMock package.
+package wechatmocks
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "webBook/internal/domain"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockMiniProgramService is a mock of MiniProgramService interface.
+type MockMiniProgramService struct {
+	ctrl     *gomock.Controller
+	recorder *MockMiniProgramServiceMockRecorder
+}
+
+// MockMiniProgramServiceMockRecorder is the mock recorder for MockMiniProgramService.
+type MockMiniProgramServiceMockRecorder struct {
+	mock *MockMiniProgramService
+}
+
+// NewMockMiniProgramService creates a new mock instance.
+func NewMockMiniProgramService(ctrl *gomock.Controller) *MockMiniProgramService {
+	mock := &MockMiniProgramService{ctrl: ctrl}
+	mock.recorder = &MockMiniProgramServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMiniProgramService) EXPECT() *MockMiniProgramServiceMockRecorder {
+	return m.recorder
+}
+
+// Code2Session mocks base method.
+func (m *MockMiniProgramService) Code2Session(ctx context.Context, code string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Code2Session", ctx, code)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Code2Session indicates an expected call of Code2Session.
+func (mr *MockMiniProgramServiceMockRecorder) Code2Session(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Code2Session", reflect.TypeOf((*MockMiniProgramService)(nil).Code2Session), ctx, code)
+}
+
+// DecryptPhone mocks base method.
+func (m *MockMiniProgramService) DecryptPhone(ctx context.Context, sessionId, encryptedData, iv string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecryptPhone", ctx, sessionId, encryptedData, iv)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DecryptPhone indicates an expected call of DecryptPhone.
+func (mr *MockMiniProgramServiceMockRecorder) DecryptPhone(ctx, sessionId, encryptedData, iv interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecryptPhone", reflect.TypeOf((*MockMiniProgramService)(nil).DecryptPhone), ctx, sessionId, encryptedData, iv)
+}
+
+// Info mocks base method.
+func (m *MockMiniProgramService) Info(ctx context.Context, sessionId string) (domain.WechatInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Info", ctx, sessionId)
+	ret0, _ := ret[0].(domain.WechatInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Info indicates an expected call of Info.
+func (mr *MockMiniProgramServiceMockRecorder) Info(ctx, sessionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Info", reflect.TypeOf((*MockMiniProgramService)(nil).Info), ctx, sessionId)
+}
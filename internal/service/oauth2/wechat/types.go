@@ -6,63 +6,127 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 	"webBook/internal/domain"
 	"webBook/pkg/logger"
 )
 
 type Service interface {
 	AuthURL(ctx context.Context, state string) (string, error)
+	// VerifyCode 用授权码换 token，并且拉取用户资料
 	VerifyCode(ctx context.Context, code string) (domain.WechatInfo, error)
+	// RefreshUserInfo 用保存下来的 refresh token 重新拉取用户资料，会返回新的 refresh token
+	RefreshUserInfo(ctx context.Context, refreshToken string) (domain.WechatInfo, error)
 }
 
-var redirectURL = url.PathEscape("https://meoying.com/oauth2/wechat/callback")
+// DefaultBaseURL 微信开放平台的接口地址，测试的时候替换成 httptest 的地址
+const DefaultBaseURL = "https://api.weixin.qq.com"
 
 type service struct {
 	appID     string
 	appSecret string
-	client    *http.Client
-	l         logger.LoggerV1
+	baseURL   string
+	// redirectURL 微信回调的地址，必须在微信开放平台配置的域名下面
+	redirectURL string
+	client      *http.Client
 }
 
-func NewService(appID string, appSecret string, l logger.LoggerV1) Service {
+func NewService(appID string, appSecret string, baseURL string,
+	redirectURL string) Service {
 	return &service{
-		appID:     appID,
-		appSecret: appSecret,
-		client:    http.DefaultClient,
-		l:         l,
+		appID:       appID,
+		appSecret:   appSecret,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		redirectURL: redirectURL,
+		client:      http.DefaultClient,
 	}
 }
+
 func (s *service) VerifyCode(ctx context.Context,
 	code string) (domain.WechatInfo, error) {
-	accessTokenUrl := fmt.Sprintf(`https://api.weixin.qq.com/sns/oauth2/access_token?appid=%s&secret=%s&code=%s&grant_type=authorization_code`,
-		s.appID, s.appSecret, code)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, accessTokenUrl, nil)
+	var res Result
+	err := s.get(ctx, "/sns/oauth2/access_token", url.Values{
+		"appid":      {s.appID},
+		"secret":     {s.appSecret},
+		"code":       {code},
+		"grant_type": {"authorization_code"},
+	}, &res)
 	if err != nil {
 		return domain.WechatInfo{}, err
 	}
-	httpResp, err := s.client.Do(req)
+	return s.userInfo(ctx, res), nil
+}
+
+func (s *service) RefreshUserInfo(ctx context.Context, refreshToken string) (domain.WechatInfo, error) {
+	var res Result
+	err := s.get(ctx, "/sns/oauth2/refresh_token", url.Values{
+		"appid":         {s.appID},
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	}, &res)
 	if err != nil {
 		return domain.WechatInfo{}, err
 	}
-	var res Result
-	err = json.NewDecoder(httpResp.Body).Decode(&res)
+	return s.userInfo(ctx, res), nil
+}
+
+// userInfo 拉取用户资料，失败了不影响登录，只是没有昵称和头像
+func (s *service) userInfo(ctx context.Context, res Result) domain.WechatInfo {
+	info := domain.WechatInfo{
+		UnionId:      res.UnionId,
+		OpenId:       res.OpenId,
+		RefreshToken: res.RefreshToken,
+	}
+	var ui UserInfoResult
+	err := s.get(ctx, "/sns/userinfo", url.Values{
+		"access_token": {res.AccessToken},
+		"openid":       {res.OpenId},
+		"lang":         {"zh_CN"},
+	}, &ui)
+	if err != nil {
+		logger.FromContext(ctx).Warn("获取微信用户资料失败",
+			logger.Field{Key: "openid", Val: res.OpenId},
+			logger.Field{Key: "err", Val: err})
+		return info
+	}
+	info.Nickname = ui.Nickname
+	info.Avatar = ui.HeadImgURL
+	if info.UnionId == "" {
+		info.UnionId = ui.UnionId
+	}
+	return info
+}
+
+// get 调用微信接口，微信出错的时候 HTTP 状态码也是 200，要看 errcode
+func (s *service) get(ctx context.Context, path string, query url.Values, val errResult) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		s.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	httpResp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+	err = json.NewDecoder(httpResp.Body).Decode(val)
 	if err != nil {
 		// 转 JSON 为结构体出错
-		return domain.WechatInfo{}, err
+		return err
 	}
-	if res.ErrCode != 0 {
-		return domain.WechatInfo{},
-			fmt.Errorf("调用微信接口失败 errcode %d, errmsg %s", res.ErrCode, res.ErrMsg)
+	if code, msg := val.errInfo(); code != 0 {
+		return fmt.Errorf("调用微信接口失败 errcode %d, errmsg %s", code, msg)
 	}
-	return domain.WechatInfo{
-		UnionId: res.UnionId,
-		OpenId:  res.OpenId,
-	}, nil
+	return nil
 }
 
 func (s *service) AuthURL(ctx context.Context, state string) (string, error) {
 	const authURLPattern = `https://open.weixin.qq.com/connect/qrconnect?appid=%s&redirect_uri=%s&response_type=code&scope=snsapi_login&state=%s#wechat_redirect`
-	return fmt.Sprintf(authURLPattern, s.appID, redirectURL, state), nil
+	return fmt.Sprintf(authURLPattern, s.appID, url.QueryEscape(s.redirectURL), url.QueryEscape(state)), nil
+}
+
+type errResult interface {
+	errInfo() (int, string)
 }
 
 type Result struct {
@@ -82,3 +146,24 @@ type Result struct {
 	ErrCode int    `json:"errcode"`
 	ErrMsg  string `json:"errmsg"`
 }
+
+func (r *Result) errInfo() (int, string) {
+	return r.ErrCode, r.ErrMsg
+}
+
+// UserInfoResult sns/userinfo 的返回
+type UserInfoResult struct {
+	OpenId   string `json:"openid"`
+	Nickname string `json:"nickname"`
+	// 用户头像，用户没有头像时该项为空
+	HeadImgURL string `json:"headimgurl"`
+	UnionId    string `json:"unionid"`
+
+	// 错误返回
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+func (r *UserInfoResult) errInfo() (int, string) {
+	return r.ErrCode, r.ErrMsg
+}
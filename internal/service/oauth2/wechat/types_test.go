@@ -0,0 +1,138 @@
+package wechat
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"webBook/internal/domain"
+)
+
+// newFakeWechat api.weixin.qq.com 的替身，userinfo 决定 sns/userinfo 返回什么
+func newFakeWechat(t *testing.T, userinfo func(w http.ResponseWriter, r *http.Request)) *httptest.Server {
+	tokenResp := func(w http.ResponseWriter) {
+		_ = json.NewEncoder(w).Encode(Result{
+			AccessToken:  "access",
+			ExpiresIn:    7200,
+			RefreshToken: "new-refresh",
+			OpenId:       "openid-1",
+			Scope:        "snsapi_login",
+		})
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sns/oauth2/access_token", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("code") != "good-code" || q.Get("appid") != "appid" || q.Get("secret") != "secret" {
+			_ = json.NewEncoder(w).Encode(Result{ErrCode: 40029, ErrMsg: "invalid code"})
+			return
+		}
+		tokenResp(w)
+	})
+	mux.HandleFunc("/sns/oauth2/refresh_token", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("refresh_token") != "old-refresh" {
+			_ = json.NewEncoder(w).Encode(Result{ErrCode: 40030, ErrMsg: "invalid refresh_token"})
+			return
+		}
+		tokenResp(w)
+	})
+	mux.HandleFunc("/sns/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		require.Equal(t, "access", q.Get("access_token"))
+		require.Equal(t, "openid-1", q.Get("openid"))
+		userinfo(w, r)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestService_VerifyCode(t *testing.T) {
+	okUserInfo := func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(UserInfoResult{
+			OpenId:     "openid-1",
+			Nickname:   "Tom",
+			HeadImgURL: "https://thirdwx.qlogo.cn/tom.png",
+			UnionId:    "unionid-1",
+		})
+	}
+	testCases := []struct {
+		name     string
+		code     string
+		userinfo func(w http.ResponseWriter, r *http.Request)
+
+		wantInfo domain.WechatInfo
+		wantErr  bool
+	}{
+		{
+			name:     "登录成功",
+			code:     "good-code",
+			userinfo: okUserInfo,
+			wantInfo: domain.WechatInfo{
+				OpenId:       "openid-1",
+				UnionId:      "unionid-1",
+				Nickname:     "Tom",
+				Avatar:       "https://thirdwx.qlogo.cn/tom.png",
+				RefreshToken: "new-refresh",
+			},
+		},
+		{
+			name: "拉取资料失败不影响登录",
+			code: "good-code",
+			userinfo: func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewEncoder(w).Encode(UserInfoResult{ErrCode: 40003, ErrMsg: "invalid openid"})
+			},
+			wantInfo: domain.WechatInfo{
+				OpenId:       "openid-1",
+				RefreshToken: "new-refresh",
+			},
+		},
+		{
+			name:     "授权码不对",
+			code:     "bad-code",
+			userinfo: okUserInfo,
+			wantErr:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newFakeWechat(t, tc.userinfo)
+			svc := NewService("appid", "secret", server.URL, "https://meoying.com/oauth2/wechat/callback")
+			info, err := svc.VerifyCode(context.Background(), tc.code)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.wantInfo, info)
+		})
+	}
+}
+
+func TestService_RefreshUserInfo(t *testing.T) {
+	server := newFakeWechat(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(UserInfoResult{
+			OpenId:   "openid-1",
+			Nickname: "Jerry",
+		})
+	})
+	svc := NewService("appid", "secret", server.URL, "https://meoying.com/oauth2/wechat/callback")
+	info, err := svc.RefreshUserInfo(context.Background(), "old-refresh")
+	require.NoError(t, err)
+	assert.Equal(t, domain.WechatInfo{
+		OpenId:       "openid-1",
+		Nickname:     "Jerry",
+		RefreshToken: "new-refresh",
+	}, info)
+
+	_, err = svc.RefreshUserInfo(context.Background(), "expired")
+	assert.Error(t, err)
+}
+
+func TestService_AuthURL(t *testing.T) {
+	svc := NewService("appid", "secret", DefaultBaseURL,
+		"https://example.com/oauth2/wechat/callback")
+	val, err := svc.AuthURL(context.Background(), "my-state")
+	require.NoError(t, err)
+	assert.Equal(t, "https://open.weixin.qq.com/connect/qrconnect?appid=appid"+
+		"&redirect_uri=https%3A%2F%2Fexample.com%2Foauth2%2Fwechat%2Fcallback"+
+		"&response_type=code&scope=snsapi_login&state=my-state#wechat_redirect", val)
+}
@@ -0,0 +1,111 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/service/sms/auth/types.go
+
+// Package authmocks is a generated GoMock package.
+package authmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockService is a mock of Service interface.
+type MockService struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceMockRecorder
+}
+
+// MockServiceMockRecorder is the mock recorder for MockService.
+type MockServiceMockRecorder struct {
+	mock *MockService
+}
+
+// NewMockService creates a new mock instance.
+func NewMockService(ctrl *gomock.Controller) *MockService {
+	mock := &MockService{ctrl: ctrl}
+	mock.recorder = &MockServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockService) EXPECT() *MockServiceMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method.
+func (m *MockService) Send(ctx context.Context, biz, tplToken string, args []string, numbers ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, biz, tplToken, args}
+	for _, a := range numbers {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Send", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockServiceMockRecorder) Send(ctx, biz, tplToken, args interface{}, numbers ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, biz, tplToken, args}, numbers...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockService)(nil).Send), varargs...)
+}
+
+// SendWithTpl mocks base method.
+func (m *MockService) SendWithTpl(ctx context.Context, biz, tplToken, tplId string, args []string, numbers ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, biz, tplToken, tplId, args}
+	for _, a := range numbers {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SendWithTpl", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendWithTpl indicates an expected call of SendWithTpl.
+func (mr *MockServiceMockRecorder) SendWithTpl(ctx, biz, tplToken, tplId, args interface{}, numbers ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, biz, tplToken, tplId, args}, numbers...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendWithTpl", reflect.TypeOf((*MockService)(nil).SendWithTpl), varargs...)
+}
+
+// MockTokenSource is a mock of TokenSource interface.
+type MockTokenSource struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenSourceMockRecorder
+}
+
+// MockTokenSourceMockRecorder is the mock recorder for MockTokenSource.
+type MockTokenSourceMockRecorder struct {
+	mock *MockTokenSource
+}
+
+// NewMockTokenSource creates a new mock instance.
+func NewMockTokenSource(ctrl *gomock.Controller) *MockTokenSource {
+	mock := &MockTokenSource{ctrl: ctrl}
+	mock.recorder = &MockTokenSourceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTokenSource) EXPECT() *MockTokenSourceMockRecorder {
+	return m.recorder
+}
+
+// Token mocks base method.
+func (m *MockTokenSource) Token() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Token")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Token indicates an expected call of Token.
+func (mr *MockTokenSourceMockRecorder) Token() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Token", reflect.TypeOf((*MockTokenSource)(nil).Token))
+}
@@ -2,28 +2,117 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/golang-jwt/jwt/v5"
+	"time"
 	"webBook/internal/service/sms"
 )
 
+var (
+	ErrInvalidToken  = errors.New("非法的模板 token")
+	ErrTokenExpired  = errors.New("模板 token 已过期")
+	ErrTplNotAllowed = errors.New("模板 token 无权使用该模板")
+	ErrBizMismatch   = errors.New("模板 token 不是签发给该业务的")
+	ErrNoExpiration  = errors.New("模板 token 必须设置过期时间")
+)
+
+var _ Service = &SMSService{}
+
+// SMSService 校验业务方的模板 token，只有拿到授权的业务方才能发短信
+// 调用方在 tplId 的位置传入 token，而不是直接传模板 ID
 type SMSService struct {
-	svc sms.Service
-	key []byte
+	svc           sms.Service
+	key           []byte
+	signingMethod jwt.SigningMethod
 }
 
-func (s *SMSService) Send(ctx context.Context, tplToken string, args []string, numbers ...string) error {
-	var claims SMSClaims
-	_, err := jwt.ParseWithClaims(tplToken, &claims, func(token *jwt.Token) (interface{}, error) {
-		return s.key, nil
-	})
+func NewSMSService(svc sms.Service, key []byte) *SMSService {
+	return &SMSService{
+		svc:           svc,
+		key:           key,
+		signingMethod: jwt.SigningMethodHS512,
+	}
+}
+
+// GenerateToken 给业务方 biz 签发模板 token，tpls 是允许使用的模板，第一个是默认模板。
+// token 一定会过期，内部业务用 NewTokenSource 在过期之前自动重新签发
+func (s *SMSService) GenerateToken(biz string, expiration time.Duration, tpls ...string) (string, error) {
+	if len(tpls) == 0 {
+		return "", fmt.Errorf("%w: 至少需要一个模板", ErrTplNotAllowed)
+	}
+	if expiration <= 0 {
+		return "", ErrNoExpiration
+	}
+	now := time.Now()
+	claims := SMSClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   biz,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiration)),
+		},
+		Biz:  biz,
+		Tpls: tpls,
+	}
+	return jwt.NewWithClaims(s.signingMethod, claims).SignedString(s.key)
+}
+
+// Send 使用 token 中的默认模板发送，biz 是调用方的业务
+func (s *SMSService) Send(ctx context.Context, biz, tplToken string, args []string, numbers ...string) error {
+	claims, err := s.parse(biz, tplToken)
+	if err != nil {
+		return err
+	}
+	if len(claims.Tpls) == 0 {
+		return ErrTplNotAllowed
+	}
+	return s.svc.Send(ctx, claims.Tpls[0], args, numbers...)
+}
+
+// SendWithTpl 使用指定的模板发送，模板必须在 token 授权的范围内
+func (s *SMSService) SendWithTpl(ctx context.Context, biz, tplToken, tplId string,
+	args []string, numbers ...string) error {
+	claims, err := s.parse(biz, tplToken)
 	if err != nil {
 		return err
 	}
-	return s.svc.Send(ctx, claims.Tpl, args, numbers...)
+	if !claims.Allow(tplId) {
+		return ErrTplNotAllowed
+	}
+	return s.svc.Send(ctx, tplId, args, numbers...)
+}
+
+// parse 校验 token，并且 token 要是签发给 biz 的，拿别的业务的 token 不能发
+func (s *SMSService) parse(biz, tplToken string) (SMSClaims, error) {
+	var claims SMSClaims
+	token, err := jwt.ParseWithClaims(tplToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		return s.key, nil
+	}, jwt.WithValidMethods([]string{s.signingMethod.Alg()}))
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return SMSClaims{}, ErrTokenExpired
+	case err != nil:
+		return SMSClaims{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	case token == nil || !token.Valid:
+		return SMSClaims{}, ErrInvalidToken
+	case claims.Biz != biz:
+		return SMSClaims{}, fmt.Errorf("%w: %s", ErrBizMismatch, biz)
+	}
+	return claims, nil
 }
 
 type SMSClaims struct {
 	jwt.RegisteredClaims
-	Tpl string
-	// 额外加字段
+	Biz string
+	// 允许使用的模板
+	Tpls []string
+}
+
+func (c SMSClaims) Allow(tplId string) bool {
+	for _, tpl := range c.Tpls {
+		if tpl == tplId {
+			return true
+		}
+	}
+	return false
 }
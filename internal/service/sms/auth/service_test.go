@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"context"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+	"webBook/internal/service/sms"
+	smsmocks "webBook/internal/service/sms/mocks"
+)
+
+var testKey = []byte("k6CswdUm77WKcbM68UQUuxVsHSpTCwgS")
+
+func TestSMSService_Send(t *testing.T) {
+	testCases := []struct {
+		name  string
+		mock  func(ctrl *gomock.Controller) sms.Service
+		token func(t *testing.T) string
+
+		wantErr error
+	}{
+		{
+			name: "发送成功",
+			mock: func(ctrl *gomock.Controller) sms.Service {
+				svc := smsmocks.NewMockService(ctrl)
+				svc.EXPECT().Send(gomock.Any(), "123", []string{"abc"}, "15212345678").
+					Return(nil)
+				return svc
+			},
+			token: func(t *testing.T) string {
+				token, err := NewSMSService(nil, testKey).
+					GenerateToken("login", time.Minute, "123", "456")
+				require.NoError(t, err)
+				return token
+			},
+		},
+		{
+			name: "token 是签发给别的业务的",
+			mock: func(ctrl *gomock.Controller) sms.Service {
+				return smsmocks.NewMockService(ctrl)
+			},
+			token: func(t *testing.T) string {
+				token, err := NewSMSService(nil, testKey).
+					GenerateToken("marketing", time.Minute, "123")
+				require.NoError(t, err)
+				return token
+			},
+			wantErr: ErrBizMismatch,
+		},
+		{
+			name: "token 过期",
+			mock: func(ctrl *gomock.Controller) sms.Service {
+				return smsmocks.NewMockService(ctrl)
+			},
+			token: func(t *testing.T) string {
+				return signClaims(t, jwt.SigningMethodHS512, testKey, SMSClaims{
+					RegisteredClaims: jwt.RegisteredClaims{
+						ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+					},
+					Biz:  "login",
+					Tpls: []string{"123"},
+				})
+			},
+			wantErr: ErrTokenExpired,
+		},
+		{
+			name: "签名 key 不对",
+			mock: func(ctrl *gomock.Controller) sms.Service {
+				return smsmocks.NewMockService(ctrl)
+			},
+			token: func(t *testing.T) string {
+				return signClaims(t, jwt.SigningMethodHS512, []byte("another key"), SMSClaims{
+					Biz:  "login",
+					Tpls: []string{"123"},
+				})
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "签名算法不对",
+			mock: func(ctrl *gomock.Controller) sms.Service {
+				return smsmocks.NewMockService(ctrl)
+			},
+			token: func(t *testing.T) string {
+				return signClaims(t, jwt.SigningMethodHS256, testKey, SMSClaims{
+					Biz:  "login",
+					Tpls: []string{"123"},
+				})
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "token 格式不对",
+			mock: func(ctrl *gomock.Controller) sms.Service {
+				return smsmocks.NewMockService(ctrl)
+			},
+			token: func(t *testing.T) string {
+				return "1877556"
+			},
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "token 没有授权任何模板",
+			mock: func(ctrl *gomock.Controller) sms.Service {
+				return smsmocks.NewMockService(ctrl)
+			},
+			token: func(t *testing.T) string {
+				return signClaims(t, jwt.SigningMethodHS512, testKey, SMSClaims{
+					RegisteredClaims: jwt.RegisteredClaims{
+						ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+					},
+					Biz: "login",
+				})
+			},
+			wantErr: ErrTplNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewSMSService(tc.mock(ctrl), testKey)
+			err := svc.Send(context.Background(), "login", tc.token(t),
+				[]string{"abc"}, "15212345678")
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestSMSService_SendWithTpl(t *testing.T) {
+	testCases := []struct {
+		name  string
+		mock  func(ctrl *gomock.Controller) sms.Service
+		tplId string
+
+		wantErr error
+	}{
+		{
+			name: "使用授权的模板",
+			mock: func(ctrl *gomock.Controller) sms.Service {
+				svc := smsmocks.NewMockService(ctrl)
+				svc.EXPECT().Send(gomock.Any(), "456", []string{"abc"}, "15212345678").
+					Return(nil)
+				return svc
+			},
+			tplId: "456",
+		},
+		{
+			name: "模板不在授权范围内",
+			mock: func(ctrl *gomock.Controller) sms.Service {
+				return smsmocks.NewMockService(ctrl)
+			},
+			tplId: "789",
+
+			wantErr: ErrTplNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewSMSService(tc.mock(ctrl), testKey)
+			token, err := svc.GenerateToken("login", time.Minute, "123", "456")
+			require.NoError(t, err)
+			err = svc.SendWithTpl(context.Background(), "login", token, tc.tplId,
+				[]string{"abc"}, "15212345678")
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestSMSService_GenerateToken(t *testing.T) {
+	svc := NewSMSService(nil, testKey)
+	_, err := svc.GenerateToken("login", time.Minute)
+	assert.ErrorIs(t, err, ErrTplNotAllowed)
+
+	// 不允许签发不过期的 token
+	_, err = svc.GenerateToken("login", 0, "123")
+	assert.ErrorIs(t, err, ErrNoExpiration)
+
+	token, err := svc.GenerateToken("login", time.Minute, "123")
+	require.NoError(t, err)
+	claims, err := svc.parse("login", token)
+	require.NoError(t, err)
+	assert.NotNil(t, claims.ExpiresAt)
+	assert.Equal(t, "login", claims.Biz)
+	assert.Equal(t, []string{"123"}, claims.Tpls)
+}
+
+func TestRotatingToken_Token(t *testing.T) {
+	svc := NewSMSService(nil, testKey)
+	_, err := svc.NewTokenSource("login", 0, "123")
+	assert.ErrorIs(t, err, ErrNoExpiration)
+
+	ts, err := svc.NewTokenSource("login", time.Hour, "123")
+	require.NoError(t, err)
+	first, err := ts.Token()
+	require.NoError(t, err)
+	second, err := ts.Token()
+	require.NoError(t, err)
+	// 有效期还没过一半，用的是同一个
+	assert.Equal(t, first, second)
+
+	// 过了一半有效期，重新签发
+	ts.refreshAt = time.Now().Add(-time.Second)
+	ts.token = "old"
+	third, err := ts.Token()
+	require.NoError(t, err)
+	assert.NotEqual(t, "old", third)
+	_, err = svc.parse("login", third)
+	assert.NoError(t, err)
+}
+
+func signClaims(t *testing.T, method jwt.SigningMethod, key []byte, claims SMSClaims) string {
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	require.NoError(t, err)
+	return token
+}
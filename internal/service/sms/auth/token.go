@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// StaticToken 固定的 token，外部业务申请好的 token 直接用
+type StaticToken string
+
+func (t StaticToken) Token() (string, error) {
+	return string(t), nil
+}
+
+// RotatingToken 内部业务用的 token，用过一半有效期就重新签发，
+// 这样 token 泄露了也只能用一段时间
+type RotatingToken struct {
+	svc        *SMSService
+	biz        string
+	tpls       []string
+	expiration time.Duration
+
+	mu        sync.Mutex
+	token     string
+	refreshAt time.Time
+}
+
+// NewTokenSource 给内部业务 biz 申请一个自动轮换的 token，启动的时候就签发一次，有问题尽早暴露
+func (s *SMSService) NewTokenSource(biz string, expiration time.Duration, tpls ...string) (*RotatingToken, error) {
+	t := &RotatingToken{
+		svc:        s,
+		biz:        biz,
+		tpls:       tpls,
+		expiration: expiration,
+	}
+	_, err := t.Token()
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *RotatingToken) Token() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if t.token != "" && now.Before(t.refreshAt) {
+		return t.token, nil
+	}
+	token, err := t.svc.GenerateToken(t.biz, t.expiration, t.tpls...)
+	if err != nil {
+		return "", err
+	}
+	t.token = token
+	t.refreshAt = now.Add(t.expiration / 2)
+	return token, nil
+}
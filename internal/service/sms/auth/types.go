@@ -0,0 +1,17 @@
+package auth
+
+import "context"
+
+// Service 业务方用模板 token 发短信，biz 是调用方自己的业务，
+// 要和 token 签发给的业务一致，拿到别的业务的 token 也不能用
+type Service interface {
+	// Send 使用 token 中的默认模板发送
+	Send(ctx context.Context, biz, tplToken string, args []string, numbers ...string) error
+	// SendWithTpl 使用指定的模板发送，模板必须在 token 授权的范围内
+	SendWithTpl(ctx context.Context, biz, tplToken, tplId string, args []string, numbers ...string) error
+}
+
+// TokenSource 提供当前可用的模板 token
+type TokenSource interface {
+	Token() (string, error)
+}
@@ -3,9 +3,9 @@ package failover
 import (
 	"context"
 	"errors"
-	"log"
 	"sync/atomic"
 	"webBook/internal/service/sms"
+	"webBook/pkg/logger"
 )
 
 type FailOverSMSService struct {
@@ -28,7 +28,7 @@ func (f *FailOverSMSService) Send(ctx context.Context, tplId string, args []stri
 		if err == nil {
 			return nil
 		}
-		log.Println(err)
+		logger.FromContext(ctx).Warn("短信服务商发送失败", logger.Field{Key: "err", Val: err})
 	}
 	return errors.New("轮询了所有的服务商，但是发送都失败了")
 }
@@ -50,7 +50,7 @@ func (f *FailOverSMSService) SendV1(ctx context.Context, tplId string, args []st
 			// 前者是被取消，后者是超时
 			return err
 		}
-		log.Println(err)
+		logger.FromContext(ctx).Warn("短信服务商发送失败", logger.Field{Key: "err", Val: err})
 	}
 	return errors.New("轮询了所有的服务商，但是发送都失败了")
 }
@@ -2,7 +2,7 @@ package localsms
 
 import (
 	"context"
-	"log"
+	"webBook/pkg/logger"
 )
 
 type Service struct {
@@ -13,6 +13,6 @@ func NewService() *Service {
 }
 
 func (s *Service) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
-	log.Println("验证码是", args)
+	logger.FromContext(ctx).Info("验证码是", logger.Field{Key: "args", Val: args})
 	return nil
 }
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+	"webBook/internal/service/sms"
+	"webBook/internal/service/sms/ratelimit"
+	"webBook/pkg/prometheusx"
+)
+
+var _ sms.Service = &Service{}
+
+// Service 按照供应商、模板和结果统计发送短信的次数和耗时
+type Service struct {
+	svc      sms.Service
+	provider string
+	sends    *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+func NewService(svc sms.Service, provider string) *Service {
+	return &Service{
+		svc:      svc,
+		provider: provider,
+		sends: prometheusx.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "webook",
+			Subsystem: "sms",
+			Name:      "send_total",
+			Help:      "发送短信的次数",
+		}, []string{"provider", "tpl", "outcome"})),
+		duration: prometheusx.MustRegister(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "webook",
+			Subsystem: "sms",
+			Name:      "send_duration_seconds",
+			Help:      "发送短信的耗时",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		}, []string{"provider"})),
+	}
+}
+
+func (s *Service) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	start := time.Now()
+	err := s.svc.Send(ctx, tplId, args, numbers...)
+	s.duration.WithLabelValues(s.provider).Observe(time.Since(start).Seconds())
+	s.sends.WithLabelValues(s.provider, tplId, outcome(err)).Inc()
+	return err
+}
+
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, ratelimit.ErrLimited):
+		return "limited"
+	default:
+		return "failure"
+	}
+}
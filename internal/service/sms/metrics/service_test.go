@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	smsmocks "webBook/internal/service/sms/mocks"
+	"webBook/internal/service/sms/ratelimit"
+)
+
+func TestService_Send(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	svc := smsmocks.NewMockService(ctrl)
+	svc.EXPECT().Send(gomock.Any(), "login", gomock.Any(), gomock.Any()).Return(nil)
+	svc.EXPECT().Send(gomock.Any(), "login", gomock.Any(), gomock.Any()).Return(ratelimit.ErrLimited)
+	svc.EXPECT().Send(gomock.Any(), "login", gomock.Any(), gomock.Any()).Return(errors.New("供应商出错"))
+
+	s := NewService(svc, "tencent")
+	ctx := context.Background()
+	assert.NoError(t, s.Send(ctx, "login", []string{"123456"}, "15212345678"))
+	assert.ErrorIs(t, s.Send(ctx, "login", []string{"123456"}, "15212345678"), ratelimit.ErrLimited)
+	assert.Error(t, s.Send(ctx, "login", []string{"123456"}, "15212345678"))
+
+	for _, oc := range []string{"success", "limited", "failure"} {
+		assert.Equal(t, float64(1), testutil.ToFloat64(s.sends.WithLabelValues("tencent", "login", oc)), oc)
+	}
+}
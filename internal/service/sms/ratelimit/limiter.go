@@ -3,19 +3,60 @@ package ratelimit
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"webBook/internal/service/sms"
 	"webBook/pkg/limiter"
+	"webBook/pkg/logger"
 )
 
-var errLimited = errors.New("触发限流")
+// ErrLimited 触发限流，部分号码被限流的时候是 *NumberLimitError
+var ErrLimited = errors.New("触发限流")
 
 var _ sms.Service = &RateLimitSMSService{}
 
+// Limiters 每个维度的限流器，nil 就是这个维度不限流
+type Limiters struct {
+	// Global 所有短信共用一个 key
+	Global limiter.Limiter
+	// Tpl 每个模板一个 key
+	Tpl limiter.Limiter
+	// Number 每个手机号码一个 key，避免一个号码把全局的额度用完
+	Number limiter.Limiter
+}
+
+// NumberLimitError 部分号码被限流了，没有被限流的号码已经发送出去了
+type NumberLimitError struct {
+	// Results 每个号码的限流结果，true 就是被限流了
+	Results map[string]bool
+}
+
+func (e *NumberLimitError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrLimited.Error(), strings.Join(e.Limited(), ","))
+}
+
+func (e *NumberLimitError) Unwrap() error {
+	return ErrLimited
+}
+
+// Limited 被限流的号码
+func (e *NumberLimitError) Limited() []string {
+	res := make([]string, 0, len(e.Results))
+	for number, limited := range e.Results {
+		if limited {
+			res = append(res, number)
+		}
+	}
+	slices.Sort(res)
+	return res
+}
+
 type RateLimitSMSService struct {
 	// 被装饰的
-	svc     sms.Service
-	limiter limiter.Limiter
-	key     string
+	svc      sms.Service
+	limiters Limiters
+	prefix   string
 }
 
 type RateLimitSMSServiceV1 struct {
@@ -24,22 +65,97 @@ type RateLimitSMSServiceV1 struct {
 	key     string
 }
 
+// Send 先按模板和全局限流，再按号码限流，被模板或者全局拒绝的时候不会占用号码的额度。
+// 号码全部被限流或者发送失败的时候，已经占用的额度都还回去
 func (r *RateLimitSMSService) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
-	limited, err := r.limiter.Limit(ctx, r.key)
+	var taken []quotaKey
+	err := r.take(ctx, &taken, r.limiters.Tpl, fmt.Sprintf("%s:tpl:%s", r.prefix, tplId))
+	if err != nil {
+		return err
+	}
+	err = r.take(ctx, &taken, r.limiters.Global, r.prefix)
+	if err != nil {
+		r.refund(ctx, taken)
+		return err
+	}
+	allowed := numbers
+	var results map[string]bool
+	if r.limiters.Number != nil {
+		allowed = make([]string, 0, len(numbers))
+		results = make(map[string]bool, len(numbers))
+		for _, number := range numbers {
+			key := fmt.Sprintf("%s:number:%s", r.prefix, number)
+			ticket, limited, err := limiter.Take(ctx, r.limiters.Number, key)
+			if err != nil {
+				r.refund(ctx, taken)
+				return err
+			}
+			results[number] = limited
+			if !limited {
+				allowed = append(allowed, number)
+				taken = append(taken, quotaKey{l: r.limiters.Number, ticket: ticket})
+			}
+		}
+		if len(allowed) == 0 {
+			r.refund(ctx, taken)
+			return &NumberLimitError{Results: results}
+		}
+	}
+	err = r.svc.Send(ctx, tplId, args, allowed...)
+	if err != nil {
+		r.refund(ctx, taken)
+		return err
+	}
+	if len(allowed) < len(numbers) {
+		return &NumberLimitError{Results: results}
+	}
+	return nil
+}
+
+// quotaKey 已经占用的额度
+type quotaKey struct {
+	l      limiter.Limiter
+	ticket limiter.Ticket
+}
+
+// take 占用 l 的额度，成功了记到 taken 里面
+func (r *RateLimitSMSService) take(ctx context.Context, taken *[]quotaKey, l limiter.Limiter, key string) error {
+	if l == nil {
+		return nil
+	}
+	ticket, limited, err := limiter.Take(ctx, l, key)
 	if err != nil {
 		return err
 	}
 	if limited {
-		return errLimited
+		return ErrLimited
+	}
+	*taken = append(*taken, quotaKey{l: l, ticket: ticket})
+	return nil
+}
+
+// refund 退还额度，退还失败只是少发几条，记录一下就可以
+func (r *RateLimitSMSService) refund(ctx context.Context, taken []quotaKey) {
+	ctx = context.WithoutCancel(ctx)
+	for _, q := range taken {
+		err := limiter.Refund(ctx, q.l, q.ticket)
+		if err != nil {
+			logger.FromContext(ctx).Error("退还短信限流额度失败",
+				logger.Field{Key: "key", Val: q.ticket.Key}, logger.Field{Key: "err", Val: err})
+		}
 	}
-	return r.svc.Send(ctx, tplId, args, numbers...)
 }
 
+// NewRateLimitSMSService 只有全局限流
 func NewRateLimitSMSService(svc sms.Service,
 	l limiter.Limiter) *RateLimitSMSService {
+	return NewCompositeRateLimitSMSService(svc, Limiters{Global: l})
+}
+
+func NewCompositeRateLimitSMSService(svc sms.Service, limiters Limiters) *RateLimitSMSService {
 	return &RateLimitSMSService{
-		svc:     svc,
-		limiter: l,
-		key:     "sms-limiter",
+		svc:      svc,
+		limiters: limiters,
+		prefix:   "sms-limiter",
 	}
 }
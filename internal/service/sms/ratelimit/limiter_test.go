@@ -41,7 +41,7 @@ func TestRateLimitSMSService_Send(t *testing.T) {
 				l.EXPECT().Limit(gomock.Any(), gomock.Any()).Return(true, nil)
 				return svc, l
 			},
-			wantErr: errLimited,
+			wantErr: ErrLimited,
 		},
 		{
 			name: "限流器错误",
@@ -68,3 +68,166 @@ func TestRateLimitSMSService_Send(t *testing.T) {
 		})
 	}
 }
+
+// refundLimiter 可以退还额度的限流器
+type refundLimiter struct {
+	*limitermocks.MockLimiter
+	*limitermocks.MockRefunder
+}
+
+func newRefundLimiter(ctrl *gomock.Controller) refundLimiter {
+	return refundLimiter{
+		MockLimiter:  limitermocks.NewMockLimiter(ctrl),
+		MockRefunder: limitermocks.NewMockRefunder(ctrl),
+	}
+}
+
+// ticket 测试里面限流器返回的 Ticket
+func ticket(key string) limiter.Ticket {
+	return limiter.Ticket{Key: key, Window: "20240101"}
+}
+
+func TestRateLimitSMSService_SendComposite(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (sms.Service, Limiters)
+
+		numbers     []string
+		wantErr     error
+		wantLimited []string
+	}{
+		{
+			name: "都没有限流",
+			mock: func(ctrl *gomock.Controller) (sms.Service, Limiters) {
+				svc := smsmocks.NewMockService(ctrl)
+				global := limitermocks.NewMockLimiter(ctrl)
+				tpl := limitermocks.NewMockLimiter(ctrl)
+				number := limitermocks.NewMockLimiter(ctrl)
+				number.EXPECT().Limit(gomock.Any(), "sms-limiter:number:131").Return(false, nil)
+				number.EXPECT().Limit(gomock.Any(), "sms-limiter:number:132").Return(false, nil)
+				tpl.EXPECT().Limit(gomock.Any(), "sms-limiter:tpl:abc").Return(false, nil)
+				global.EXPECT().Limit(gomock.Any(), "sms-limiter").Return(false, nil)
+				svc.EXPECT().Send(gomock.Any(), "abc", []string{"123"}, "131", "132").Return(nil)
+				return svc, Limiters{Global: global, Tpl: tpl, Number: number}
+			},
+			numbers: []string{"131", "132"},
+		},
+		{
+			name: "部分号码被限流",
+			mock: func(ctrl *gomock.Controller) (sms.Service, Limiters) {
+				svc := smsmocks.NewMockService(ctrl)
+				global := limitermocks.NewMockLimiter(ctrl)
+				number := limitermocks.NewMockLimiter(ctrl)
+				number.EXPECT().Limit(gomock.Any(), "sms-limiter:number:131").Return(true, nil)
+				number.EXPECT().Limit(gomock.Any(), "sms-limiter:number:132").Return(false, nil)
+				global.EXPECT().Limit(gomock.Any(), "sms-limiter").Return(false, nil)
+				// 只发给没有被限流的
+				svc.EXPECT().Send(gomock.Any(), "abc", []string{"123"}, "132").Return(nil)
+				return svc, Limiters{Global: global, Number: number}
+			},
+			numbers:     []string{"131", "132"},
+			wantErr:     ErrLimited,
+			wantLimited: []string{"131"},
+		},
+		{
+			name: "号码全部被限流，退还全局额度",
+			mock: func(ctrl *gomock.Controller) (sms.Service, Limiters) {
+				svc := smsmocks.NewMockService(ctrl)
+				global := newRefundLimiter(ctrl)
+				global.MockRefunder.EXPECT().Take(gomock.Any(), "sms-limiter").Return(ticket("sms-limiter"), false, nil)
+				global.MockRefunder.EXPECT().Refund(gomock.Any(), ticket("sms-limiter")).Return(nil)
+				number := limitermocks.NewMockLimiter(ctrl)
+				number.EXPECT().Limit(gomock.Any(), gomock.Any()).Return(true, nil).Times(2)
+				return svc, Limiters{Global: global, Number: number}
+			},
+			numbers:     []string{"131", "132"},
+			wantErr:     ErrLimited,
+			wantLimited: []string{"131", "132"},
+		},
+		{
+			name: "全局限流，不占用号码额度",
+			mock: func(ctrl *gomock.Controller) (sms.Service, Limiters) {
+				svc := smsmocks.NewMockService(ctrl)
+				tpl := newRefundLimiter(ctrl)
+				tpl.MockRefunder.EXPECT().Take(gomock.Any(), "sms-limiter:tpl:abc").
+					Return(ticket("sms-limiter:tpl:abc"), false, nil)
+				tpl.MockRefunder.EXPECT().Refund(gomock.Any(), ticket("sms-limiter:tpl:abc")).Return(nil)
+				global := limitermocks.NewMockLimiter(ctrl)
+				global.EXPECT().Limit(gomock.Any(), "sms-limiter").Return(true, nil)
+				return svc, Limiters{Global: global, Tpl: tpl, Number: limitermocks.NewMockLimiter(ctrl)}
+			},
+			numbers: []string{"131"},
+			wantErr: ErrLimited,
+		},
+		{
+			name: "发送失败，退还所有额度",
+			mock: func(ctrl *gomock.Controller) (sms.Service, Limiters) {
+				svc := smsmocks.NewMockService(ctrl)
+				global := newRefundLimiter(ctrl)
+				global.MockRefunder.EXPECT().Take(gomock.Any(), "sms-limiter").Return(ticket("sms-limiter"), false, nil)
+				global.MockRefunder.EXPECT().Refund(gomock.Any(), ticket("sms-limiter")).Return(nil)
+				number := newRefundLimiter(ctrl)
+				number.MockRefunder.EXPECT().Take(gomock.Any(), "sms-limiter:number:131").
+					Return(ticket("sms-limiter:number:131"), false, nil)
+				number.MockRefunder.EXPECT().Take(gomock.Any(), "sms-limiter:number:132").
+					Return(ticket("sms-limiter:number:132"), true, nil)
+				// 被限流的号码没有占用额度，不用退还
+				number.MockRefunder.EXPECT().Refund(gomock.Any(), ticket("sms-limiter:number:131")).Return(nil)
+				svc.EXPECT().Send(gomock.Any(), "abc", []string{"123"}, "131").
+					Return(errors.New("短信服务错误"))
+				return svc, Limiters{Global: global, Number: number}
+			},
+			numbers: []string{"131", "132"},
+			wantErr: errors.New("短信服务错误"),
+		},
+		{
+			name: "模板限流",
+			mock: func(ctrl *gomock.Controller) (sms.Service, Limiters) {
+				svc := smsmocks.NewMockService(ctrl)
+				tpl := limitermocks.NewMockLimiter(ctrl)
+				tpl.EXPECT().Limit(gomock.Any(), "sms-limiter:tpl:abc").Return(true, nil)
+				return svc, Limiters{Tpl: tpl}
+			},
+			numbers: []string{"131"},
+			wantErr: ErrLimited,
+		},
+		{
+			name: "号码限流器错误",
+			mock: func(ctrl *gomock.Controller) (sms.Service, Limiters) {
+				svc := smsmocks.NewMockService(ctrl)
+				number := limitermocks.NewMockLimiter(ctrl)
+				number.EXPECT().Limit(gomock.Any(), gomock.Any()).
+					Return(false, errors.New("redis限流器错误"))
+				return svc, Limiters{Number: number}
+			},
+			numbers: []string{"131"},
+			wantErr: errors.New("redis限流器错误"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			smsSvc, limiters := tc.mock(ctrl)
+			svc := NewCompositeRateLimitSMSService(smsSvc, limiters)
+			err := svc.Send(context.Background(), "abc",
+				[]string{"123"}, tc.numbers...)
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			if errors.Is(tc.wantErr, ErrLimited) {
+				assert.ErrorIs(t, err, ErrLimited)
+			} else {
+				assert.Equal(t, tc.wantErr, err)
+			}
+			var nle *NumberLimitError
+			if errors.As(err, &nle) {
+				assert.Equal(t, tc.wantLimited, nle.Limited())
+			} else {
+				assert.Empty(t, tc.wantLimited)
+			}
+		})
+	}
+}
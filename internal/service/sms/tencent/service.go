@@ -6,7 +6,8 @@ import (
 	"github.com/ecodeclub/ekit"
 	"github.com/ecodeclub/ekit/slice"
 	sms "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/sms/v20210111"
-	"go.uber.org/zap"
+	"webBook/pkg/logger"
+	"webBook/pkg/phonex"
 )
 
 // Service 结构体定义，包含腾讯云SMS客户端和相关配置。
@@ -14,29 +15,36 @@ type Service struct {
 	client   *sms.Client // client字段，指向腾讯云SMS服务的客户端实例。
 	appId    *string     // appId字段，存储腾讯云应用ID。
 	signName *string     // signName字段，存储短信签名。
+	// phoneParser 腾讯云要求号码是 E.164 格式，和 web 层用同一个配置，支持的地区才一致。
+	phoneParser *phonex.Parser
 }
 
-func NewService(client *sms.Client, appId string, signName string) *Service {
+func NewService(client *sms.Client, appId string, signName string, phoneParser *phonex.Parser) *Service {
 	return &Service{
-		client:   client,
-		appId:    &appId,
-		signName: &signName,
+		client:      client,
+		appId:       &appId,
+		signName:    &signName,
+		phoneParser: phoneParser,
 	}
 }
 
 // Send 方法，发送短信。
 func (s *Service) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	phones, err := s.formatNumbers(numbers) // 国际短信必须带上国家码。
+	if err != nil {
+		return err
+	}
 	request := sms.NewSendSmsRequest()             // 创建发送短信的请求实例。
 	request.SetContext(ctx)                        // 设置请求上下文。
 	request.SmsSdkAppId = s.appId                  // 设置短信SDK应用ID。
 	request.SignName = s.signName                  // 设置短信签名。
 	request.TemplateId = ekit.ToPtr[string](tplId) // 设置短信模板ID。
 	request.TemplateParamSet = s.toPtrSlice(args)  // 设置短信模板参数。
-	request.PhoneNumberSet = s.toPtrSlice(numbers) // 设置接收短信的手机号码。
+	request.PhoneNumberSet = s.toPtrSlice(phones)  // 设置接收短信的手机号码。
 	response, err := s.client.SendSms(request)     // 调用腾讯云SMS客户端发送短信。
-	zap.L().Debug("请求腾讯SendSms接口",
-		zap.Any("req", request),
-		zap.Any("resp", response))
+	logger.FromContext(ctx).Debug("请求腾讯SendSms接口",
+		logger.Field{Key: "req", Val: request},
+		logger.Field{Key: "resp", Val: response})
 	// 处理异常。
 	if err != nil {
 		return err // 如果有错误，直接返回错误。
@@ -55,6 +63,19 @@ func (s *Service) Send(ctx context.Context, tplId string, args []string, numbers
 	return nil // 所有短信都成功发送。
 }
 
+// formatNumbers 方法，将号码转换为 E.164 格式。
+func (s *Service) formatNumbers(numbers []string) ([]string, error) {
+	res := make([]string, 0, len(numbers))
+	for _, n := range numbers {
+		phone, err := s.phoneParser.Normalize(n)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, n)
+		}
+		res = append(res, phone)
+	}
+	return res, nil
+}
+
 // toPtrSlice 方法，将字符串切片转换为字符串指针切片。
 func (s *Service) toPtrSlice(data []string) []*string {
 	return slice.Map[string, *string](data,
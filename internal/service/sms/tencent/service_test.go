@@ -8,6 +8,7 @@ import (
 	sms "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/sms/v20210111"
 	"os"
 	"testing"
+	"webBook/pkg/phonex"
 )
 
 // 这个需要手动跑，也就是你需要在本地搞好这些环境变量
@@ -25,7 +26,7 @@ func TestSender(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	s := NewService(c, "1400842696", "妙影科技")
+	s := NewService(c, "1400842696", "妙影科技", phonex.NewParser("CN"))
 
 	testCases := []struct {
 		name    string
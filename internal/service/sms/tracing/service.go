@@ -0,0 +1,41 @@
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"webBook/internal/service/sms"
+)
+
+var _ sms.Service = &Service{}
+
+// Service 给发送短信加上 span，号码是敏感信息，只记录数量
+type Service struct {
+	svc    sms.Service
+	tracer trace.Tracer
+}
+
+func NewService(svc sms.Service) *Service {
+	return &Service{
+		svc:    svc,
+		tracer: otel.Tracer("webBook/internal/service/sms"),
+	}
+}
+
+func (s *Service) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	ctx, span := s.tracer.Start(ctx, "sms/Send",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("tpl_id", tplId),
+			attribute.Int("numbers", len(numbers)),
+		))
+	defer span.End()
+	err := s.svc.Send(ctx, tplId, args, numbers...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,43 @@
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"webBook/internal/service"
+)
+
+var _ service.CodeService = &CodeService{}
+
+// CodeService 手机号码是敏感信息，span 上只记录业务
+type CodeService struct {
+	svc    service.CodeService
+	tracer trace.Tracer
+}
+
+func NewCodeService(svc service.CodeService) service.CodeService {
+	return &CodeService{
+		svc:    svc,
+		tracer: otel.Tracer(instrumentationName),
+	}
+}
+
+func (s *CodeService) Send(ctx context.Context, biz, phone, ip string) error {
+	ctx, span := s.tracer.Start(ctx, "CodeService/Send",
+		trace.WithAttributes(attribute.String("biz", biz)))
+	defer span.End()
+	err := s.svc.Send(ctx, biz, phone, ip)
+	record(span, err)
+	return err
+}
+
+func (s *CodeService) Verify(ctx context.Context, biz, phone, inputCode string) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "CodeService/Verify",
+		trace.WithAttributes(attribute.String("biz", biz)))
+	defer span.End()
+	ok, err := s.svc.Verify(ctx, biz, phone, inputCode)
+	span.SetAttributes(attribute.Bool("ok", ok))
+	record(span, err)
+	return ok, err
+}
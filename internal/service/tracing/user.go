@@ -0,0 +1,103 @@
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"webBook/internal/domain"
+	"webBook/internal/service"
+)
+
+const instrumentationName = "webBook/internal/service"
+
+var _ service.UserService = &UserService{}
+
+// UserService 给每个方法加上 span 的装饰器
+type UserService struct {
+	svc    service.UserService
+	tracer trace.Tracer
+}
+
+func NewUserService(svc service.UserService) service.UserService {
+	return &UserService{
+		svc:    svc,
+		tracer: otel.Tracer(instrumentationName),
+	}
+}
+
+func (s *UserService) Signup(ctx context.Context, u domain.User) error {
+	ctx, span := s.tracer.Start(ctx, "UserService/Signup")
+	defer span.End()
+	err := s.svc.Signup(ctx, u)
+	record(span, err)
+	return err
+}
+
+func (s *UserService) Login(ctx context.Context, email string, password string) (domain.User, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService/Login")
+	defer span.End()
+	u, err := s.svc.Login(ctx, email, password)
+	record(span, err)
+	return u, err
+}
+
+func (s *UserService) UpdateNonSensitiveInfo(ctx context.Context, user domain.User) error {
+	ctx, span := s.tracer.Start(ctx, "UserService/UpdateNonSensitiveInfo",
+		trace.WithAttributes(attribute.Int64("uid", user.Id)))
+	defer span.End()
+	err := s.svc.UpdateNonSensitiveInfo(ctx, user)
+	record(span, err)
+	return err
+}
+
+func (s *UserService) FindById(ctx context.Context, uid int64) (domain.User, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService/FindById",
+		trace.WithAttributes(attribute.Int64("uid", uid)))
+	defer span.End()
+	u, err := s.svc.FindById(ctx, uid)
+	record(span, err)
+	return u, err
+}
+
+func (s *UserService) FindOrCreate(ctx context.Context, phone string) (domain.User, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService/FindOrCreate")
+	defer span.End()
+	u, err := s.svc.FindOrCreate(ctx, phone)
+	record(span, err)
+	return u, err
+}
+
+func (s *UserService) FindOrCreateByWechat(ctx context.Context, info domain.WechatInfo) (domain.User, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService/FindOrCreateByWechat")
+	defer span.End()
+	u, err := s.svc.FindOrCreateByWechat(ctx, info)
+	record(span, err)
+	return u, err
+}
+
+func (s *UserService) FindOrCreateByPhoneAndWechat(ctx context.Context, phone string,
+	info domain.WechatInfo) (domain.User, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService/FindOrCreateByPhoneAndWechat")
+	defer span.End()
+	u, err := s.svc.FindOrCreateByPhoneAndWechat(ctx, phone, info)
+	record(span, err)
+	return u, err
+}
+
+func (s *UserService) FindOrCreateByIdentity(ctx context.Context, identity domain.UserIdentity) (domain.User, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService/FindOrCreateByIdentity",
+		trace.WithAttributes(attribute.String("provider", identity.Provider)))
+	defer span.End()
+	u, err := s.svc.FindOrCreateByIdentity(ctx, identity)
+	record(span, err)
+	return u, err
+}
+
+func record(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
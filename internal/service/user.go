@@ -3,10 +3,10 @@ package service
 import (
 	"context"
 	"errors"
-	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 	"webBook/internal/domain"
 	"webBook/internal/repository"
+	"webBook/pkg/logger"
 )
 
 var (
@@ -23,17 +23,23 @@ type UserService interface {
 		uid int64) (domain.User, error)
 	FindOrCreate(ctx context.Context, phone string) (domain.User, error)
 	FindOrCreateByWechat(ctx context.Context, info domain.WechatInfo) (domain.User, error)
+	// FindOrCreateByPhoneAndWechat 小程序手机号登录，先按照微信身份找，找不到再按照手机号码找，
+	// 并且把微信身份绑定到手机号码的账号上，下次微信登录就是同一个账号
+	FindOrCreateByPhoneAndWechat(ctx context.Context, phone string, info domain.WechatInfo) (domain.User, error)
+	// FindOrCreateByIdentity 通过第三方身份查找用户，没有绑定过的身份会创建新用户
+	FindOrCreateByIdentity(ctx context.Context, identity domain.UserIdentity) (domain.User, error)
 }
 
 type userService struct {
-	repo   repository.UserRepository // repo字段，指向UserRepository结构体实例，用于仓库层操作。
-	logger *zap.Logger
+	repo         repository.UserRepository // repo字段，指向UserRepository结构体实例，用于仓库层操作。
+	identityRepo repository.UserIdentityRepository
 }
 
-func NewUserService(repo repository.UserRepository) UserService {
+func NewUserService(repo repository.UserRepository,
+	identityRepo repository.UserIdentityRepository) UserService {
 	return &userService{
-		repo:   repo,
-		logger: zap.L(),
+		repo:         repo,
+		identityRepo: identityRepo,
 	}
 }
 
@@ -86,17 +92,114 @@ func (svc *userService) FindOrCreate(ctx context.Context, phone string) (domain.
 	return svc.repo.FindByPhone(ctx, phone) // 再次尝试从仓库层通过电话查找用户。
 }
 
+// FindOrCreateByWechat 方法，网站应用和小程序共用，有 UnionId 的时候靠 UnionId 打通两边的账号。
 func (svc *userService) FindOrCreateByWechat(ctx context.Context, wechatInfo domain.WechatInfo) (domain.User, error) {
-	u, err := svc.repo.FindByWechat(ctx, wechatInfo.OpenId)
+	u, err := svc.findByWechat(ctx, wechatInfo)
+	if err == nil {
+		svc.syncWechat(ctx, u, wechatInfo)
+		return u, nil
+	}
 	if !errors.Is(err, repository.ErrUserNotFound) {
 		return u, err
 	}
-	svc.logger.Info("新用户", zap.Any("wechatInfo", wechatInfo))
+	logger.FromContext(ctx).Info("新用户",
+		logger.Field{Key: "openId", Val: wechatInfo.OpenId},
+		logger.Field{Key: "miniOpenId", Val: wechatInfo.MiniOpenId},
+		logger.Field{Key: "unionId", Val: wechatInfo.UnionId})
 	err = svc.repo.Create(ctx, domain.User{
+		Nickname:   wechatInfo.Nickname,
+		Avatar:     wechatInfo.Avatar,
 		WechatInfo: wechatInfo,
 	})
 	if err != nil && err != repository.ErrDuplicateUser {
 		return domain.User{}, err
 	}
-	return svc.repo.FindByWechat(ctx, wechatInfo.OpenId)
+	return svc.findByWechat(ctx, wechatInfo)
+}
+
+func (svc *userService) findByWechat(ctx context.Context, info domain.WechatInfo) (domain.User, error) {
+	if info.UnionId != "" {
+		u, err := svc.repo.FindByWechatUnion(ctx, info.UnionId)
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			return u, err
+		}
+		// 老数据可能没有 UnionId，继续按照 openid 找
+	}
+	if info.MiniOpenId != "" {
+		return svc.repo.FindByWechatMini(ctx, info.MiniOpenId)
+	}
+	return svc.repo.FindByWechat(ctx, info.OpenId)
+}
+
+func (svc *userService) FindOrCreateByPhoneAndWechat(ctx context.Context, phone string,
+	info domain.WechatInfo) (domain.User, error) {
+	u, err := svc.findByWechat(ctx, info)
+	if err == nil {
+		// 这个微信已经有账号了，不管是网站应用还是小程序创建的，都用这个账号
+		svc.syncWechat(ctx, u, info)
+		return u, nil
+	}
+	if !errors.Is(err, repository.ErrUserNotFound) {
+		return domain.User{}, err
+	}
+	u, err = svc.FindOrCreate(ctx, phone)
+	if err != nil {
+		return domain.User{}, err
+	}
+	svc.syncWechat(ctx, u, info)
+	return u, nil
+}
+
+// syncWechat 老用户登录，补上另外一个应用的 openid 和老数据没有的 UnionId，更新 refresh token，
+// 失败了也不影响登录。已经绑定过的不会覆盖
+func (svc *userService) syncWechat(ctx context.Context, u domain.User, info domain.WechatInfo) {
+	var bind domain.WechatInfo
+	if info.OpenId != "" && u.WechatInfo.OpenId == "" {
+		bind.OpenId = info.OpenId
+	}
+	if info.MiniOpenId != "" && u.WechatInfo.MiniOpenId == "" {
+		bind.MiniOpenId = info.MiniOpenId
+	}
+	if info.UnionId != "" && u.WechatInfo.UnionId == "" {
+		bind.UnionId = info.UnionId
+	}
+	if bind != (domain.WechatInfo{}) {
+		err := svc.repo.BindWechat(ctx, u.Id, bind)
+		if err != nil {
+			logger.FromContext(ctx).Error("绑定微信账号失败",
+				logger.Field{Key: "uid", Val: u.Id}, logger.Field{Key: "err", Val: err})
+		}
+	}
+	if info.RefreshToken != "" {
+		err := svc.repo.UpdateWechatRefreshToken(ctx, u.Id, info.RefreshToken)
+		if err != nil {
+			logger.FromContext(ctx).Error("更新微信 refresh token 失败",
+				logger.Field{Key: "uid", Val: u.Id}, logger.Field{Key: "err", Val: err})
+		}
+	}
+}
+
+func (svc *userService) FindOrCreateByIdentity(ctx context.Context, identity domain.UserIdentity) (domain.User, error) {
+	uid, err := svc.identityRepo.FindUid(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		return svc.repo.FindById(ctx, uid)
+	}
+	if !errors.Is(err, repository.ErrUserNotFound) {
+		return domain.User{}, err
+	}
+	logger.FromContext(ctx).Info("新用户",
+		logger.Field{Key: "provider", Val: identity.Provider},
+		logger.Field{Key: "subject", Val: identity.Subject})
+	// 第三方给的邮箱不一定验证过，所以不写到 users 上，避免被用来抢占别人的账号
+	uid, err = svc.identityRepo.CreateUser(ctx, domain.User{
+		Nickname: identity.Name,
+	}, identity)
+	if errors.Is(err, repository.ErrDuplicateIdentity) {
+		// 并发登录，别人已经创建好了
+		uid, err = svc.identityRepo.FindUid(ctx, identity.Provider, identity.Subject)
+	}
+	if err != nil {
+		return domain.User{}, err
+	}
+	return svc.repo.FindById(ctx, uid)
 }
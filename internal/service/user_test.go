@@ -118,10 +118,234 @@ func Test_userService_Login(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 			repo := tc.mock(ctrl)
-			svc := NewUserService(repo)
+			svc := NewUserService(repo, nil)
 			user, err := svc.Login(tc.ctx, tc.email, tc.password)
 			assert.Equal(t, tc.wantErr, err)
 			assert.Equal(t, tc.wantUser, user)
 		})
 	}
 }
+
+func Test_userService_FindOrCreateByIdentity(t *testing.T) {
+	identity := domain.UserIdentity{
+		Provider: "github",
+		Subject:  "123",
+		Email:    "123@qq.com",
+		Name:     "Tom",
+	}
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (repository.UserRepository, repository.UserIdentityRepository)
+
+		wantUser domain.User
+		wantErr  error
+	}{
+		{
+			name: "已经绑定过",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.UserIdentityRepository) {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				identityRepo := repomocks.NewMockUserIdentityRepository(ctrl)
+				identityRepo.EXPECT().FindUid(gomock.Any(), "github", "123").Return(int64(1), nil)
+				repo.EXPECT().FindById(gomock.Any(), int64(1)).Return(domain.User{Id: 1}, nil)
+				return repo, identityRepo
+			},
+			wantUser: domain.User{Id: 1},
+		},
+		{
+			name: "新用户",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.UserIdentityRepository) {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				identityRepo := repomocks.NewMockUserIdentityRepository(ctrl)
+				identityRepo.EXPECT().FindUid(gomock.Any(), "github", "123").
+					Return(int64(0), repository.ErrUserNotFound)
+				// 邮箱不写到用户上
+				identityRepo.EXPECT().CreateUser(gomock.Any(), domain.User{Nickname: "Tom"}, identity).
+					Return(int64(2), nil)
+				repo.EXPECT().FindById(gomock.Any(), int64(2)).Return(domain.User{Id: 2, Nickname: "Tom"}, nil)
+				return repo, identityRepo
+			},
+			wantUser: domain.User{Id: 2, Nickname: "Tom"},
+		},
+		{
+			name: "并发创建",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.UserIdentityRepository) {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				identityRepo := repomocks.NewMockUserIdentityRepository(ctrl)
+				identityRepo.EXPECT().FindUid(gomock.Any(), "github", "123").
+					Return(int64(0), repository.ErrUserNotFound)
+				identityRepo.EXPECT().CreateUser(gomock.Any(), gomock.Any(), identity).
+					Return(int64(0), repository.ErrDuplicateIdentity)
+				identityRepo.EXPECT().FindUid(gomock.Any(), "github", "123").Return(int64(3), nil)
+				repo.EXPECT().FindById(gomock.Any(), int64(3)).Return(domain.User{Id: 3}, nil)
+				return repo, identityRepo
+			},
+			wantUser: domain.User{Id: 3},
+		},
+		{
+			name: "查询出错",
+			mock: func(ctrl *gomock.Controller) (repository.UserRepository, repository.UserIdentityRepository) {
+				identityRepo := repomocks.NewMockUserIdentityRepository(ctrl)
+				identityRepo.EXPECT().FindUid(gomock.Any(), "github", "123").
+					Return(int64(0), errors.New("db错误"))
+				return repomocks.NewMockUserRepository(ctrl), identityRepo
+			},
+			wantErr: errors.New("db错误"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repo, identityRepo := tc.mock(ctrl)
+			svc := NewUserService(repo, identityRepo)
+			user, err := svc.FindOrCreateByIdentity(context.Background(), identity)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantUser, user)
+		})
+	}
+}
+
+func Test_userService_FindOrCreateByWechat(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.UserRepository
+		info domain.WechatInfo
+
+		wantUser domain.User
+		wantErr  error
+	}{
+		{
+			name: "小程序登录，按照 UnionId 找到网站应用的用户",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				u := domain.User{Id: 1, WechatInfo: domain.WechatInfo{OpenId: "web", UnionId: "union"}}
+				repo.EXPECT().FindByWechatUnion(gomock.Any(), "union").Return(u, nil)
+				// 补上小程序的 openid，已经有的不更新
+				repo.EXPECT().BindWechat(gomock.Any(), int64(1),
+					domain.WechatInfo{MiniOpenId: "mini"}).Return(nil)
+				return repo
+			},
+			info:     domain.WechatInfo{MiniOpenId: "mini", UnionId: "union"},
+			wantUser: domain.User{Id: 1, WechatInfo: domain.WechatInfo{OpenId: "web", UnionId: "union"}},
+		},
+		{
+			name: "没有 UnionId 的老用户，按照 openid 找，补上 UnionId，更新 refresh token",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				u := domain.User{Id: 2, WechatInfo: domain.WechatInfo{OpenId: "web"}}
+				repo.EXPECT().FindByWechatUnion(gomock.Any(), "union").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().FindByWechat(gomock.Any(), "web").Return(u, nil)
+				repo.EXPECT().BindWechat(gomock.Any(), int64(2),
+					domain.WechatInfo{UnionId: "union"}).Return(nil)
+				repo.EXPECT().UpdateWechatRefreshToken(gomock.Any(), int64(2), "refresh").Return(nil)
+				return repo
+			},
+			info:     domain.WechatInfo{OpenId: "web", UnionId: "union", RefreshToken: "refresh"},
+			wantUser: domain.User{Id: 2, WechatInfo: domain.WechatInfo{OpenId: "web"}},
+		},
+		{
+			name: "新用户，带上昵称和头像",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				info := domain.WechatInfo{MiniOpenId: "mini", Nickname: "Tom", Avatar: "tom.png"}
+				repo.EXPECT().FindByWechatMini(gomock.Any(), "mini").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().Create(gomock.Any(), domain.User{
+					Nickname:   "Tom",
+					Avatar:     "tom.png",
+					WechatInfo: info,
+				}).Return(nil)
+				repo.EXPECT().FindByWechatMini(gomock.Any(), "mini").Return(domain.User{Id: 3}, nil)
+				return repo
+			},
+			info:     domain.WechatInfo{MiniOpenId: "mini", Nickname: "Tom", Avatar: "tom.png"},
+			wantUser: domain.User{Id: 3},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewUserService(tc.mock(ctrl), nil)
+			user, err := svc.FindOrCreateByWechat(context.Background(), tc.info)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantUser, user)
+		})
+	}
+}
+
+func Test_userService_FindOrCreateByPhoneAndWechat(t *testing.T) {
+	info := domain.WechatInfo{MiniOpenId: "mini", UnionId: "union"}
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.UserRepository
+
+		wantUser domain.User
+		wantErr  error
+	}{
+		{
+			name: "微信已经有账号了，直接用",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				u := domain.User{Id: 1, WechatInfo: domain.WechatInfo{OpenId: "web", UnionId: "union"}}
+				repo.EXPECT().FindByWechatUnion(gomock.Any(), "union").Return(u, nil)
+				repo.EXPECT().BindWechat(gomock.Any(), int64(1),
+					domain.WechatInfo{MiniOpenId: "mini"}).Return(nil)
+				return repo
+			},
+			wantUser: domain.User{Id: 1, WechatInfo: domain.WechatInfo{OpenId: "web", UnionId: "union"}},
+		},
+		{
+			name: "微信没有账号，绑定到手机号码的账号上",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByWechatUnion(gomock.Any(), "union").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().FindByWechatMini(gomock.Any(), "mini").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().FindByPhone(gomock.Any(), "+8615212345678").
+					Return(domain.User{Id: 2, Phone: "+8615212345678"}, nil)
+				repo.EXPECT().BindWechat(gomock.Any(), int64(2), info).Return(nil)
+				return repo
+			},
+			wantUser: domain.User{Id: 2, Phone: "+8615212345678"},
+		},
+		{
+			name: "绑定冲突不影响登录",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByWechatUnion(gomock.Any(), "union").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().FindByWechatMini(gomock.Any(), "mini").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				repo.EXPECT().FindByPhone(gomock.Any(), "+8615212345678").
+					Return(domain.User{Id: 2, Phone: "+8615212345678"}, nil)
+				repo.EXPECT().BindWechat(gomock.Any(), int64(2), info).
+					Return(repository.ErrWechatConflict)
+				return repo
+			},
+			wantUser: domain.User{Id: 2, Phone: "+8615212345678"},
+		},
+		{
+			name: "查找微信账号出错",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				repo := repomocks.NewMockUserRepository(ctrl)
+				repo.EXPECT().FindByWechatUnion(gomock.Any(), "union").
+					Return(domain.User{}, errors.New("db错误"))
+				return repo
+			},
+			wantErr: errors.New("db错误"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewUserService(tc.mock(ctrl), nil)
+			user, err := svc.FindOrCreateByPhoneAndWechat(context.Background(), "+8615212345678", info)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantUser, user)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"webBook/internal/service/captcha"
+	"webBook/pkg/ginx/openapi"
+)
+
+type CaptchaHandler struct {
+	svc captcha.Service
+}
+
+func NewCaptchaHandler(svc captcha.Service) *CaptchaHandler {
+	return &CaptchaHandler{
+		svc: svc,
+	}
+}
+
+func (h *CaptchaHandler) RegisterRoutes(server *gin.Engine) {
+	server.POST("/captcha", h.Generate)
+}
+
+func (h *CaptchaHandler) Docs() []openapi.Route {
+	return []openapi.Route{
+		{Method: http.MethodPost, Path: "/captcha", Tag: "captcha", Summary: "生成图形验证码", Resp: CaptchaVO{}},
+	}
+}
+
+// CaptchaVO Image 是 base64 编码的图片
+type CaptchaVO struct {
+	Id    string `json:"id"`
+	Image string `json:"image"`
+}
+
+// Generate 生成一个图形验证码挑战，前端展示图片，提交的时候带上 captchaId 和用户的答案
+func (h *CaptchaHandler) Generate(ctx *gin.Context) {
+	c, err := h.svc.Generate(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	writeOK(ctx, "", CaptchaVO{
+		Id:    c.Id,
+		Image: c.Image,
+	})
+}
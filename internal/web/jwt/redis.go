@@ -9,19 +9,23 @@ import (
 	"github.com/redis/go-redis/v9"
 	"strings"
 	"time"
+	"webBook/pkg/ginx"
 )
 
 type RedisJWTHandler struct {
 	client        redis.Cmdable
 	signingMethod jwt.SigningMethod
 	rcExpiration  time.Duration
+	// oauth2Key 给第三方应用签发 access token 的 key
+	oauth2Key []byte
 }
 
-func NewRedisJWTHandler(client redis.Cmdable) Handler {
+func NewRedisJWTHandler(client redis.Cmdable, oauth2Key []byte) Handler {
 	return &RedisJWTHandler{
 		client:        client,
 		signingMethod: jwt.SigningMethodHS512,
 		rcExpiration:  time.Hour * 24 * 7,
+		oauth2Key:     oauth2Key,
 	}
 }
 
@@ -64,7 +68,7 @@ func (h *RedisJWTHandler) SetLoginToken(ctx *gin.Context, uid int64) error {
 func (h *RedisJWTHandler) ClearToken(ctx *gin.Context) error {
 	ctx.Header("x-jwt-token", "")
 	ctx.Header("x-refresh-token", "")
-	uc := ctx.MustGet("user").(UserClaims)
+	uc := ctx.MustGet(ginx.ClaimsKey).(UserClaims)
 
 	return h.client.Set(ctx,
 		fmt.Sprintf("users:ssid:%s", uc.Ssid),
@@ -107,6 +111,24 @@ func (h *RedisJWTHandler) setRefreshToken(ctx *gin.Context, uid int64, ssid stri
 	return nil
 }
 
+func (h *RedisJWTHandler) SignOAuth2Token(claims OAuth2Claims) (string, error) {
+	return jwt.NewWithClaims(h.signingMethod, claims).SignedString(h.oauth2Key)
+}
+
+func (h *RedisJWTHandler) ParseOAuth2Token(tokenStr string) (OAuth2Claims, error) {
+	var claims OAuth2Claims
+	token, err := jwt.ParseWithClaims(tokenStr, &claims, func(token *jwt.Token) (interface{}, error) {
+		return h.oauth2Key, nil
+	}, jwt.WithValidMethods([]string{h.signingMethod.Alg()}))
+	if err != nil {
+		return OAuth2Claims{}, err
+	}
+	if token == nil || !token.Valid {
+		return OAuth2Claims{}, errors.New("token 无效")
+	}
+	return claims, nil
+}
+
 var JWTKey = []byte("k6CswdUm77WKcbM68UQUuxVsHSpTCwgK")
 var RCJWTKey = []byte("k6CswdUm77WKcbM68UQUuxVsHSpTCwgA")
 
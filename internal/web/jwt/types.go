@@ -1,6 +1,9 @@
 package jwt
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
 
 type Handler interface {
 	ClearToken(ctx *gin.Context) error
@@ -8,4 +11,17 @@ type Handler interface {
 	SetLoginToken(ctx *gin.Context, uid int64) error
 	SetJWTToken(ctx *gin.Context, uid int64, ssid string) error
 	CheckSession(ctx *gin.Context, ssid string) error
+	// SignOAuth2Token 签发给第三方应用的 access token，签名算法和登录 token 一样，但是 key 不一样，
+	// 避免 access token 被当成登录 token 用
+	SignOAuth2Token(claims OAuth2Claims) (string, error)
+	// ParseOAuth2Token 校验签名和过期时间
+	ParseOAuth2Token(tokenStr string) (OAuth2Claims, error)
+}
+
+// OAuth2Claims 授权服务器签发的 access token
+type OAuth2Claims struct {
+	jwt.RegisteredClaims
+	ClientId string `json:"client_id"`
+	// Scope 空格分隔
+	Scope string `json:"scope"`
 }
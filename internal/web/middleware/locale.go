@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"webBook/internal/i18n"
+	ijwt "webBook/internal/web/jwt"
+	"webBook/pkg/ginx"
+	"webBook/pkg/logger"
+)
+
+// LocaleMiddlewareBuilder 决定响应用什么语言，放到 Request 的 ctx 里面，
+// 要放在登录校验后面，才能拿到用户在个人资料里面设置的语言
+type LocaleMiddlewareBuilder struct {
+	// preference 查询用户设置的语言，nil 就是只看 Accept-Language
+	preference func(ctx context.Context, uid int64) (string, error)
+}
+
+func NewLocaleMiddlewareBuilder(preference func(ctx context.Context, uid int64) (string, error)) *LocaleMiddlewareBuilder {
+	return &LocaleMiddlewareBuilder{
+		preference: preference,
+	}
+}
+
+func (b *LocaleMiddlewareBuilder) Build() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var pref string
+		if uc, ok := ctx.Get(ginx.ClaimsKey); ok && b.preference != nil {
+			if claims, ok := uc.(ijwt.UserClaims); ok {
+				var err error
+				pref, err = b.preference(ctx, claims.Uid)
+				if err != nil {
+					// 查不到就按照 Accept-Language 来，不影响业务
+					logger.FromContext(ctx).Warn("查询用户设置的语言失败",
+						logger.Field{Key: "uid", Val: claims.Uid},
+						logger.Field{Key: "err", Val: err})
+				}
+			}
+		}
+		tag := i18n.Negotiate(pref, ctx.GetHeader("Accept-Language"))
+		ctx.Request = ctx.Request.WithContext(i18n.WithLocale(ctx.Request.Context(), tag))
+		ctx.Header("Content-Language", tag.String())
+	}
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"webBook/internal/i18n"
+	ijwt "webBook/internal/web/jwt"
+	"webBook/pkg/ginx"
+)
+
+func TestLocaleMiddlewareBuilder_Build(t *testing.T) {
+	testCases := []struct {
+		name           string
+		preference     func(ctx context.Context, uid int64) (string, error)
+		login          bool
+		acceptLanguage string
+
+		want string
+	}{
+		{
+			name:           "没有登录",
+			acceptLanguage: "en-US,en;q=0.9",
+			want:           "en-US",
+		},
+		{
+			name: "用户设置的优先",
+			preference: func(ctx context.Context, uid int64) (string, error) {
+				return "en-US", nil
+			},
+			login:          true,
+			acceptLanguage: "zh-CN",
+			want:           "en-US",
+		},
+		{
+			name: "用户没有设置",
+			preference: func(ctx context.Context, uid int64) (string, error) {
+				return "", nil
+			},
+			login:          true,
+			acceptLanguage: "en",
+			want:           "en-US",
+		},
+		{
+			name: "查询失败",
+			preference: func(ctx context.Context, uid int64) (string, error) {
+				return "", errors.New("mock error")
+			},
+			login:          true,
+			acceptLanguage: "en",
+			want:           "en-US",
+		},
+		{
+			name: "都没有就用默认的",
+			want: "zh-CN",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := gin.New()
+			server.Use(func(ctx *gin.Context) {
+				if tc.login {
+					ctx.Set(ginx.ClaimsKey, ijwt.UserClaims{Uid: 123})
+				}
+			}, NewLocaleMiddlewareBuilder(tc.preference).Build())
+			var got string
+			server.GET("/test", func(ctx *gin.Context) {
+				got = i18n.FromContext(ctx.Request.Context()).String()
+			})
+
+			req, err := http.NewRequest(http.MethodGet, "/test", nil)
+			require.NoError(t, err)
+			req.Header.Set("Accept-Language", tc.acceptLanguage)
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.want, recorder.Header().Get("Content-Language"))
+		})
+	}
+}
@@ -5,18 +5,43 @@ import (
 	"context"
 	"github.com/gin-gonic/gin"
 	"io"
+	"math/rand/v2"
 	"time"
+	ijwt "webBook/internal/web/jwt"
+	"webBook/pkg/ginx"
+	"webBook/pkg/ginx/middleware/requestid"
 )
 
 type LogMiddlewareBuilder struct {
 	logFn         func(ctx context.Context, l AccessLog)
 	allowReqBody  bool
 	allowRespBody bool
+	// maxBodySize 请求体和响应体最多记录多少字节
+	maxBodySize  int
+	reqRedactor  *redactor
+	respRedactor *redactor
+	sampling     []SamplingRule
+}
+
+// SamplingRule 采样规则，按照顺序匹配，第一条匹配上的决定是否记录，
+// 一条都没有匹配上的就记录。例如：
+//
+//	{MinStatus: 400, Rate: 1}, {Rate: 0.01}
+//
+// 就是出错的全部记录，成功的只记录 1%
+type SamplingRule struct {
+	// Path gin 注册的路由，为空就是所有路由
+	Path string `yaml:"path"`
+	// MinStatus 响应码大于等于它才匹配，0 就是都匹配
+	MinStatus int `yaml:"minStatus"`
+	// Rate 记录的比例，0 到 1
+	Rate float64 `yaml:"rate"`
 }
 
 func NewLogMiddlewareBuilder(logFn func(ctx context.Context, l AccessLog)) *LogMiddlewareBuilder {
 	return &LogMiddlewareBuilder{
-		logFn: logFn,
+		logFn:       logFn,
+		maxBodySize: 2048,
 	}
 }
 
@@ -30,6 +55,30 @@ func (l *LogMiddlewareBuilder) AllowRespBody() *LogMiddlewareBuilder {
 	return l
 }
 
+// MaxBodySize 超过的部分不记录，响应体也只缓存这么多
+func (l *LogMiddlewareBuilder) MaxBodySize(size int) *LogMiddlewareBuilder {
+	l.maxBodySize = size
+	return l
+}
+
+// RedactReq 把请求体里面的敏感字段替换成 ***。
+// path 是用 . 分隔的 JSON 路径，例如 password、user.phone，* 匹配任意字段或者数组元素
+func (l *LogMiddlewareBuilder) RedactReq(paths ...string) *LogMiddlewareBuilder {
+	l.reqRedactor = newRedactor(paths)
+	return l
+}
+
+// RedactResp 同 RedactReq，响应体和请求体的字段含义不一样，例如响应里面的 code 是错误码
+func (l *LogMiddlewareBuilder) RedactResp(paths ...string) *LogMiddlewareBuilder {
+	l.respRedactor = newRedactor(paths)
+	return l
+}
+
+func (l *LogMiddlewareBuilder) Sampling(rules ...SamplingRule) *LogMiddlewareBuilder {
+	l.sampling = rules
+	return l
+}
+
 func (l *LogMiddlewareBuilder) Build() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		path := ctx.Request.URL.Path
@@ -38,29 +87,43 @@ func (l *LogMiddlewareBuilder) Build() gin.HandlerFunc {
 		}
 		method := ctx.Request.Method
 		al := AccessLog{
-			Path:   path,
-			Method: method,
+			Path:      path,
+			Method:    method,
+			ClientIP:  ctx.ClientIP(),
+			RequestID: requestid.FromContext(ctx),
 		}
-		if l.allowReqBody {
+		if l.allowReqBody && ctx.Request.Body != nil {
 			body, _ := ctx.GetRawData()
-			if len(body) > 2048 {
-				al.ReqBody = string(body[:2048])
-			} else {
-				al.ReqBody = string(body)
-			}
 			ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+			// 要先脱敏再截断，截断之后就不是合法的 JSON 了
+			al.ReqBody = l.truncate(l.reqRedactor.redact(ctx.ContentType(), body))
 		}
 
 		start := time.Now()
+		var w *responseWriter
 		if l.allowRespBody {
-			ctx.Writer = &responseWriter{
+			w = &responseWriter{
 				ResponseWriter: ctx.Writer,
-				al:             &al,
+				limit:          l.maxBodySize,
 			}
+			ctx.Writer = w
 		}
 
 		defer func() {
 			al.Duration = time.Since(start)
+			// gin 的 ResponseWriter 自己记录了响应码，没有调用 WriteHeader 的时候是 200
+			al.Status = ctx.Writer.Status()
+			if uc, ok := ctx.Get(ginx.ClaimsKey); ok {
+				if claims, ok := uc.(ijwt.UserClaims); ok {
+					al.Uid = claims.Uid
+				}
+			}
+			if !l.sample(ctx, al.Status) {
+				return
+			}
+			if w != nil {
+				al.RespBody = l.respBody(ctx, w)
+			}
 			l.logFn(ctx, al)
 		}()
 
@@ -68,26 +131,73 @@ func (l *LogMiddlewareBuilder) Build() gin.HandlerFunc {
 	}
 }
 
+func (l *LogMiddlewareBuilder) sample(ctx *gin.Context, status int) bool {
+	for _, r := range l.sampling {
+		if r.Path != "" && r.Path != ctx.FullPath() {
+			continue
+		}
+		if status < r.MinStatus {
+			continue
+		}
+		return r.Rate >= 1 || rand.Float64() < r.Rate
+	}
+	return true
+}
+
+func (l *LogMiddlewareBuilder) respBody(ctx *gin.Context, w *responseWriter) string {
+	body := w.buf.Bytes()
+	if !w.truncated {
+		return l.truncate(l.respRedactor.redact(ctx.Writer.Header().Get("Content-Type"), body))
+	}
+	// 响应体只缓存了前面一部分，没办法解析，也就没办法脱敏
+	if l.respRedactor != nil {
+		return "[truncated]"
+	}
+	return string(body)
+}
+
+func (l *LogMiddlewareBuilder) truncate(body []byte) string {
+	if len(body) > l.maxBodySize {
+		return string(body[:l.maxBodySize])
+	}
+	return string(body)
+}
+
 type AccessLog struct {
-	Path     string        `json:"path"`
-	Method   string        `json:"method"`
-	ReqBody  string        `json:"req_body"`
-	RespBody string        `json:"resp_body"`
-	Duration time.Duration `json:"duration"`
-	Status   int           `json:"status"`
+	Path      string        `json:"path"`
+	Method    string        `json:"method"`
+	ClientIP  string        `json:"client_ip"`
+	Uid       int64         `json:"uid,omitempty"`
+	RequestID string        `json:"request_id,omitempty"`
+	ReqBody   string        `json:"req_body"`
+	RespBody  string        `json:"resp_body"`
+	Duration  time.Duration `json:"duration"`
+	Status    int           `json:"status"`
 }
 
+// responseWriter 最多缓存 limit 字节的响应体，避免大响应占用太多内存
 type responseWriter struct {
 	gin.ResponseWriter
-	al *AccessLog
+	limit     int
+	buf       bytes.Buffer
+	truncated bool
 }
 
 func (w *responseWriter) Write(data []byte) (int, error) {
-	w.al.RespBody = string(data)
+	w.capture(data)
 	return w.ResponseWriter.Write(data)
 }
 
-func (w *responseWriter) WriteHeader(statusCode int) {
-	w.al.Status = statusCode
-	w.ResponseWriter.WriteHeader(statusCode)
+func (w *responseWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *responseWriter) capture(data []byte) {
+	remain := w.limit - w.buf.Len()
+	if len(data) > remain {
+		data = data[:max(remain, 0)]
+		w.truncated = true
+	}
+	w.buf.Write(data)
 }
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLogMiddlewareBuilder_Build(t *testing.T) {
+	testCases := []struct {
+		name    string
+		builder func(b *LogMiddlewareBuilder)
+		handler gin.HandlerFunc
+		body    string
+
+		wantLogged bool
+		wantLog    AccessLog
+	}{
+		{
+			name: "没有调用 WriteHeader 也能拿到响应码",
+			handler: func(ctx *gin.Context) {
+				ctx.String(http.StatusOK, "hello")
+			},
+			wantLogged: true,
+			wantLog:    AccessLog{Status: http.StatusOK, RespBody: "hello"},
+		},
+		{
+			name: "请求体脱敏",
+			builder: func(b *LogMiddlewareBuilder) {
+				b.RedactReq("password", "user.phone")
+			},
+			handler: func(ctx *gin.Context) {
+				ctx.JSON(http.StatusOK, gin.H{"code": 0})
+			},
+			body:       `{"email":"a@qq.com","password":"hello#world123","user":{"phone":"15212345678","id":12345678901234567}}`,
+			wantLogged: true,
+			wantLog: AccessLog{
+				Status:   http.StatusOK,
+				ReqBody:  `{"email":"a@qq.com","password":"***","user":{"id":12345678901234567,"phone":"***"}}`,
+				RespBody: `{"code":0}`,
+			},
+		},
+		{
+			name: "响应体只缓存一部分",
+			builder: func(b *LogMiddlewareBuilder) {
+				b.MaxBodySize(4)
+			},
+			handler: func(ctx *gin.Context) {
+				_, _ = ctx.Writer.WriteString("hello")
+				_, _ = ctx.Writer.WriteString("world")
+			},
+			wantLogged: true,
+			wantLog:    AccessLog{Status: http.StatusOK, RespBody: "hell"},
+		},
+		{
+			name: "响应体截断了没办法脱敏",
+			builder: func(b *LogMiddlewareBuilder) {
+				b.MaxBodySize(4).RedactResp("access_token")
+			},
+			handler: func(ctx *gin.Context) {
+				ctx.JSON(http.StatusOK, gin.H{"access_token": "abcdefg"})
+			},
+			wantLogged: true,
+			wantLog:    AccessLog{Status: http.StatusOK, RespBody: "[truncated]"},
+		},
+		{
+			name: "成功的不采样",
+			builder: func(b *LogMiddlewareBuilder) {
+				b.Sampling(SamplingRule{MinStatus: 400, Rate: 1}, SamplingRule{Rate: 0})
+			},
+			handler: func(ctx *gin.Context) {
+				ctx.String(http.StatusOK, "hello")
+			},
+		},
+		{
+			name: "出错的全部记录",
+			builder: func(b *LogMiddlewareBuilder) {
+				b.Sampling(SamplingRule{MinStatus: 400, Rate: 1}, SamplingRule{Rate: 0})
+			},
+			handler: func(ctx *gin.Context) {
+				ctx.AbortWithStatus(http.StatusUnauthorized)
+			},
+			wantLogged: true,
+			wantLog:    AccessLog{Status: http.StatusUnauthorized},
+		},
+	}
+	gin.SetMode(gin.ReleaseMode)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				logged bool
+				al     AccessLog
+			)
+			b := NewLogMiddlewareBuilder(func(ctx context.Context, l AccessLog) {
+				logged = true
+				al = l
+			}).AllowReqBody().AllowRespBody()
+			if tc.builder != nil {
+				tc.builder(b)
+			}
+			server := gin.New()
+			server.Use(b.Build())
+			server.POST("/users/login", tc.handler)
+
+			req := httptest.NewRequest(http.MethodPost, "/users/login", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.RemoteAddr = "10.0.0.1:1234"
+			server.ServeHTTP(httptest.NewRecorder(), req)
+
+			require.Equal(t, tc.wantLogged, logged)
+			if !logged {
+				return
+			}
+			assert.Equal(t, "/users/login", al.Path)
+			assert.Equal(t, "10.0.0.1", al.ClientIP)
+			assert.Equal(t, tc.wantLog.Status, al.Status)
+			assert.Equal(t, tc.wantLog.ReqBody, al.ReqBody)
+			assert.Equal(t, tc.wantLog.RespBody, al.RespBody)
+		})
+	}
+}
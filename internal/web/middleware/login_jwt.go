@@ -4,7 +4,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"net/http"
+	"strings"
 	ijwt "webBook/internal/web/jwt"
+	"webBook/pkg/ginx"
+	"webBook/pkg/logger"
 )
 
 type LoginJWTMiddlewareBuilder struct {
@@ -24,8 +27,17 @@ func (m *LoginJWTMiddlewareBuilder) CheckLogin() gin.HandlerFunc {
 			path == "/users/login" ||
 			path == "/users/login_sms/code/send" ||
 			path == "/users/login_sms" ||
-			path == "/oauth2/wechat/authurl" ||
-			path == "/oauth2/wechat/callback" {
+			isOAuth2Login(path) ||
+			path == "/oauth2/wechat/mini/session" ||
+			path == "/oauth2/wechat/mini/login" ||
+			// 这几个是给第三方应用调用的，用 client 凭证认证
+			path == "/oauth2/token" ||
+			path == "/oauth2/introspect" ||
+			path == "/oauth2/revoke" ||
+			path == "/captcha" ||
+			// 接口文档
+			path == "/openapi.json" ||
+			path == "/docs" {
 			// 不需要登录校验
 			return
 		}
@@ -63,6 +75,17 @@ func (m *LoginJWTMiddlewareBuilder) CheckLogin() gin.HandlerFunc {
 		//	return
 		//}
 
-		ctx.Set("user", uc)
+		ctx.Set(ginx.ClaimsKey, uc)
+		// 之后的日志都带上 uid
+		l := logger.FromContext(ctx.Request.Context()).With(logger.Field{Key: "uid", Val: uc.Uid})
+		ctx.Request = ctx.Request.WithContext(logger.WithContext(ctx.Request.Context(), l))
 	}
 }
+
+// isOAuth2Login 第三方登录的 /oauth2/:provider/authurl 和 /oauth2/:provider/callback
+func isOAuth2Login(path string) bool {
+	if !strings.HasPrefix(path, "/oauth2/") {
+		return false
+	}
+	return strings.HasSuffix(path, "/authurl") || strings.HasSuffix(path, "/callback")
+}
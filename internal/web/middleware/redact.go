@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+const redacted = "***"
+
+// redactor 按照 JSON 路径脱敏，nil 的时候什么都不做
+type redactor struct {
+	paths [][]string
+	// fields 表单只有一层，用路径的最后一段匹配
+	fields map[string]struct{}
+}
+
+func newRedactor(paths []string) *redactor {
+	if len(paths) == 0 {
+		return nil
+	}
+	r := &redactor{fields: make(map[string]struct{}, len(paths))}
+	for _, p := range paths {
+		segs := strings.Split(p, ".")
+		r.paths = append(r.paths, segs)
+		r.fields[segs[len(segs)-1]] = struct{}{}
+	}
+	return r
+}
+
+func (r *redactor) redact(contentType string, body []byte) []byte {
+	if r == nil || len(body) == 0 {
+		return body
+	}
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		return r.redactForm(body)
+	}
+	var val any
+	// 保留数字原本的样子，不然大整数会变成科学计数法
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&val); err != nil {
+		// 不是 JSON 的只可能是纯文本之类的，JSON 解析失败的不知道里面有什么，不记录
+		if strings.HasPrefix(contentType, "application/json") {
+			return []byte("[unparsable]")
+		}
+		return body
+	}
+	for _, p := range r.paths {
+		val = redactPath(val, p)
+	}
+	res, err := json.Marshal(val)
+	if err != nil {
+		return []byte("[unparsable]")
+	}
+	return res
+}
+
+func (r *redactor) redactForm(body []byte) []byte {
+	vals, err := url.ParseQuery(string(body))
+	if err != nil {
+		return []byte("[unparsable]")
+	}
+	for k := range vals {
+		if _, ok := r.fields[k]; ok {
+			vals[k] = []string{redacted}
+		}
+	}
+	return []byte(vals.Encode())
+}
+
+func redactPath(val any, path []string) any {
+	if len(path) == 0 {
+		return redacted
+	}
+	switch v := val.(type) {
+	case map[string]any:
+		for k, sub := range v {
+			if path[0] == "*" || path[0] == k {
+				v[k] = redactPath(sub, path[1:])
+			}
+		}
+	case []any:
+		// 数组的元素用 * 匹配，也可以直接跳过数组，例如 items.token
+		for i, sub := range v {
+			if path[0] == "*" {
+				v[i] = redactPath(sub, path[1:])
+			} else {
+				v[i] = redactPath(sub, path)
+			}
+		}
+	}
+	return val
+}
@@ -0,0 +1,97 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"webBook/internal/errs"
+	"webBook/internal/service"
+	"webBook/internal/service/oauth2"
+	ijwt "webBook/internal/web/jwt"
+	"webBook/pkg/ginx/openapi"
+)
+
+// OAuth2Handler 通用的第三方登录，provider 决定用哪个提供方
+type OAuth2Handler struct {
+	providers map[string]oauth2.Provider
+	userSvc   service.UserService
+	ijwt.Handler
+	state oauth2State
+}
+
+func NewOAuth2Handler(providers []oauth2.Provider, hdl ijwt.Handler, userSvc service.UserService,
+	stateSvc oauth2.StateService, cookieCfg StateCookieConfig) *OAuth2Handler {
+	m := make(map[string]oauth2.Provider, len(providers))
+	for _, p := range providers {
+		m[p.Name()] = p
+	}
+	return &OAuth2Handler{
+		providers: m,
+		userSvc:   userSvc,
+		Handler:   hdl,
+		state: oauth2State{
+			svc: stateSvc,
+			cfg: cookieCfg,
+		},
+	}
+}
+
+func (o *OAuth2Handler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/oauth2/:provider")
+	g.GET("/authurl", o.Auth2URL)
+	g.Any("/callback", o.Callback)
+}
+
+func (o *OAuth2Handler) Docs() []openapi.Route {
+	const tag = "oauth2"
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/oauth2/:provider/authurl", Tag: tag,
+			Summary: "第三方登录的地址", Query: OAuth2AuthURLReq{}, Resp: ""},
+		{Method: openapi.MethodAny, Path: "/oauth2/:provider/callback", Tag: tag,
+			Summary: "第三方登录回调，有 return_url 的时候登录之后跳回去", Query: OAuth2CallbackReq{}},
+	}
+}
+
+func (o *OAuth2Handler) Auth2URL(ctx *gin.Context) {
+	p, ok := o.providers[ctx.Param("provider")]
+	if !ok {
+		writeError(ctx, errs.OAuth2UnsupportedProvider)
+		return
+	}
+	o.state.authURL(ctx, p.Name(), func(state string) (string, error) {
+		return p.AuthURL(ctx, state)
+	})
+}
+
+func (o *OAuth2Handler) Callback(ctx *gin.Context) {
+	p, ok := o.providers[ctx.Param("provider")]
+	if !ok {
+		writeError(ctx, errs.OAuth2UnsupportedProvider)
+		return
+	}
+	returnURL, err := o.state.verify(ctx, p.Name())
+	if err != nil {
+		writeError(ctx, errs.OAuth2InvalidState)
+		return
+	}
+	token, err := p.Exchange(ctx, ctx.Query("code"))
+	if err != nil {
+		writeError(ctx, errs.OAuth2InvalidCode)
+		return
+	}
+	identity, err := p.UserInfo(ctx, token)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	u, err := o.userSvc.FindOrCreateByIdentity(ctx, identity)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	err = o.SetLoginToken(ctx, u.Id)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	o.state.loginSuccess(ctx, returnURL)
+}
@@ -0,0 +1,378 @@
+package web
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"webBook/internal/domain"
+	"webBook/internal/errs"
+	"webBook/internal/service/oauth2/server"
+	ijwt "webBook/internal/web/jwt"
+	"webBook/pkg/ginx"
+	"webBook/pkg/ginx/openapi"
+	"webBook/pkg/logger"
+)
+
+// OAuth2ServerHandler webBook 作为授权服务器，给内部的其他应用提供「用 webBook 登录」
+// /oauth2/authorize 需要先登录，前端没有登录的时候先走已有的登录接口（密码、短信、微信），
+// 登录之后再调用 authorize，用户同意之后前端跳转到返回的地址。
+// /oauth2/token、/oauth2/introspect 和 /oauth2/revoke 是给 client 调用的，按照 RFC 返回
+type OAuth2ServerHandler struct {
+	svc server.Service
+	ijwt.Handler
+}
+
+func NewOAuth2ServerHandler(svc server.Service, hdl ijwt.Handler) *OAuth2ServerHandler {
+	return &OAuth2ServerHandler{
+		svc:     svc,
+		Handler: hdl,
+	}
+}
+
+func (h *OAuth2ServerHandler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/oauth2")
+	g.GET("/authorize", h.Authorize)
+	g.POST("/authorize", h.Consent)
+	g.POST("/token", h.Token)
+	g.POST("/introspect", h.Introspect)
+	g.POST("/revoke", h.Revoke)
+}
+
+func (h *OAuth2ServerHandler) Docs() []openapi.Route {
+	const tag = "oauth2-server"
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/oauth2/authorize", Tag: tag,
+			Summary: "校验授权请求，返回是否需要用户同意", Auth: true, Query: authorizeReq{}, Resp: AuthorizeVO{}},
+		{Method: http.MethodPost, Path: "/oauth2/authorize", Tag: tag,
+			Summary: "用户同意或者拒绝授权", Auth: true, Body: ConsentReq{}, Resp: AuthorizeVO{}},
+		{Method: http.MethodPost, Path: "/oauth2/token", Tag: tag,
+			Summary: "RFC 6749 换 access token，client 凭证也可以放在 Basic 认证里面", Form: TokenReq{}, Resp: TokenVO{}, Raw: true},
+		{Method: http.MethodPost, Path: "/oauth2/introspect", Tag: tag,
+			Summary: "RFC 7662 校验 access token", Form: IntrospectReq{}, Resp: IntrospectVO{}, Raw: true},
+		{Method: http.MethodPost, Path: "/oauth2/revoke", Tag: tag,
+			Summary: "RFC 7009 吊销 access token", Form: IntrospectReq{}, Raw: true},
+	}
+}
+
+type authorizeReq struct {
+	ResponseType        string `json:"response_type" form:"response_type"`
+	ClientId            string `json:"client_id" form:"client_id"`
+	RedirectURI         string `json:"redirect_uri" form:"redirect_uri"`
+	Scope               string `json:"scope" form:"scope"`
+	State               string `json:"state" form:"state"`
+	CodeChallenge       string `json:"code_challenge" form:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method" form:"code_challenge_method"`
+}
+
+func (r authorizeReq) toService() server.AuthorizeRequest {
+	return server.AuthorizeRequest{
+		ResponseType:        r.ResponseType,
+		ClientId:            r.ClientId,
+		RedirectURI:         r.RedirectURI,
+		Scope:               r.Scope,
+		CodeChallenge:       r.CodeChallenge,
+		CodeChallengeMethod: r.CodeChallengeMethod,
+	}
+}
+
+// AuthorizeVO 前端根据 NeedConsent 决定展示同意页面还是直接跳转
+type AuthorizeVO struct {
+	NeedConsent bool     `json:"needConsent"`
+	ClientName  string   `json:"clientName,omitempty"`
+	Scopes      []string `json:"scopes,omitempty"`
+	Redirect    string   `json:"redirect,omitempty"`
+}
+
+func (h *OAuth2ServerHandler) Authorize(ctx *gin.Context) {
+	uc, ok := ctx.MustGet(ginx.ClaimsKey).(ijwt.UserClaims)
+	if !ok {
+		writeError(ctx, errs.Unauthorized)
+		return
+	}
+	var req authorizeReq
+	if err := ctx.BindQuery(&req); err != nil {
+		return
+	}
+	a, err := h.svc.Authorize(ctx, uc.Uid, req.toService())
+	if err != nil {
+		h.authorizeError(ctx, req, err)
+		return
+	}
+	if a.NeedConsent {
+		writeOK(ctx, "", AuthorizeVO{
+			NeedConsent: true,
+			ClientName:  a.Client.Name,
+			Scopes:      a.Scopes,
+		})
+		return
+	}
+	code, err := h.svc.IssueCode(ctx, uc.Uid, req.toService(), false)
+	if err != nil {
+		h.authorizeError(ctx, req, err)
+		return
+	}
+	writeOK(ctx, "", AuthorizeVO{
+		Redirect: redirectWith(req.RedirectURI, url.Values{"code": {code}}, req.State),
+	})
+}
+
+// ConsentReq 带上 authorize 的参数，再加上用户的选择
+type ConsentReq struct {
+	authorizeReq
+	Approve bool `json:"approve"`
+}
+
+// Consent 用户在同意页面点了同意或者拒绝
+func (h *OAuth2ServerHandler) Consent(ctx *gin.Context) {
+	uc, ok := ctx.MustGet(ginx.ClaimsKey).(ijwt.UserClaims)
+	if !ok {
+		writeError(ctx, errs.Unauthorized)
+		return
+	}
+	var req ConsentReq
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	var (
+		code string
+		err  error
+	)
+	if req.Approve {
+		code, err = h.svc.IssueCode(ctx, uc.Uid, req.toService(), true)
+	} else {
+		// 拒绝之前也要校验 client 和 redirect_uri，不能随便跳
+		_, err = h.svc.Authorize(ctx, uc.Uid, req.toService())
+		if err == nil {
+			err = server.ErrAccessDenied
+		}
+	}
+	if err != nil {
+		h.authorizeError(ctx, req.authorizeReq, err)
+		return
+	}
+	writeOK(ctx, "", AuthorizeVO{
+		Redirect: redirectWith(req.RedirectURI, url.Values{"code": {code}}, req.State),
+	})
+}
+
+// authorizeError client 和 redirect_uri 校验通过的错误要跳回 client，其他的直接报错
+func (h *OAuth2ServerHandler) authorizeError(ctx *gin.Context, req authorizeReq, err error) {
+	var oe *server.Error
+	if !errors.As(err, &oe) ||
+		errors.Is(err, server.ErrInvalidClient) || errors.Is(err, server.ErrInvalidRedirectURI) {
+		writeError(ctx, err)
+		return
+	}
+	writeOK(ctx, "", AuthorizeVO{
+		Redirect: redirectWith(req.RedirectURI, url.Values{
+			"error":             {oe.Code},
+			"error_description": {oe.Description},
+		}, req.State),
+	})
+}
+
+// ClientAuthReq client 凭证，也可以放在 HTTP Basic 里面，见 clientCredentials
+type ClientAuthReq struct {
+	ClientId     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+// TokenReq RFC 6749 4.1.3 和 4.4.2 的表单参数，handler 按照 RFC 直接读表单，这里只是说明
+type TokenReq struct {
+	ClientAuthReq
+	GrantType    string `form:"grant_type" binding:"required,oneof=authorization_code client_credentials"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	Scope        string `form:"scope"`
+}
+
+// IntrospectReq introspect 和 revoke 的表单参数
+type IntrospectReq struct {
+	ClientAuthReq
+	Token string `form:"token" binding:"required"`
+}
+
+// TokenVO RFC 6749 5.1
+type TokenVO struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+func (h *OAuth2ServerHandler) Token(ctx *gin.Context) {
+	clientId, clientSecret := clientCredentials(ctx)
+	var (
+		grant domain.OAuth2Grant
+		err   error
+	)
+	switch ctx.PostForm("grant_type") {
+	case server.GrantAuthorizationCode:
+		grant, err = h.svc.ExchangeCode(ctx, clientId, clientSecret, ctx.PostForm("code"),
+			ctx.PostForm("redirect_uri"), ctx.PostForm("code_verifier"))
+	case server.GrantClientCredentials:
+		grant, err = h.svc.ClientCredentials(ctx, clientId, clientSecret, ctx.PostForm("scope"))
+	default:
+		err = server.ErrUnsupportedGrantType
+	}
+	if err != nil {
+		h.tokenError(ctx, err)
+		return
+	}
+	sub := grant.ClientId
+	if grant.Uid > 0 {
+		sub = strconv.FormatInt(grant.Uid, 10)
+	}
+	now := time.Now()
+	scope := strings.Join(grant.Scopes, " ")
+	token, err := h.SignOAuth2Token(ijwt.OAuth2Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        grant.Id,
+			Subject:   sub,
+			Audience:  jwt.ClaimStrings{grant.ClientId},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(grant.ExpiresAt),
+		},
+		ClientId: grant.ClientId,
+		Scope:    scope,
+	})
+	if err != nil {
+		h.tokenError(ctx, err)
+		return
+	}
+	// RFC 6749 要求不能缓存
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, TokenVO{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(grant.ExpiresAt.Sub(now) / time.Second),
+		Scope:       scope,
+	})
+}
+
+// IntrospectVO RFC 7662，token 无效的时候只有 active=false
+type IntrospectVO struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientId  string `json:"client_id,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Jti       string `json:"jti,omitempty"`
+}
+
+func (h *OAuth2ServerHandler) Introspect(ctx *gin.Context) {
+	clientId, clientSecret := clientCredentials(ctx)
+	_, err := h.svc.AuthenticateClient(ctx, clientId, clientSecret)
+	if err != nil {
+		h.tokenError(ctx, err)
+		return
+	}
+	claims, err := h.ParseOAuth2Token(ctx.PostForm("token"))
+	if err != nil {
+		ctx.JSON(http.StatusOK, IntrospectVO{})
+		return
+	}
+	revoked, err := h.svc.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		h.tokenError(ctx, err)
+		return
+	}
+	if revoked {
+		ctx.JSON(http.StatusOK, IntrospectVO{})
+		return
+	}
+	vo := IntrospectVO{
+		Active:    true,
+		Scope:     claims.Scope,
+		ClientId:  claims.ClientId,
+		Sub:       claims.Subject,
+		TokenType: "Bearer",
+		Jti:       claims.ID,
+	}
+	if claims.ExpiresAt != nil {
+		vo.Exp = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		vo.Iat = claims.IssuedAt.Unix()
+	}
+	ctx.JSON(http.StatusOK, vo)
+}
+
+// Revoke RFC 7009，token 无效或者不是这个 client 的也返回 200
+func (h *OAuth2ServerHandler) Revoke(ctx *gin.Context) {
+	clientId, clientSecret := clientCredentials(ctx)
+	client, err := h.svc.AuthenticateClient(ctx, clientId, clientSecret)
+	if err != nil {
+		h.tokenError(ctx, err)
+		return
+	}
+	claims, err := h.ParseOAuth2Token(ctx.PostForm("token"))
+	if err != nil || claims.ClientId != client.ClientId || claims.ExpiresAt == nil {
+		ctx.Status(http.StatusOK)
+		return
+	}
+	err = h.svc.Revoke(ctx, claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		h.tokenError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusOK)
+}
+
+// tokenError RFC 6749 5.2 的错误格式
+func (h *OAuth2ServerHandler) tokenError(ctx *gin.Context, err error) {
+	var oe *server.Error
+	if !errors.As(err, &oe) {
+		logger.FromContext(ctx).Error("OAuth2 token 接口失败", logger.Field{Key: "err", Val: err})
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	status := http.StatusBadRequest
+	if errors.Is(err, server.ErrInvalidClient) {
+		status = http.StatusUnauthorized
+		ctx.Header("WWW-Authenticate", `Basic realm="webBook"`)
+	}
+	ctx.JSON(status, gin.H{
+		"error":             oe.Code,
+		"error_description": oe.Description,
+	})
+}
+
+// clientCredentials 优先用 HTTP Basic，其次是表单里面的 client_id 和 client_secret
+func clientCredentials(ctx *gin.Context) (string, string) {
+	if id, secret, ok := ctx.Request.BasicAuth(); ok {
+		// RFC 6749 2.3.1 要求先做 URL 编码
+		if uid, err := url.QueryUnescape(id); err == nil {
+			id = uid
+		}
+		if usecret, err := url.QueryUnescape(secret); err == nil {
+			secret = usecret
+		}
+		return id, secret
+	}
+	return ctx.PostForm("client_id"), ctx.PostForm("client_secret")
+}
+
+func redirectWith(redirectURI string, params url.Values, state string) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	if state != "" {
+		q.Set("state", state)
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,94 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"webBook/internal/i18n"
+	"webBook/internal/service/oauth2"
+)
+
+// StateCookieConfig 第三方登录 state cookie 的配置
+type StateCookieConfig struct {
+	Name   string
+	Domain string
+	// Secure 线上必须是 true，只有本地开发用 http 的时候才关掉
+	Secure bool
+	// MaxAge 单位是秒，和 state 在 Redis 里面的过期时间保持一致
+	MaxAge int
+}
+
+// OAuth2AuthURLReq 第三方登录地址的 query 参数，start 直接读 query，这里只是说明
+type OAuth2AuthURLReq struct {
+	// ReturnURL 登录成功之后跳回去的地址，必须在白名单里面
+	ReturnURL string `form:"return_url"`
+}
+
+// OAuth2CallbackReq 第三方回调的 query 参数
+type OAuth2CallbackReq struct {
+	Code  string `form:"code" binding:"required"`
+	State string `form:"state" binding:"required"`
+}
+
+// oauth2State state 保存在服务端，cookie 里面也放一份，
+// 保证回调的浏览器就是发起登录的浏览器
+type oauth2State struct {
+	svc oauth2.StateService
+	cfg StateCookieConfig
+}
+
+// start 生成 state 并且写 cookie，return_url 是登录成功之后跳回去的地址
+func (s oauth2State) start(ctx *gin.Context, provider string) (string, error) {
+	state, err := s.svc.Create(ctx, provider, ctx.Query("return_url"))
+	if err != nil {
+		return "", err
+	}
+	s.setCookie(ctx, provider, state, s.cfg.MaxAge)
+	return state, nil
+}
+
+// verify 校验 state，校验之后 state 就失效了，返回登录之后跳转的地址
+func (s oauth2State) verify(ctx *gin.Context, provider string) (string, error) {
+	state := ctx.Query("state")
+	ck, err := ctx.Cookie(s.cfg.Name)
+	if err != nil {
+		return "", err
+	}
+	// 不管成功失败都删掉 cookie
+	s.setCookie(ctx, provider, "", -1)
+	if ck != state {
+		return "", oauth2.ErrInvalidState
+	}
+	return s.svc.Take(ctx, provider, state)
+}
+
+func (s oauth2State) setCookie(ctx *gin.Context, provider, state string, maxAge int) {
+	// 第三方跳转回来是顶层的 GET 请求，Lax 就够了
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(s.cfg.Name, state, maxAge, "/oauth2/"+provider+"/callback",
+		s.cfg.Domain, s.cfg.Secure, true)
+}
+
+// authURL Auth2URL 的通用部分
+func (s oauth2State) authURL(ctx *gin.Context, provider string,
+	build func(state string) (string, error)) {
+	state, err := s.start(ctx, provider)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	val, err := build(state)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	writeOK(ctx, "", val)
+}
+
+// loginSuccess 有 returnURL 就跳回去，没有就返回 JSON
+func (s oauth2State) loginSuccess(ctx *gin.Context, returnURL string) {
+	if returnURL != "" {
+		ctx.Redirect(http.StatusFound, returnURL)
+		return
+	}
+	writeOK(ctx, i18n.MsgOK, nil)
+}
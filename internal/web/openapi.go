@@ -0,0 +1,21 @@
+package web
+
+import (
+	"webBook/pkg/ginx/openapi"
+)
+
+// OpenAPI 根据所有 handler 的 Docs 生成接口文档
+func OpenAPI(hdls ...Handler) openapi.Document {
+	g := openapi.NewGenerator(openapi.Info{
+		Title: "webook",
+		Description: "除了 /oauth2/token 这几个按照 RFC 返回的接口，响应都是 {code, msg, data}。" +
+			"请求头 Accept-Language 决定 msg 的语言，X-Result-Version: 2 使用新版本的错误码",
+		Version: "v1",
+	}).
+		BindingFormat("password", "password").
+		BindingFormat("phone", "phone")
+	for _, h := range hdls {
+		g.Add(h.Docs()...)
+	}
+	return g.Document()
+}
@@ -0,0 +1,78 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// ginAnyMethods gin 的 Any 注册的方法，文档里面只写 GET 和 POST
+var ginAnyMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodHead, http.MethodOptions, http.MethodDelete, http.MethodConnect, http.MethodTrace,
+}
+
+var ginParam = regexp.MustCompile(`[:*](\w+)`)
+
+// TestOpenAPI_Routes RegisterRoutes 注册的路由和文档对不上的时候失败，改了路由要一起改 Docs
+func TestOpenAPI_Routes(t *testing.T) {
+	hdls := []Handler{&UserHandler{}, &OAuth2WechatHandler{}, &MiniProgramHandler{},
+		&OAuth2Handler{}, &OAuth2ServerHandler{}, &CaptchaHandler{}}
+	server := gin.New()
+	for _, hdl := range hdls {
+		hdl.RegisterRoutes(server)
+	}
+	doc := OpenAPI(hdls...)
+
+	methods := make(map[string][]string)
+	for _, r := range server.Routes() {
+		path := ginParam.ReplaceAllString(r.Path, "{$1}")
+		methods[path] = append(methods[path], r.Method)
+	}
+	var registered []string
+	for path, ms := range methods {
+		if len(ms) == len(ginAnyMethods) {
+			ms = []string{http.MethodGet, http.MethodPost}
+		}
+		for _, m := range ms {
+			registered = append(registered, strings.ToLower(m)+" "+path)
+		}
+	}
+	var documented []string
+	for path, item := range doc.Paths {
+		for m := range item {
+			documented = append(documented, m+" "+path)
+		}
+	}
+	sort.Strings(registered)
+	sort.Strings(documented)
+	assert.Equal(t, registered, documented)
+}
+
+func TestOpenAPI_Schemas(t *testing.T) {
+	doc := OpenAPI(&UserHandler{})
+
+	signUp := doc.Components.Schemas["SignUpReq"]
+	require.NotNil(t, signUp)
+	assert.ElementsMatch(t, []string{"email", "password", "confirmPassword"}, signUp.Required)
+	assert.Equal(t, "email", signUp.Properties["email"].Format)
+	assert.Equal(t, "password", signUp.Properties["password"].Format)
+
+	edit := doc.Components.Schemas["EditReq"]
+	require.NotNil(t, edit)
+	assert.Equal(t, 128, *edit.Properties["nickname"].MaxLength)
+	assert.Equal(t, 4096, *edit.Properties["aboutMe"].MaxLength)
+	assert.Equal(t, "date", edit.Properties["birthday"].Format)
+	assert.Equal(t, []string{"zh-CN", "en-US"}, edit.Properties["locale"].Enum)
+
+	profile := doc.Paths["/users/profile"]["get"]
+	require.NotNil(t, profile)
+	assert.NotEmpty(t, profile.Security)
+	data := profile.Responses["200"].Content["application/json"].Schema.Properties["data"]
+	assert.Equal(t, "#/components/schemas/ProfileVO", data.Ref)
+}
@@ -1,7 +1,173 @@
 package web
 
-type Result struct {
-	Code int    `json:"code"`
-	Msg  string `json:"msg"`
-	Data any    `json:"data"`
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sync/atomic"
+	"webBook/internal/errs"
+	"webBook/internal/i18n"
+	"webBook/internal/service"
+	"webBook/internal/service/oauth2"
+	"webBook/internal/service/oauth2/server"
+	"webBook/internal/service/oauth2/wechat"
+	"webBook/internal/service/sms/ratelimit"
+	"webBook/pkg/ginx"
+	"webBook/pkg/logger"
+	"webBook/pkg/phonex"
+)
+
+// Result 所有接口统一的响应格式，Code 为 0 就是成功，其他的是 errs 里面定义的错误码
+type Result = ginx.Result
+
+// RegisterResultHandlers ginx.Wrap 系列的 handler 的响应也按照这里的错误码和老版本格式写，
+// 改的是 ginx 全局的 handler，启动的时候调用
+func RegisterResultHandlers() {
+	ginx.SetErrorHandler(writeError)
+	ginx.SetResultHandler(writeResult)
+}
+
+// ResultVersionHeader 客户端用来声明自己认识哪个版本的错误码：
+// 1 是老版本，HTTP 状态码都是 200，错误码只有 4 和 5；2 是新版本
+const ResultVersionHeader = "X-Result-Version"
+
+var legacyResult atomic.Bool
+
+// SetLegacyResult 没有带 ResultVersionHeader 的请求是否按照老版本返回，
+// 老客户端都升级之后关掉
+func SetLegacyResult(legacy bool) {
+	legacyResult.Store(legacy)
+}
+
+func isLegacy(ctx *gin.Context) bool {
+	switch ctx.GetHeader(ResultVersionHeader) {
+	case "1":
+		return true
+	case "2":
+		return false
+	default:
+		return legacyResult.Load()
+	}
+}
+
+// legacyShape 统一成 Result 之前，有些接口返回的不是 Result，老客户端还是按照原来的格式解析
+type legacyShape int
+
+const (
+	legacyShapeResult legacyShape = iota
+	// legacyShapeText 成功和失败都是直接返回提示信息的纯文本
+	legacyShapeText
+	// legacyShapeData 成功的时候直接返回数据，失败的时候是纯文本
+	legacyShapeData
+)
+
+const legacyShapeKey = "web.legacyShape"
+
+// legacyText 注册路由的时候放在 handler 前面，标记这个接口老版本返回纯文本
+func legacyText(ctx *gin.Context) {
+	ctx.Set(legacyShapeKey, legacyShapeText)
+}
+
+// legacyData 注册路由的时候放在 handler 前面，标记这个接口老版本直接返回数据
+func legacyData(ctx *gin.Context) {
+	ctx.Set(legacyShapeKey, legacyShapeData)
+}
+
+func legacyShapeOf(ctx *gin.Context) legacyShape {
+	if !isLegacy(ctx) {
+		return legacyShapeResult
+	}
+	val, _ := ctx.Get(legacyShapeKey)
+	shape, _ := val.(legacyShape)
+	return shape
+}
+
+// writeOK msg 是 i18n 里面提示信息的 key，为空就是没有提示
+func writeOK(ctx *gin.Context, msg string, data any) {
+	if msg != "" {
+		msg = i18n.T(ctx, msg)
+	}
+	writeResult(ctx, Result{Msg: msg, Data: data})
+}
+
+// writeResult ginx.Wrap 系列的 handler 成功的时候也在这里写响应
+func writeResult(ctx *gin.Context, res Result) {
+	switch legacyShapeOf(ctx) {
+	case legacyShapeText:
+		ctx.String(http.StatusOK, res.Msg)
+	case legacyShapeData:
+		ctx.JSON(http.StatusOK, res.Data)
+	default:
+		ctx.JSON(http.StatusOK, res)
+	}
+}
+
+// writeError 把 err 翻译成错误码返回，翻译不了的当作系统错误，并且记录日志
+func writeError(ctx *gin.Context, err error) {
+	code := toCode(err)
+	if code == errs.SystemError {
+		logger.FromContext(ctx).Error("系统错误",
+			logger.Field{Key: "path", Val: ctx.FullPath()},
+			logger.Field{Key: "err", Val: err})
+	}
+	// 参数校验失败的时候带上具体是哪些字段不对
+	locale := i18n.FromContext(ctx)
+	data := ginx.TranslateFieldErrors(err, locale)
+	msg := i18n.CodeMsg(ctx, code)
+	switch legacyShapeOf(ctx) {
+	case legacyShapeText, legacyShapeData:
+		// 原来没有登录只有 401，没有响应体
+		if code == errs.Unauthorized {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		ctx.String(http.StatusOK, msg)
+		return
+	}
+	if isLegacy(ctx) {
+		status := http.StatusOK
+		// 没有登录一直都是 401，老客户端也是按照 401 处理的
+		if code == errs.Unauthorized {
+			status = http.StatusUnauthorized
+		}
+		ctx.JSON(status, Result{Code: code.LegacyCode(), Msg: msg, Data: data})
+		return
+	}
+	ctx.JSON(code.HTTPStatus, Result{Code: code.Code, Msg: msg, Data: data})
+}
+
+// bizErrors service 返回的错误和错误码的对应关系，所有的翻译都在这里
+var bizErrors = []struct {
+	err  error
+	code *errs.Code
+}{
+	{ginx.ErrBind, errs.InvalidParam},
+	{ginx.ErrNoClaims, errs.Unauthorized},
+	{service.ErrDuplicateEmail, errs.UserDuplicateEmail},
+	{service.ErrInvalidUserOrPassword, errs.UserInvalidCredential},
+	{service.ErrCodeSendTooMany, errs.CodeSendTooMany},
+	{service.ErrCodeQuotaExhaust, errs.CodeQuotaExhausted},
+	// 短信服务的全局、模板或者号码限流，*ratelimit.NumberLimitError 也是这个
+	{ratelimit.ErrLimited, errs.CodeSendTooMany},
+	{phonex.ErrInvalidPhone, errs.UserInvalidPhone},
+	{phonex.ErrRegionNotSupported, errs.UserPhoneNotSupported},
+	{oauth2.ErrInvalidState, errs.OAuth2InvalidState},
+	{oauth2.ErrReturnURLNotAllowed, errs.OAuth2ReturnURLNotAllowed},
+	{wechat.ErrSessionNotFound, errs.OAuth2SessionExpired},
+	{wechat.ErrInvalidEncryptedData, errs.OAuth2InvalidPhone},
+	{server.ErrInvalidClient, errs.OAuth2InvalidClient},
+	{server.ErrInvalidRedirectURI, errs.OAuth2InvalidRedirectURI},
+}
+
+func toCode(err error) *errs.Code {
+	var code *errs.Code
+	if errors.As(err, &code) {
+		return code
+	}
+	for _, be := range bizErrors {
+		if errors.Is(err, be.err) {
+			return be.code
+		}
+	}
+	return errs.SystemError
 }
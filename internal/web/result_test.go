@@ -0,0 +1,194 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"webBook/internal/errs"
+	"webBook/internal/service"
+	"webBook/pkg/phonex"
+)
+
+// TestMain 和 ioc.InitWebServer 一样，先注册响应的格式和校验规则
+func TestMain(m *testing.M) {
+	RegisterResultHandlers()
+	if err := RegisterValidators(phonex.NewParser("CN")); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func TestWriteError(t *testing.T) {
+	testCases := []struct {
+		name    string
+		legacy  bool
+		version string
+		shape   gin.HandlerFunc
+		err     error
+
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "service 的错误",
+			err:      service.ErrDuplicateEmail,
+			wantCode: http.StatusConflict,
+			wantBody: `{"code":201004,"msg":"邮箱冲突，请换一个","data":null}`,
+		},
+		{
+			name:     "包装过的 service 错误",
+			err:      errors.Join(errors.New("注册失败"), service.ErrCodeSendTooMany),
+			wantCode: http.StatusTooManyRequests,
+			wantBody: `{"code":202002,"msg":"短信发送太频繁，请稍后再试","data":null}`,
+		},
+		{
+			name:     "不认识的错误",
+			err:      errors.New("redis 崩了"),
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"code":100001,"msg":"系统错误","data":null}`,
+		},
+		{
+			name:     "兼容老客户端",
+			legacy:   true,
+			err:      errs.UserInvalidEmail,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":4,"msg":"非法邮箱格式","data":null}`,
+		},
+		{
+			name:     "兼容老客户端，系统错误",
+			legacy:   true,
+			err:      errors.New("redis 崩了"),
+			wantCode: http.StatusOK,
+			wantBody: `{"code":5,"msg":"系统错误","data":null}`,
+		},
+		{
+			name:     "兼容老客户端，没有登录还是 401",
+			legacy:   true,
+			err:      errs.Unauthorized,
+			wantCode: http.StatusUnauthorized,
+			wantBody: `{"code":4,"msg":"请先登录","data":null}`,
+		},
+		{
+			name:     "新客户端声明了版本",
+			legacy:   true,
+			version:  "2",
+			err:      errs.UserInvalidEmail,
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":201001,"msg":"非法邮箱格式","data":null}`,
+		},
+		{
+			name:     "老版本返回纯文本的接口",
+			legacy:   true,
+			shape:    legacyText,
+			err:      service.ErrDuplicateEmail,
+			wantCode: http.StatusOK,
+			wantBody: "邮箱冲突，请换一个",
+		},
+		{
+			name:     "老版本直接返回数据的接口，没有登录",
+			legacy:   true,
+			shape:    legacyData,
+			err:      errs.Unauthorized,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "新客户端不管接口原来的格式",
+			legacy:   true,
+			version:  "2",
+			shape:    legacyText,
+			err:      service.ErrDuplicateEmail,
+			wantCode: http.StatusConflict,
+			wantBody: `{"code":201004,"msg":"邮箱冲突，请换一个","data":null}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			SetLegacyResult(tc.legacy)
+			defer SetLegacyResult(false)
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.version != "" {
+				ctx.Request.Header.Set(ResultVersionHeader, tc.version)
+			}
+			if tc.shape != nil {
+				tc.shape(ctx)
+			}
+			writeError(ctx, tc.err)
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assertBody(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestWriteResult(t *testing.T) {
+	testCases := []struct {
+		name   string
+		legacy bool
+		shape  gin.HandlerFunc
+		res    Result
+
+		wantBody string
+	}{
+		{
+			name:     "统一的格式",
+			res:      Result{Msg: "注册成功"},
+			wantBody: `{"code":0,"msg":"注册成功","data":null}`,
+		},
+		{
+			name:     "老版本没有标记的接口也是统一的格式",
+			legacy:   true,
+			res:      Result{Msg: "发送成功"},
+			wantBody: `{"code":0,"msg":"发送成功","data":null}`,
+		},
+		{
+			name:     "老版本返回纯文本",
+			legacy:   true,
+			shape:    legacyText,
+			res:      Result{Msg: "注册成功"},
+			wantBody: "注册成功",
+		},
+		{
+			name:     "老版本直接返回数据",
+			legacy:   true,
+			shape:    legacyData,
+			res:      Result{Data: ProfileVO{Nickname: "Tom"}},
+			wantBody: `{"nickname":"Tom","email":"","aboutMe":"","birthday":"","locale":""}`,
+		},
+		{
+			name:     "新版本不管接口原来的格式",
+			shape:    legacyData,
+			res:      Result{Data: ProfileVO{Nickname: "Tom"}},
+			wantBody: `{"code":0,"msg":"","data":{"nickname":"Tom","email":"","aboutMe":"","birthday":"","locale":""}}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			SetLegacyResult(tc.legacy)
+			defer SetLegacyResult(false)
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.shape != nil {
+				tc.shape(ctx)
+			}
+			writeResult(ctx, tc.res)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assertBody(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+// assertBody 老版本有的接口返回纯文本，不是 JSON
+func assertBody(t *testing.T, want, got string) {
+	if json.Valid([]byte(want)) {
+		assert.JSONEq(t, want, got)
+		return
+	}
+	assert.Equal(t, want, got)
+}
@@ -1,7 +1,12 @@
 package web
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/gin-gonic/gin"
+	"webBook/pkg/ginx/openapi"
+)
 
 type Handler interface {
 	RegisterRoutes(server *gin.Engine)
+	// Docs 注册的路由的接口文档
+	Docs() []openapi.Route
 }
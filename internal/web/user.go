@@ -1,43 +1,50 @@
 package web
 
 import (
-	regexp "github.com/dlclark/regexp2"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
-	"go.uber.org/zap"
-	"log"
 	"net/http"
 	"time"
 	"webBook/internal/domain"
+	"webBook/internal/errs"
+	"webBook/internal/i18n"
 	"webBook/internal/service"
+	"webBook/internal/service/captcha"
 	ijwt "webBook/internal/web/jwt"
+	"webBook/pkg/ginx"
+	"webBook/pkg/ginx/openapi"
+	"webBook/pkg/logger"
+	"webBook/pkg/phonex"
 )
 
-const (
-	emailRegexPattern = "^\\w+([-+.]\\w+)*@\\w+([-.]\\w+)*\\.\\w+([-.]\\w+)*$"
-	// 和上面比起来，用 ` 看起来就比较清爽
-	passwordRegexPattern = `^(?=.*[A-Za-z])(?=.*\d)(?=.*[$@$!%*#?&])[A-Za-z\d$@$!%*#?&]{8,}$`
-	bizLogin             = "login"
-)
+const bizLogin = "login"
 
 type UserHandler struct {
 	ijwt.Handler
-	emailRexExp    *regexp.Regexp
-	passwordRexExp *regexp.Regexp
-	svc            service.UserService
-	codeSvc        service.CodeService
+	// phoneParser 把手机号码统一成 E.164 格式
+	phoneParser *phonex.Parser
+	svc         service.UserService
+	codeSvc     service.CodeService
+	// captchaSvc 记录登录失败次数，决定要不要图形验证码
+	captchaSvc captcha.Service
+	// captchaVerifier 校验图形验证码，可以换成第三方的实现
+	captchaVerifier captcha.Verifier
 }
 
 func NewUserHandler(svc service.UserService,
 	hdl ijwt.Handler,
-	codeSvc service.CodeService) *UserHandler {
+	codeSvc service.CodeService,
+	phoneParser *phonex.Parser,
+	captchaSvc captcha.Service,
+	captchaVerifier captcha.Verifier) *UserHandler {
 	return &UserHandler{
-		emailRexExp:    regexp.MustCompile(emailRegexPattern, regexp.None),
-		passwordRexExp: regexp.MustCompile(passwordRegexPattern, regexp.None),
-		svc:            svc,
-		codeSvc:        codeSvc,
-		Handler:        hdl,
+		phoneParser:     phoneParser,
+		svc:             svc,
+		codeSvc:         codeSvc,
+		captchaSvc:      captchaSvc,
+		captchaVerifier: captchaVerifier,
+		Handler:         hdl,
 	}
 }
 
@@ -47,177 +54,155 @@ func (h *UserHandler) RegisterRoutes(server *gin.Engine) {
 	//server.PUT("/user", h.SignUp)
 	//server.GET("/users/:username", h.Profile)
 	ug := server.Group("/users")
+	// 老版本的注册、登录、编辑返回纯文本，profile 直接返回数据
 	// POST /users/signup
-	ug.POST("/signup", h.SignUp)
+	ug.POST("/signup", legacyText, ginx.WrapBody(h.SignUp))
 	// POST /users/login
-	//ug.POST("/login", h.Login)
-	ug.POST("/login", h.LoginJWT)
-	ug.POST("/logout", h.LogoutJWT)
+	//ug.POST("/login", ginx.WrapBody(h.Login))
+	ug.POST("/login", legacyText, ginx.WrapBody(h.LoginJWT))
+	ug.POST("/logout", ginx.Wrap(h.LogoutJWT))
 	// POST /users/edit
-	ug.POST("/edit", h.Edit)
+	ug.POST("/edit", legacyText, ginx.WrapBodyAndClaims(h.Edit))
 	// GET /users/profile
-	ug.GET("/profile", h.Profile)
+	ug.GET("/profile", legacyData, ginx.WrapClaims(h.Profile))
 	ug.GET("/refresh_token", h.RefreshToken)
 
 	// 手机验证码登录相关功能
-	ug.POST("/login_sms/code/send", h.SendSMSLoginCode)
-	ug.POST("/login_sms", h.LoginSMS)
+	ug.POST("/login_sms/code/send", ginx.WrapBody(h.SendSMSLoginCode))
+	ug.POST("/login_sms", ginx.WrapBody(h.LoginSMS))
 }
 
-func (h *UserHandler) LoginSMS(ctx *gin.Context) {
-	type Req struct {
-		Phone string `json:"phone"`
-		Code  string `json:"code"`
-	}
-	var req Req
-	if err := ctx.Bind(&req); err != nil {
-		return
+// Docs 和 RegisterRoutes 一一对应，改了路由记得一起改
+func (h *UserHandler) Docs() []openapi.Route {
+	const tag = "user"
+	return []openapi.Route{
+		{Method: http.MethodPost, Path: "/users/signup", Tag: tag, Summary: "邮箱注册", Body: SignUpReq{}},
+		{Method: http.MethodPost, Path: "/users/login", Tag: tag,
+			Summary: "邮箱密码登录，token 在响应头 x-jwt-token 和 x-refresh-token 里面", Body: LoginReq{}},
+		{Method: http.MethodPost, Path: "/users/logout", Tag: tag, Summary: "退出登录", Auth: true},
+		{Method: http.MethodPost, Path: "/users/edit", Tag: tag, Summary: "修改个人资料", Auth: true, Body: EditReq{}},
+		{Method: http.MethodGet, Path: "/users/profile", Tag: tag, Summary: "个人资料", Auth: true, Resp: ProfileVO{}},
+		{Method: http.MethodGet, Path: "/users/refresh_token", Tag: tag,
+			Summary: "用 refresh token 换新的 access token，Authorization 带的是 refresh token", Auth: true},
+		{Method: http.MethodPost, Path: "/users/login_sms/code/send", Tag: tag, Summary: "发送登录验证码", Body: SendSMSCodeReq{}},
+		{Method: http.MethodPost, Path: "/users/login_sms", Tag: tag, Summary: "短信验证码登录", Body: LoginSMSReq{}},
 	}
+}
+
+type LoginSMSReq struct {
+	Phone string `json:"phone" binding:"required,phone"`
+	Code  string `json:"code" binding:"required"`
+}
 
-	ok, err := h.codeSvc.Verify(ctx, bizLogin, req.Phone, req.Code)
+func (h *UserHandler) LoginSMS(ctx *gin.Context, req LoginSMSReq) (ginx.Result, error) {
+	phone, err := h.phoneParser.Normalize(req.Phone)
 	if err != nil {
-		ctx.JSON(http.StatusOK, Result{
-			Code: 5,
-			Msg:  "系统异常",
-		})
-		zap.L().Error("手机验证码失败", zap.Error(err))
-		return
+		return ginx.Result{}, err
+	}
+	ok, err := h.codeSvc.Verify(ctx, bizLogin, phone, req.Code)
+	if err != nil {
+		return ginx.Result{}, err
 	}
 	if !ok {
-		ctx.JSON(http.StatusOK, Result{
-			Code: 4,
-			Msg:  "验证码不对，请重新输入",
-		})
-		return
+		return ginx.Result{}, errs.CodeInvalid
 	}
-	u, err := h.svc.FindOrCreate(ctx, req.Phone)
+	u, err := h.svc.FindOrCreate(ctx, phone)
 	if err != nil {
-		ctx.JSON(http.StatusOK, Result{
-			Code: 5,
-			Msg:  "系统错误",
-		})
-		return
+		return ginx.Result{}, err
 	}
 	err = h.SetLoginToken(ctx, u.Id)
 	if err != nil {
-		ctx.String(http.StatusOK, "系统错误")
-		return
+		return ginx.Result{}, err
 	}
-	ctx.JSON(http.StatusOK, Result{
-		Msg: "登录成功",
-	})
+	return ginx.Result{Msg: i18n.T(ctx, i18n.MsgLoginOK)}, nil
 }
 
-func (h *UserHandler) SendSMSLoginCode(ctx *gin.Context) {
-	type Req struct {
-		Phone string `json:"phone"`
-	}
-	var req Req
-	if err := ctx.Bind(&req); err != nil {
-		return
-	}
-	// 你这边可以校验 Req
-	if req.Phone == "" {
-		ctx.JSON(http.StatusOK, Result{
-			Code: 4,
-			Msg:  "请输入手机号码",
-		})
-		return
-	}
-	err := h.codeSvc.Send(ctx, bizLogin, req.Phone)
-	switch err {
-	case nil:
-		ctx.JSON(http.StatusOK, Result{
-			Msg: "发送成功",
-		})
-	case service.ErrCodeSendTooMany:
-		ctx.JSON(http.StatusOK, Result{
-			Code: 4,
-			Msg:  "短信发送太频繁，请稍后再试",
-		})
-	default:
-		ctx.JSON(http.StatusOK, Result{
-			Code: 5,
-			Msg:  "系统错误",
-		})
-		// 补日志的
-		log.Println(err)
-	}
+type SendSMSCodeReq struct {
+	Phone string `json:"phone" binding:"required,phone"`
+	// 发送验证码之前必须先通过图形验证码
+	CaptchaId string `json:"captchaId"`
+	Captcha   string `json:"captcha"`
 }
 
-func (h *UserHandler) SignUp(ctx *gin.Context) {
-	type SignUpReq struct {
-		Email           string `json:"email"`
-		Password        string `json:"password"`
-		ConfirmPassword string `json:"confirmPassword"`
-	}
-
-	var req SignUpReq
-	if err := ctx.Bind(&req); err != nil {
-		return
-	}
-
-	isEmail, err := h.emailRexExp.MatchString(req.Email)
+func (h *UserHandler) SendSMSLoginCode(ctx *gin.Context, req SendSMSCodeReq) (ginx.Result, error) {
+	phone, err := h.phoneParser.Normalize(req.Phone)
 	if err != nil {
-		ctx.String(http.StatusOK, "系统错误")
-		return
+		return ginx.Result{}, err
 	}
-	if !isEmail {
-		ctx.String(http.StatusOK, "非法邮箱格式")
-		return
+	ok, err := h.captchaVerifier.Verify(ctx, req.CaptchaId, req.Captcha)
+	if err != nil {
+		return ginx.Result{}, err
 	}
-
-	if req.Password != req.ConfirmPassword {
-		ctx.String(http.StatusOK, "两次输入密码不对")
-		return
+	if !ok {
+		return ginx.Result{}, errs.CaptchaInvalid
 	}
-
-	isPassword, err := h.passwordRexExp.MatchString(req.Password)
+	err = h.codeSvc.Send(ctx, bizLogin, phone, ctx.ClientIP())
 	if err != nil {
-		ctx.String(http.StatusOK, "系统错误")
-		return
-	}
-	if !isPassword {
-		ctx.String(http.StatusOK, "密码必须包含字母、数字、特殊字符，并且不少于八位")
-		return
+		return ginx.Result{}, err
 	}
+	return ginx.Result{Msg: i18n.T(ctx, i18n.MsgCodeSent)}, nil
+}
 
-	err = h.svc.Signup(ctx, domain.User{
+type SignUpReq struct {
+	Email           string `json:"email" binding:"required,email"`
+	Password        string `json:"password" binding:"required,password"`
+	ConfirmPassword string `json:"confirmPassword" binding:"required,eqfield=Password"`
+}
+
+func (h *UserHandler) SignUp(ctx *gin.Context, req SignUpReq) (ginx.Result, error) {
+	err := h.svc.Signup(ctx, domain.User{
 		Email:    req.Email,
 		Password: req.Password,
 	})
-	switch err {
-	case nil:
-		ctx.String(http.StatusOK, "注册成功")
-	case service.ErrDuplicateEmail:
-		ctx.String(http.StatusOK, "邮箱冲突，请换一个")
-	default:
-		ctx.String(http.StatusOK, "系统错误")
+	if err != nil {
+		return ginx.Result{}, err
 	}
+	return ginx.Result{Msg: i18n.T(ctx, i18n.MsgSignupOK)}, nil
 }
 
-func (h *UserHandler) LoginJWT(ctx *gin.Context) {
-	type Req struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+type LoginReq struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	// 连续登录失败之后，需要带上图形验证码
+	CaptchaId string `json:"captchaId"`
+	Captcha   string `json:"captcha"`
+}
+
+func (h *UserHandler) LoginJWT(ctx *gin.Context, req LoginReq) (ginx.Result, error) {
+	need, err := h.captchaSvc.Required(ctx, bizLogin, req.Email)
+	if err != nil {
+		return ginx.Result{}, err
 	}
-	var req Req
-	if err := ctx.Bind(&req); err != nil {
-		return
+	if need {
+		ok, err := h.captchaVerifier.Verify(ctx, req.CaptchaId, req.Captcha)
+		if err != nil {
+			return ginx.Result{}, err
+		}
+		if !ok {
+			return ginx.Result{}, errs.CaptchaInvalid
+		}
 	}
 	u, err := h.svc.Login(ctx, req.Email, req.Password)
 	switch err {
 	case nil:
+		err = h.captchaSvc.ResetFailure(ctx, bizLogin, req.Email)
+		if err != nil {
+			// 不影响登录
+			logger.FromContext(ctx).Error("清除登录失败次数失败", logger.Field{Key: "err", Val: err})
+		}
 		err = h.SetLoginToken(ctx, u.Id)
 		if err != nil {
-			ctx.String(http.StatusOK, "系统错误")
-			return
+			return ginx.Result{}, err
 		}
-		ctx.String(http.StatusOK, "登录成功")
+		return ginx.Result{Msg: i18n.T(ctx, i18n.MsgLoginOK)}, nil
 	case service.ErrInvalidUserOrPassword:
-		ctx.String(http.StatusOK, "用户名或者密码不对")
+		err = h.captchaSvc.RecordFailure(ctx, bizLogin, req.Email)
+		if err != nil {
+			logger.FromContext(ctx).Error("记录登录失败次数失败", logger.Field{Key: "err", Val: err})
+		}
+		return ginx.Result{}, errs.UserInvalidCredential
 	default:
-		ctx.String(http.StatusOK, "系统错误")
+		return ginx.Result{}, err
 	}
 }
 
@@ -229,107 +214,78 @@ func (h *UserHandler) LoginJWT(ctx *gin.Context) {
 //	sess.Save()
 //}
 
-func (h *UserHandler) Login(ctx *gin.Context) {
-	type Req struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}
-	var req Req
-	if err := ctx.Bind(&req); err != nil {
-		return
-	}
+func (h *UserHandler) Login(ctx *gin.Context, req LoginReq) (ginx.Result, error) {
 	u, err := h.svc.Login(ctx, req.Email, req.Password)
-	switch err {
-	case nil:
-		sess := sessions.Default(ctx)
-		sess.Set("userId", u.Id)
-		sess.Options(sessions.Options{
-			// 十分钟
-			MaxAge: 30,
-		})
-		err = sess.Save()
-		if err != nil {
-			ctx.String(http.StatusOK, "系统错误")
-			return
-		}
-		ctx.String(http.StatusOK, "登录成功")
-	case service.ErrInvalidUserOrPassword:
-		ctx.String(http.StatusOK, "用户名或者密码不对")
-	default:
-		ctx.String(http.StatusOK, "系统错误")
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	sess := sessions.Default(ctx)
+	sess.Set("userId", u.Id)
+	sess.Options(sessions.Options{
+		// 十分钟
+		MaxAge: 30,
+	})
+	err = sess.Save()
+	if err != nil {
+		return ginx.Result{}, err
 	}
+	return ginx.Result{Msg: i18n.T(ctx, i18n.MsgLoginOK)}, nil
 }
 
-func (h *UserHandler) Edit(ctx *gin.Context) {
-	// 嵌入一段刷新过期时间的代码
-	type Req struct {
-		// 改邮箱，密码，或者能不能改手机号
+type EditReq struct {
+	// 改邮箱，密码，或者能不能改手机号
 
-		Nickname string `json:"nickname"`
-		// YYYY-MM-DD
-		Birthday string `json:"birthday"`
-		AboutMe  string `json:"aboutMe"`
-	}
-	var req Req
-	if err := ctx.Bind(&req); err != nil {
-		return
-	}
-	//sess := sessions.Default(ctx)
-	//sess.Get("uid")
-	uc, ok := ctx.MustGet("user").(ijwt.UserClaims)
-	if !ok {
-		//ctx.String(http.StatusOK, "系统错误")
-		ctx.AbortWithStatus(http.StatusUnauthorized)
-		return
-	}
-	// 用户输入不对
+	// 长度和数据库的字段一致
+	Nickname string `json:"nickname" binding:"max=128"`
+	// YYYY-MM-DD
+	Birthday string `json:"birthday" binding:"required,datetime=2006-01-02"`
+	AboutMe  string `json:"aboutMe" binding:"max=4096"`
+	// Locale 界面和提示信息的语言，为空就是跟着浏览器
+	Locale string `json:"locale" binding:"omitempty,oneof=zh-CN en-US"`
+}
+
+func (h *UserHandler) Edit(ctx *gin.Context, req EditReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	// binding 已经校验过格式了
 	birthday, err := time.Parse(time.DateOnly, req.Birthday)
 	if err != nil {
-		//ctx.String(http.StatusOK, "系统错误")
-		ctx.String(http.StatusOK, "生日格式不对")
-		return
+		return ginx.Result{}, err
 	}
 	err = h.svc.UpdateNonSensitiveInfo(ctx, domain.User{
 		Id:       uc.Uid,
 		Nickname: req.Nickname,
 		Birthday: birthday,
 		AboutMe:  req.AboutMe,
+		Locale:   req.Locale,
 	})
 	if err != nil {
-		ctx.String(http.StatusOK, "系统异常")
-		return
+		return ginx.Result{}, err
 	}
-	ctx.String(http.StatusOK, "更新成功")
+	return ginx.Result{Msg: i18n.T(ctx, i18n.MsgEditOK)}, nil
 }
 
-func (h *UserHandler) Profile(ctx *gin.Context) {
-	//us := ctx.MustGet("user").(UserClaims)
-	//ctx.String(http.StatusOK, "这是 profile")
-	// 嵌入一段刷新过期时间的代码
+// ProfileVO 个人信息
+type ProfileVO struct {
+	Nickname string `json:"nickname"`
+	Email    string `json:"email"`
+	AboutMe  string `json:"aboutMe"`
+	Birthday string `json:"birthday"`
+	Locale   string `json:"locale"`
+}
 
-	uc, ok := ctx.MustGet("user").(ijwt.UserClaims)
-	if !ok {
-		//ctx.String(http.StatusOK, "系统错误")
-		ctx.AbortWithStatus(http.StatusUnauthorized)
-		return
-	}
+func (h *UserHandler) Profile(ctx *gin.Context, uc ijwt.UserClaims) (ginx.Result, error) {
 	u, err := h.svc.FindById(ctx, uc.Uid)
 	if err != nil {
-		ctx.String(http.StatusOK, "系统异常")
-		return
-	}
-	type User struct {
-		Nickname string `json:"nickname"`
-		Email    string `json:"email"`
-		AboutMe  string `json:"aboutMe"`
-		Birthday string `json:"birthday"`
-	}
-	ctx.JSON(http.StatusOK, User{
-		Nickname: u.Nickname,
-		Email:    u.Email,
-		AboutMe:  u.AboutMe,
-		Birthday: u.Birthday.Format(time.DateOnly),
-	})
+		return ginx.Result{}, err
+	}
+	return ginx.Result{
+		Data: ProfileVO{
+			Nickname: u.Nickname,
+			Email:    u.Email,
+			AboutMe:  u.AboutMe,
+			Birthday: u.Birthday.Format(time.DateOnly),
+			Locale:   u.Locale,
+		},
+	}, nil
 }
 
 func (h *UserHandler) RefreshToken(ctx *gin.Context) {
@@ -340,36 +296,33 @@ func (h *UserHandler) RefreshToken(ctx *gin.Context) {
 		return ijwt.RCJWTKey, nil
 	})
 	if err != nil {
-		ctx.AbortWithStatus(http.StatusUnauthorized)
+		writeError(ctx, errs.Unauthorized)
 		return
 	}
 	if token == nil || !token.Valid {
-		ctx.AbortWithStatus(http.StatusUnauthorized)
+		writeError(ctx, errs.Unauthorized)
 		return
 	}
 
 	err = h.CheckSession(ctx, rc.Ssid)
 	if err != nil {
 		// token 无效或者 redis 有问题
-		ctx.AbortWithStatus(http.StatusUnauthorized)
+		writeError(ctx, errs.Unauthorized)
 		return
 	}
 
 	err = h.SetJWTToken(ctx, rc.Uid, rc.Ssid)
 	if err != nil {
-		ctx.AbortWithStatus(http.StatusUnauthorized)
+		writeError(ctx, errs.Unauthorized)
 		return
 	}
-	ctx.JSON(http.StatusOK, Result{
-		Msg: "OK",
-	})
+	writeOK(ctx, i18n.MsgOK, nil)
 }
 
-func (h *UserHandler) LogoutJWT(ctx *gin.Context) {
+func (h *UserHandler) LogoutJWT(ctx *gin.Context) (ginx.Result, error) {
 	err := h.ClearToken(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusOK, Result{Code: 5, Msg: "系统错误"})
-		return
+		return ginx.Result{}, err
 	}
-	ctx.JSON(http.StatusOK, Result{Msg: "退出登录成功"})
+	return ginx.Result{Msg: i18n.T(ctx, i18n.MsgLogoutOK)}, nil
 }
@@ -3,17 +3,29 @@ package web
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 	"webBook/internal/domain"
 	"webBook/internal/service"
+	"webBook/internal/service/captcha"
+	captchamocks "webBook/internal/service/captcha/mocks"
 	svcmocks "webBook/internal/service/mocks"
+	"webBook/internal/service/sms/ratelimit"
+	ijwt "webBook/internal/web/jwt"
+	jwtmocks "webBook/internal/web/jwt/mocks"
+	"webBook/internal/web/middleware"
+	"webBook/pkg/phonex"
 )
 
 func TestUserHandler_SignUp(t *testing.T) {
@@ -54,7 +66,7 @@ func TestUserHandler_SignUp(t *testing.T) {
 			},
 
 			wantCode: http.StatusOK,
-			wantBody: "注册成功",
+			wantBody: `{"code":0,"msg":"注册成功","data":null}`,
 		},
 		{
 			name: "Bind出错",
@@ -75,6 +87,7 @@ func TestUserHandler_SignUp(t *testing.T) {
 			},
 
 			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":100002,"msg":"参数错误","data":null}`,
 		},
 
 		{
@@ -96,8 +109,8 @@ func TestUserHandler_SignUp(t *testing.T) {
 				return req
 			},
 
-			wantCode: http.StatusOK,
-			wantBody: "非法邮箱格式",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":100002,"msg":"参数错误","data":[{"field":"email","msg":"email必须是一个有效的邮箱"}]}`,
 		},
 		{
 			name: "两次密码输入不同",
@@ -118,8 +131,8 @@ func TestUserHandler_SignUp(t *testing.T) {
 				return req
 			},
 
-			wantCode: http.StatusOK,
-			wantBody: "两次输入密码不对",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":100002,"msg":"参数错误","data":[{"field":"confirmPassword","msg":"confirmPassword必须等于Password"}]}`,
 		},
 
 		{
@@ -141,8 +154,32 @@ func TestUserHandler_SignUp(t *testing.T) {
 				return req
 			},
 
-			wantCode: http.StatusOK,
-			wantBody: "密码必须包含字母、数字、特殊字符，并且不少于八位",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":100002,"msg":"参数错误","data":[{"field":"password","msg":"password必须包含字母、数字、特殊字符，并且不少于八位"}]}`,
+		},
+
+		{
+			name: "英文的错误信息",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.CodeService) {
+				userSvc := svcmocks.NewMockUserService(ctrl)
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				return userSvc, codeSvc
+			},
+			reqBuilder: func(t *testing.T) *http.Request {
+				req, err := http.NewRequest(http.MethodPost,
+					"/users/signup", bytes.NewReader([]byte(`{
+"email": "123",
+"password": "hello#world123",
+"confirmPassword": "hello#world123"
+}`)))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Accept-Language", "en-US,en;q=0.9,zh-CN;q=0.8")
+				assert.NoError(t, err)
+				return req
+			},
+
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":100002,"msg":"Invalid parameters","data":[{"field":"email","msg":"email must be a valid email address"}]}`,
 		},
 
 		{
@@ -168,8 +205,8 @@ func TestUserHandler_SignUp(t *testing.T) {
 				return req
 			},
 
-			wantCode: http.StatusOK,
-			wantBody: "系统错误",
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"code":100001,"msg":"系统错误","data":null}`,
 		},
 
 		{
@@ -195,8 +232,8 @@ func TestUserHandler_SignUp(t *testing.T) {
 				return req
 			},
 
-			wantCode: http.StatusOK,
-			wantBody: "邮箱冲突，请换一个",
+			wantCode: http.StatusConflict,
+			wantBody: `{"code":201004,"msg":"邮箱冲突，请换一个","data":null}`,
 		},
 	}
 
@@ -207,10 +244,12 @@ func TestUserHandler_SignUp(t *testing.T) {
 
 			// 构造 handler
 			userSvc, codeSvc := tc.mock(ctrl)
-			hdl := NewUserHandler(userSvc, codeSvc)
+			hdl := NewUserHandler(userSvc, nil, codeSvc, phonex.NewParser("CN"), nil, nil)
 
 			// 准备服务器，注册路由
 			server := gin.Default()
+			server.ContextWithFallback = true
+			server.Use(middleware.NewLocaleMiddlewareBuilder(nil).Build())
 			hdl.RegisterRoutes(server)
 
 			// 准备Req和记录的 recorder
@@ -222,42 +261,370 @@ func TestUserHandler_SignUp(t *testing.T) {
 
 			// 断言结果
 			assert.Equal(t, tc.wantCode, recorder.Code)
-			assert.Equal(t, tc.wantBody, recorder.Body.String())
+			if tc.wantBody != "" {
+				assert.JSONEq(t, tc.wantBody, recorder.Body.String())
+			}
 		})
 	}
 }
 
-func TestEmailPattern(t *testing.T) {
+func TestUserHandler_SendSMSLoginCode(t *testing.T) {
 	testCases := []struct {
 		name  string
-		email string
-		match bool
+		mock  func(ctrl *gomock.Controller) (service.CodeService, captcha.Verifier)
+		phone string
+
+		wantCode int
+		wantBody Result
 	}{
 		{
-			name:  "不带@",
-			email: "123456",
-			match: false,
+			name: "不带国家码",
+			mock: func(ctrl *gomock.Controller) (service.CodeService, captcha.Verifier) {
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Send(gomock.Any(), bizLogin, "+8613812345678", gomock.Any()).Return(nil)
+				return codeSvc, passedCaptcha(ctrl)
+			},
+			phone:    "13812345678",
+			wantCode: http.StatusOK,
+			wantBody: Result{Msg: "发送成功"},
 		},
 		{
-			name:  "带@ 但是没后缀",
-			email: "123456@",
-			match: false,
+			name: "带国家码和空格",
+			mock: func(ctrl *gomock.Controller) (service.CodeService, captcha.Verifier) {
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Send(gomock.Any(), bizLogin, "+8613812345678", gomock.Any()).Return(nil)
+				return codeSvc, passedCaptcha(ctrl)
+			},
+			phone:    "+86 138 1234 5678",
+			wantCode: http.StatusOK,
+			wantBody: Result{Msg: "发送成功"},
 		},
 		{
-			// 巴拉巴拉
-			name:  "合法邮箱",
-			email: "123456@qq.com",
-			match: true,
+			name: "号码不合法",
+			mock: func(ctrl *gomock.Controller) (service.CodeService, captcha.Verifier) {
+				return svcmocks.NewMockCodeService(ctrl), captchamocks.NewMockVerifier(ctrl)
+			},
+			phone:    "1381234",
+			wantCode: http.StatusBadRequest,
+			wantBody: Result{Code: 100002, Msg: "参数错误", Data: []any{
+				map[string]any{"field": "phone", "msg": "phone不是合法的手机号码"},
+			}},
+		},
+		{
+			name: "图形验证码不对",
+			mock: func(ctrl *gomock.Controller) (service.CodeService, captcha.Verifier) {
+				verifier := captchamocks.NewMockVerifier(ctrl)
+				verifier.EXPECT().Verify(gomock.Any(), "captcha-id", "42").Return(false, nil)
+				return svcmocks.NewMockCodeService(ctrl), verifier
+			},
+			phone:    "13812345678",
+			wantCode: http.StatusBadRequest,
+			wantBody: Result{Code: 203001, Msg: "图形验证码不对，请重新输入"},
+		},
+		{
+			name: "发送额度用完",
+			mock: func(ctrl *gomock.Controller) (service.CodeService, captcha.Verifier) {
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Send(gomock.Any(), bizLogin, "+8613812345678", gomock.Any()).
+					Return(service.ErrCodeQuotaExhaust)
+				return codeSvc, passedCaptcha(ctrl)
+			},
+			phone:    "13812345678",
+			wantCode: http.StatusTooManyRequests,
+			wantBody: Result{Code: 202003, Msg: "今天发送验证码的次数太多了，请明天再试"},
+		},
+		{
+			name: "短信服务限流",
+			mock: func(ctrl *gomock.Controller) (service.CodeService, captcha.Verifier) {
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Send(gomock.Any(), bizLogin, "+8613812345678", gomock.Any()).
+					Return(&ratelimit.NumberLimitError{Results: map[string]bool{"+8613812345678": true}})
+				return codeSvc, passedCaptcha(ctrl)
+			},
+			phone:    "13812345678",
+			wantCode: http.StatusTooManyRequests,
+			wantBody: Result{Code: 202002, Msg: "短信发送太频繁，请稍后再试"},
 		},
 	}
 
-	h := NewUserHandler(nil, nil)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			codeSvc, verifier := tc.mock(ctrl)
+			hdl := NewUserHandler(svcmocks.NewMockUserService(ctrl), nil,
+				codeSvc, phonex.NewParser("CN"), nil, verifier)
+			server := gin.Default()
+			hdl.RegisterRoutes(server)
+
+			body, err := json.Marshal(map[string]string{
+				"phone":     tc.phone,
+				"captchaId": "captcha-id",
+				"captcha":   "42",
+			})
+			require.NoError(t, err)
+			req, err := http.NewRequest(http.MethodPost,
+				"/users/login_sms/code/send", bytes.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			var res Result
+			err = json.NewDecoder(recorder.Body).Decode(&res)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantBody, res)
+		})
+	}
+}
+
+func TestUserHandler_LoginJWT(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (service.UserService, captcha.Service, captcha.Verifier)
+
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "密码错误，记录失败次数",
+			mock: func(ctrl *gomock.Controller) (service.UserService, captcha.Service, captcha.Verifier) {
+				userSvc := svcmocks.NewMockUserService(ctrl)
+				userSvc.EXPECT().Login(gomock.Any(), "123@qq.com", "hello#world123").
+					Return(domain.User{}, service.ErrInvalidUserOrPassword)
+				captchaSvc := captchamocks.NewMockService(ctrl)
+				captchaSvc.EXPECT().Required(gomock.Any(), bizLogin, "123@qq.com").Return(false, nil)
+				captchaSvc.EXPECT().RecordFailure(gomock.Any(), bizLogin, "123@qq.com").Return(nil)
+				return userSvc, captchaSvc, captchamocks.NewMockVerifier(ctrl)
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":201005,"msg":"用户名或者密码不对","data":null}`,
+		},
+		{
+			name: "失败太多次，图形验证码不对",
+			mock: func(ctrl *gomock.Controller) (service.UserService, captcha.Service, captcha.Verifier) {
+				captchaSvc := captchamocks.NewMockService(ctrl)
+				captchaSvc.EXPECT().Required(gomock.Any(), bizLogin, "123@qq.com").Return(true, nil)
+				verifier := captchamocks.NewMockVerifier(ctrl)
+				verifier.EXPECT().Verify(gomock.Any(), "captcha-id", "42").Return(false, nil)
+				return svcmocks.NewMockUserService(ctrl), captchaSvc, verifier
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":203001,"msg":"图形验证码不对，请重新输入","data":null}`,
+		},
+		{
+			name: "失败太多次，通过图形验证码之后密码还是不对",
+			mock: func(ctrl *gomock.Controller) (service.UserService, captcha.Service, captcha.Verifier) {
+				userSvc := svcmocks.NewMockUserService(ctrl)
+				userSvc.EXPECT().Login(gomock.Any(), "123@qq.com", "hello#world123").
+					Return(domain.User{}, service.ErrInvalidUserOrPassword)
+				captchaSvc := captchamocks.NewMockService(ctrl)
+				captchaSvc.EXPECT().Required(gomock.Any(), bizLogin, "123@qq.com").Return(true, nil)
+				captchaSvc.EXPECT().RecordFailure(gomock.Any(), bizLogin, "123@qq.com").Return(nil)
+				return userSvc, captchaSvc, passedCaptcha(ctrl)
+			},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":201005,"msg":"用户名或者密码不对","data":null}`,
+		},
+	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			match, err := h.emailRexExp.MatchString(tc.email)
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			userSvc, captchaSvc, verifier := tc.mock(ctrl)
+			hdl := NewUserHandler(userSvc, nil, svcmocks.NewMockCodeService(ctrl),
+				phonex.NewParser("CN"), captchaSvc, verifier)
+			server := gin.Default()
+			hdl.RegisterRoutes(server)
+
+			req, err := http.NewRequest(http.MethodPost,
+				"/users/login", bytes.NewReader([]byte(`{
+"email": "123@qq.com",
+"password": "hello#world123",
+"captchaId": "captcha-id",
+"captcha": "42"
+}`)))
 			require.NoError(t, err)
-			assert.Equal(t, tc.match, match)
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.JSONEq(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func passedCaptcha(ctrl *gomock.Controller) captcha.Verifier {
+	verifier := captchamocks.NewMockVerifier(ctrl)
+	verifier.EXPECT().Verify(gomock.Any(), "captcha-id", "42").Return(true, nil)
+	return verifier
+}
+
+func TestUserHandler_RefreshToken(t *testing.T) {
+	validToken := func(t *testing.T) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS512, ijwt.RefreshClaims{
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+			Uid:              123,
+			Ssid:             "ssid",
+		})
+		tokenStr, err := token.SignedString(ijwt.RCJWTKey)
+		require.NoError(t, err)
+		return tokenStr
+	}
+	testCases := []struct {
+		name   string
+		mock   func(t *testing.T, ctrl *gomock.Controller) ijwt.Handler
+		legacy bool
+
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "刷新成功",
+			mock: func(t *testing.T, ctrl *gomock.Controller) ijwt.Handler {
+				hdl := jwtmocks.NewMockHandler(ctrl)
+				hdl.EXPECT().ExtractToken(gomock.Any()).Return(validToken(t))
+				hdl.EXPECT().CheckSession(gomock.Any(), "ssid").Return(nil)
+				hdl.EXPECT().SetJWTToken(gomock.Any(), int64(123), "ssid").Return(nil)
+				return hdl
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"OK","data":null}`,
+		},
+		{
+			name: "token 不对",
+			mock: func(t *testing.T, ctrl *gomock.Controller) ijwt.Handler {
+				hdl := jwtmocks.NewMockHandler(ctrl)
+				hdl.EXPECT().ExtractToken(gomock.Any()).Return("bad-token")
+				return hdl
+			},
+			wantCode: http.StatusUnauthorized,
+			wantBody: `{"code":100003,"msg":"请先登录","data":null}`,
+		},
+		{
+			name: "已经退出登录，兼容老客户端",
+			mock: func(t *testing.T, ctrl *gomock.Controller) ijwt.Handler {
+				hdl := jwtmocks.NewMockHandler(ctrl)
+				hdl.EXPECT().ExtractToken(gomock.Any()).Return(validToken(t))
+				hdl.EXPECT().CheckSession(gomock.Any(), "ssid").Return(errors.New("已经退出登录"))
+				return hdl
+			},
+			legacy:   true,
+			wantCode: http.StatusUnauthorized,
+			wantBody: `{"code":4,"msg":"请先登录","data":null}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			SetLegacyResult(tc.legacy)
+			defer SetLegacyResult(false)
+			hdl := NewUserHandler(svcmocks.NewMockUserService(ctrl), tc.mock(t, ctrl),
+				svcmocks.NewMockCodeService(ctrl), phonex.NewParser("CN"), nil, nil)
+			server := gin.New()
+			hdl.RegisterRoutes(server)
+
+			req := httptest.NewRequest(http.MethodGet, "/users/refresh_token", nil)
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.JSONEq(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestSignUpReq_Validate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		email    string
+		password string
+		match    bool
+	}{
+		{
+			name:     "不带@",
+			email:    "123456",
+			password: "hello#world123",
+			match:    false,
+		},
+		{
+			name:     "带@ 但是没后缀",
+			email:    "123456@",
+			password: "hello#world123",
+			match:    false,
+		},
+		{
+			name:     "密码没有特殊字符",
+			email:    "123456@qq.com",
+			password: "helloworld123",
+			match:    false,
+		},
+		{
+			name:     "密码太短",
+			email:    "123456@qq.com",
+			password: "he#12",
+			match:    false,
+		},
+		{
+			// 巴拉巴拉
+			name:     "合法",
+			email:    "123456@qq.com",
+			password: "hello#world123",
+			match:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := binding.Validator.ValidateStruct(SignUpReq{
+				Email:           tc.email,
+				Password:        tc.password,
+				ConfirmPassword: tc.password,
+			})
+			assert.Equal(t, tc.match, err == nil)
+		})
+	}
+}
+
+func TestEditReq_Validate(t *testing.T) {
+	testCases := []struct {
+		name  string
+		req   EditReq
+		match bool
+	}{
+		{
+			name:  "合法",
+			req:   EditReq{Nickname: "大明", Birthday: "2000-01-01", AboutMe: "hello"},
+			match: true,
+		},
+		{
+			// 按照字符算，不是按照字节
+			name:  "昵称刚好 128 个字",
+			req:   EditReq{Nickname: strings.Repeat("明", 128), Birthday: "2000-01-01"},
+			match: true,
+		},
+		{
+			name: "昵称太长",
+			req:  EditReq{Nickname: strings.Repeat("明", 129), Birthday: "2000-01-01"},
+		},
+		{
+			name: "简介太长",
+			req:  EditReq{Birthday: "2000-01-01", AboutMe: strings.Repeat("a", 4097)},
+		},
+		{
+			name: "生日格式不对",
+			req:  EditReq{Birthday: "2000/01/01"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := binding.Validator.ValidateStruct(tc.req)
+			assert.Equal(t, tc.match, err == nil)
 		})
 	}
 }
@@ -0,0 +1,37 @@
+package web
+
+import (
+	"errors"
+	regexp "github.com/dlclark/regexp2"
+	"github.com/go-playground/validator/v10"
+	"webBook/pkg/ginx"
+	"webBook/pkg/phonex"
+)
+
+// 标准库的 regexp 不支持 (?=，所以用 regexp2
+const passwordRegexPattern = `^(?=.*[A-Za-z])(?=.*\d)(?=.*[$@$!%*#?&])[A-Za-z\d$@$!%*#?&]{8,}$`
+
+var passwordRegExp = regexp.MustCompile(passwordRegexPattern, regexp.None)
+
+// RegisterValidators 注册请求结构体里面 binding 标签用到的自定义规则：
+// password 密码强度，phone 手机号码格式。改的是 gin 全局的 validator，启动的时候调用
+func RegisterValidators(phoneParser *phonex.Parser) error {
+	err := ginx.RegisterValidation("password", func(fl validator.FieldLevel) bool {
+		ok, err := passwordRegExp.MatchString(fl.Field().String())
+		return err == nil && ok
+	}, map[string]string{
+		"zh": "{0}必须包含字母、数字、特殊字符，并且不少于八位",
+		"en": "{0} must contain letters, digits and special characters, and be at least 8 characters long",
+	})
+	if err != nil {
+		return err
+	}
+	return ginx.RegisterValidation("phone", func(fl validator.FieldLevel) bool {
+		// 不支持的地区交给 Normalize，返回专门的错误码
+		_, err := phoneParser.Normalize(fl.Field().String())
+		return !errors.Is(err, phonex.ErrInvalidPhone)
+	}, map[string]string{
+		"zh": "{0}不是合法的手机号码",
+		"en": "{0} must be a valid phone number",
+	})
+}
@@ -1,31 +1,35 @@
 package web
 
 import (
-	"fmt"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-	uuid "github.com/lithammer/shortuuid/v4"
 	"net/http"
+	"webBook/internal/errs"
 	"webBook/internal/service"
+	"webBook/internal/service/oauth2"
 	"webBook/internal/service/oauth2/wechat"
 	ijwt "webBook/internal/web/jwt"
+	"webBook/pkg/ginx/openapi"
 )
 
+const providerWechat = "wechat"
+
 type OAuth2WechatHandler struct {
 	svc     wechat.Service
 	userSvc service.UserService
 	ijwt.Handler
-	key             []byte
-	stateCookieName string
+	state oauth2State
 }
 
-func NewOAuth2WechatHandler(svc wechat.Service, hdl ijwt.Handler, userSvc service.UserService) *OAuth2WechatHandler {
+func NewOAuth2WechatHandler(svc wechat.Service, hdl ijwt.Handler, userSvc service.UserService,
+	stateSvc oauth2.StateService, cookieCfg StateCookieConfig) *OAuth2WechatHandler {
 	return &OAuth2WechatHandler{
-		svc:             svc,
-		userSvc:         userSvc,
-		key:             []byte("k6CswdUm77WKcbM68UQUuxVsHSpTCwgB"),
-		stateCookieName: "jwt-state",
-		Handler:         hdl,
+		svc:     svc,
+		userSvc: userSvc,
+		Handler: hdl,
+		state: oauth2State{
+			svc: stateSvc,
+			cfg: cookieCfg,
+		},
 	}
 }
 
@@ -35,105 +39,44 @@ func (o *OAuth2WechatHandler) RegisterRoutes(server *gin.Engine) {
 	g.Any("/callback", o.Callback)
 }
 
-func (o *OAuth2WechatHandler) Auth2URL(ctx *gin.Context) {
-	state := uuid.New()
-	val, err := o.svc.AuthURL(ctx, state)
-	if err != nil {
-		ctx.JSON(http.StatusOK, Result{
-			Msg:  "构造跳转URL失败",
-			Code: 5,
-		})
-		return
+func (o *OAuth2WechatHandler) Docs() []openapi.Route {
+	const tag = "oauth2"
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/oauth2/wechat/authurl", Tag: tag,
+			Summary: "微信扫码登录的地址", Query: OAuth2AuthURLReq{}, Resp: ""},
+		{Method: openapi.MethodAny, Path: "/oauth2/wechat/callback", Tag: tag,
+			Summary: "微信回调，有 return_url 的时候登录之后跳回去", Query: OAuth2CallbackReq{}},
 	}
-	err = o.setStateCookie(ctx, state)
-	if err != nil {
-		ctx.JSON(http.StatusOK, Result{
-			Msg:  "服务器异常",
-			Code: 5,
-		})
-	}
-	ctx.JSON(http.StatusOK, Result{
-		Data: val,
+}
+
+func (o *OAuth2WechatHandler) Auth2URL(ctx *gin.Context) {
+	o.state.authURL(ctx, providerWechat, func(state string) (string, error) {
+		return o.svc.AuthURL(ctx, state)
 	})
 }
 
 func (o *OAuth2WechatHandler) Callback(ctx *gin.Context) {
-	err := o.verifyState(ctx)
+	returnURL, err := o.state.verify(ctx, providerWechat)
 	if err != nil {
-		ctx.JSON(http.StatusOK, Result{
-			Msg:  "非法请求",
-			Code: 4,
-		})
+		// cookie 不对、state 过期都是非法请求
+		writeError(ctx, errs.OAuth2InvalidState)
 		return
 	}
-	// 你校验不校验都可以
 	code := ctx.Query("code")
-	// state := ctx.Query("state")
 	wechatInfo, err := o.svc.VerifyCode(ctx, code)
 	if err != nil {
-		ctx.JSON(http.StatusOK, Result{
-			Msg:  "授权码有误",
-			Code: 4,
-		})
+		writeError(ctx, errs.OAuth2InvalidCode)
 		return
 	}
 	u, err := o.userSvc.FindOrCreateByWechat(ctx, wechatInfo)
 	if err != nil {
-		ctx.JSON(http.StatusOK, Result{
-			Msg:  "系统错误",
-			Code: 5,
-		})
+		writeError(ctx, err)
 		return
 	}
 	err = o.SetLoginToken(ctx, u.Id)
 	if err != nil {
-		ctx.String(http.StatusOK, "系统错误")
+		writeError(ctx, err)
 		return
 	}
-	ctx.JSON(http.StatusOK, Result{
-		Msg: "OK",
-	})
-	return
-}
-
-func (o *OAuth2WechatHandler) verifyState(ctx *gin.Context) error {
-	state := ctx.Query("state")
-	ck, err := ctx.Cookie(o.stateCookieName)
-	if err != nil {
-		return fmt.Errorf("无法获得 cookie %w", err)
-	}
-	var sc StateClaims
-	_, err = jwt.ParseWithClaims(ck, &sc, func(token *jwt.Token) (interface{}, error) {
-		return o.key, nil
-	})
-	if err != nil {
-		return fmt.Errorf("解析 token 失败 %w", err)
-	}
-	if state != sc.State {
-		// state 不匹配，有人搞你
-		return fmt.Errorf("state 不匹配")
-	}
-	return nil
-}
-
-func (o *OAuth2WechatHandler) setStateCookie(ctx *gin.Context,
-	state string) error {
-	claims := StateClaims{
-		State: state,
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS512, claims)
-	tokenStr, err := token.SignedString(o.key)
-	if err != nil {
-
-		return err
-	}
-	ctx.SetCookie(o.stateCookieName, tokenStr,
-		600, "/oauth2/wechat/callback",
-		"", false, true)
-	return nil
-}
-
-type StateClaims struct {
-	jwt.RegisteredClaims
-	State string
+	o.state.loginSuccess(ctx, returnURL)
 }
@@ -0,0 +1,132 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"webBook/internal/domain"
+	"webBook/internal/errs"
+	"webBook/internal/i18n"
+	"webBook/internal/service"
+	"webBook/internal/service/oauth2/wechat"
+	ijwt "webBook/internal/web/jwt"
+	"webBook/pkg/ginx/openapi"
+	"webBook/pkg/logger"
+	"webBook/pkg/phonex"
+)
+
+// MiniProgramHandler 小程序登录
+// 前端先调用 wx.login 拿到 code 换 session ID，再带着 session ID 登录，
+// 用户授权了手机号码的话带上 getPhoneNumber 的加密数据，按照手机号码登录
+type MiniProgramHandler struct {
+	svc         wechat.MiniProgramService
+	userSvc     service.UserService
+	phoneParser *phonex.Parser
+	ijwt.Handler
+}
+
+func NewMiniProgramHandler(svc wechat.MiniProgramService, hdl ijwt.Handler,
+	userSvc service.UserService, phoneParser *phonex.Parser) *MiniProgramHandler {
+	return &MiniProgramHandler{
+		svc:         svc,
+		userSvc:     userSvc,
+		phoneParser: phoneParser,
+		Handler:     hdl,
+	}
+}
+
+func (h *MiniProgramHandler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/oauth2/wechat/mini")
+	g.POST("/session", h.Session)
+	g.POST("/login", h.Login)
+}
+
+func (h *MiniProgramHandler) Docs() []openapi.Route {
+	const tag = "oauth2"
+	return []openapi.Route{
+		{Method: http.MethodPost, Path: "/oauth2/wechat/mini/session", Tag: tag,
+			Summary: "小程序 code 换 sessionId", Body: MiniSessionReq{}, Resp: ""},
+		{Method: http.MethodPost, Path: "/oauth2/wechat/mini/login", Tag: tag,
+			Summary: "小程序登录，带上手机号的加密数据就按照手机号登录", Body: MiniLoginReq{}},
+	}
+}
+
+type MiniSessionReq struct {
+	// Code wx.login 拿到的 code
+	Code string `json:"code"`
+}
+
+func (h *MiniProgramHandler) Session(ctx *gin.Context) {
+	var req MiniSessionReq
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	if req.Code == "" {
+		writeError(ctx, errs.OAuth2InvalidCode)
+		return
+	}
+	sessionId, err := h.svc.Code2Session(ctx, req.Code)
+	if err != nil {
+		logger.FromContext(ctx).Warn("小程序 code2session 失败", logger.Field{Key: "err", Val: err})
+		writeError(ctx, errs.OAuth2InvalidCode)
+		return
+	}
+	writeOK(ctx, "", sessionId)
+}
+
+type MiniLoginReq struct {
+	SessionId string `json:"sessionId"`
+	// getPhoneNumber 的加密数据，没有的话按照微信身份登录
+	EncryptedData string `json:"encryptedData"`
+	Iv            string `json:"iv"`
+}
+
+func (h *MiniProgramHandler) Login(ctx *gin.Context) {
+	var req MiniLoginReq
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	var (
+		u   domain.User
+		err error
+	)
+	if req.EncryptedData != "" {
+		u, err = h.loginByPhone(ctx, req.SessionId, req.EncryptedData, req.Iv)
+	} else {
+		u, err = h.loginByWechat(ctx, req.SessionId)
+	}
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	err = h.SetLoginToken(ctx, u.Id)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	writeOK(ctx, i18n.MsgLoginOK, nil)
+}
+
+func (h *MiniProgramHandler) loginByWechat(ctx *gin.Context, sessionId string) (domain.User, error) {
+	info, err := h.svc.Info(ctx, sessionId)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return h.userSvc.FindOrCreateByWechat(ctx, info)
+}
+
+func (h *MiniProgramHandler) loginByPhone(ctx *gin.Context, sessionId, encryptedData, iv string) (domain.User, error) {
+	phone, err := h.svc.DecryptPhone(ctx, sessionId, encryptedData, iv)
+	if err != nil {
+		return domain.User{}, err
+	}
+	phone, err = h.phoneParser.Normalize(phone)
+	if err != nil {
+		return domain.User{}, err
+	}
+	// 带上微信身份，手机号登录和微信登录是同一个账号
+	info, err := h.svc.Info(ctx, sessionId)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return h.userSvc.FindOrCreateByPhoneAndWechat(ctx, phone, info)
+}
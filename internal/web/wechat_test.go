@@ -0,0 +1,175 @@
+package web
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"webBook/internal/domain"
+	"webBook/internal/service"
+	svcmocks "webBook/internal/service/mocks"
+	"webBook/internal/service/oauth2"
+	oauth2mocks "webBook/internal/service/oauth2/mocks"
+	"webBook/internal/service/oauth2/wechat"
+	wechatmocks "webBook/internal/service/oauth2/wechat/mocks"
+	ijwt "webBook/internal/web/jwt"
+	jwtmocks "webBook/internal/web/jwt/mocks"
+)
+
+var testCookieCfg = StateCookieConfig{
+	Name:   "oauth2-state",
+	Secure: true,
+	MaxAge: 600,
+}
+
+func TestOAuth2WechatHandler_Auth2URL(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (wechat.Service, oauth2.StateService)
+		url  string
+
+		wantCode   int
+		wantBody   string
+		wantCookie bool
+	}{
+		{
+			name: "成功",
+			mock: func(ctrl *gomock.Controller) (wechat.Service, oauth2.StateService) {
+				svc := wechatmocks.NewMockService(ctrl)
+				stateSvc := oauth2mocks.NewMockStateService(ctrl)
+				stateSvc.EXPECT().Create(gomock.Any(), "wechat", "/profile").Return("my-state", nil)
+				svc.EXPECT().AuthURL(gomock.Any(), "my-state").Return("https://open.weixin.qq.com", nil)
+				return svc, stateSvc
+			},
+			url:        "/oauth2/wechat/authurl?return_url=/profile",
+			wantCode:   http.StatusOK,
+			wantBody:   `{"code":0,"msg":"","data":"https://open.weixin.qq.com"}`,
+			wantCookie: true,
+		},
+		{
+			name: "跳转地址不在白名单",
+			mock: func(ctrl *gomock.Controller) (wechat.Service, oauth2.StateService) {
+				stateSvc := oauth2mocks.NewMockStateService(ctrl)
+				stateSvc.EXPECT().Create(gomock.Any(), "wechat", "https://evil.com").
+					Return("", oauth2.ErrReturnURLNotAllowed)
+				return wechatmocks.NewMockService(ctrl), stateSvc
+			},
+			url:      "/oauth2/wechat/authurl?return_url=https://evil.com",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":204004,"msg":"跳转地址不合法","data":null}`,
+		},
+		{
+			name: "保存 state 失败只返回一个响应",
+			mock: func(ctrl *gomock.Controller) (wechat.Service, oauth2.StateService) {
+				stateSvc := oauth2mocks.NewMockStateService(ctrl)
+				stateSvc.EXPECT().Create(gomock.Any(), "wechat", "").
+					Return("", errors.New("redis错误"))
+				return wechatmocks.NewMockService(ctrl), stateSvc
+			},
+			url:      "/oauth2/wechat/authurl",
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"code":100001,"msg":"系统错误","data":null}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc, stateSvc := tc.mock(ctrl)
+			server := gin.New()
+			NewOAuth2WechatHandler(svc, nil, nil, stateSvc, testCookieCfg).RegisterRoutes(server)
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, req)
+			assert.Equal(t, tc.wantCode, resp.Code)
+			assert.JSONEq(t, tc.wantBody, resp.Body.String())
+			cookies := resp.Result().Cookies()
+			if !tc.wantCookie {
+				assert.Empty(t, cookies)
+				return
+			}
+			require.Len(t, cookies, 1)
+			ck := cookies[0]
+			assert.Equal(t, "my-state", ck.Value)
+			assert.Equal(t, "/oauth2/wechat/callback", ck.Path)
+			assert.True(t, ck.Secure)
+			assert.True(t, ck.HttpOnly)
+			assert.Equal(t, http.SameSiteLaxMode, ck.SameSite)
+		})
+	}
+}
+
+func TestOAuth2WechatHandler_Callback(t *testing.T) {
+	testCases := []struct {
+		name   string
+		mock   func(ctrl *gomock.Controller) (wechat.Service, oauth2.StateService, service.UserService, ijwt.Handler)
+		cookie string
+
+		wantCode     int
+		wantBody     string
+		wantLocation string
+	}{
+		{
+			name: "登录成功，跳回去",
+			mock: func(ctrl *gomock.Controller) (wechat.Service, oauth2.StateService, service.UserService, ijwt.Handler) {
+				svc := wechatmocks.NewMockService(ctrl)
+				stateSvc := oauth2mocks.NewMockStateService(ctrl)
+				userSvc := svcmocks.NewMockUserService(ctrl)
+				hdl := jwtmocks.NewMockHandler(ctrl)
+				stateSvc.EXPECT().Take(gomock.Any(), "wechat", "my-state").Return("/profile", nil)
+				svc.EXPECT().VerifyCode(gomock.Any(), "code").Return(domain.WechatInfo{OpenId: "openid"}, nil)
+				userSvc.EXPECT().FindOrCreateByWechat(gomock.Any(), domain.WechatInfo{OpenId: "openid"}).
+					Return(domain.User{Id: 1}, nil)
+				hdl.EXPECT().SetLoginToken(gomock.Any(), int64(1)).Return(nil)
+				return svc, stateSvc, userSvc, hdl
+			},
+			cookie:       "my-state",
+			wantCode:     http.StatusFound,
+			wantLocation: "/profile",
+		},
+		{
+			name: "cookie 和 state 不一致",
+			mock: func(ctrl *gomock.Controller) (wechat.Service, oauth2.StateService, service.UserService, ijwt.Handler) {
+				return wechatmocks.NewMockService(ctrl), oauth2mocks.NewMockStateService(ctrl),
+					svcmocks.NewMockUserService(ctrl), jwtmocks.NewMockHandler(ctrl)
+			},
+			cookie:   "another-state",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":204002,"msg":"非法请求","data":null}`,
+		},
+		{
+			name: "state 已经用过了",
+			mock: func(ctrl *gomock.Controller) (wechat.Service, oauth2.StateService, service.UserService, ijwt.Handler) {
+				stateSvc := oauth2mocks.NewMockStateService(ctrl)
+				stateSvc.EXPECT().Take(gomock.Any(), "wechat", "my-state").Return("", oauth2.ErrInvalidState)
+				return wechatmocks.NewMockService(ctrl), stateSvc,
+					svcmocks.NewMockUserService(ctrl), jwtmocks.NewMockHandler(ctrl)
+			},
+			cookie:   "my-state",
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":204002,"msg":"非法请求","data":null}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc, stateSvc, userSvc, hdl := tc.mock(ctrl)
+			server := gin.New()
+			NewOAuth2WechatHandler(svc, hdl, userSvc, stateSvc, testCookieCfg).RegisterRoutes(server)
+			req := httptest.NewRequest(http.MethodGet, "/oauth2/wechat/callback?code=code&state=my-state", nil)
+			req.AddCookie(&http.Cookie{Name: testCookieCfg.Name, Value: tc.cookie})
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, req)
+			assert.Equal(t, tc.wantCode, resp.Code)
+			if tc.wantBody != "" {
+				assert.JSONEq(t, tc.wantBody, resp.Body.String())
+			}
+			assert.Equal(t, tc.wantLocation, resp.Header().Get("Location"))
+		})
+	}
+}
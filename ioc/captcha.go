@@ -0,0 +1,41 @@
+package ioc
+
+import (
+	"github.com/spf13/viper"
+	"time"
+	"webBook/internal/repository"
+	"webBook/internal/service/captcha"
+)
+
+func InitCaptchaService(repo repository.CaptchaRepository) captcha.Service {
+	type Config struct {
+		Kind          string        `yaml:"kind"`
+		Length        int           `yaml:"length"`
+		Expiration    time.Duration `yaml:"expiration"`
+		MaxFailures   int64         `yaml:"maxFailures"`
+		FailureWindow time.Duration `yaml:"failureWindow"`
+	}
+	var cfg = Config{
+		Kind:          captcha.KindMath,
+		Length:        4,
+		Expiration:    time.Minute * 5,
+		MaxFailures:   3,
+		FailureWindow: time.Minute * 15,
+	}
+	err := viper.UnmarshalKey("captcha", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	return captcha.NewLocalService(repo, captcha.Config{
+		Kind:          cfg.Kind,
+		Length:        cfg.Length,
+		Expiration:    cfg.Expiration,
+		MaxFailures:   cfg.MaxFailures,
+		FailureWindow: cfg.FailureWindow,
+	})
+}
+
+// InitCaptchaVerifier 默认用本地的挑战来校验，接入第三方验证码的时候换掉这里就可以
+func InitCaptchaVerifier(svc captcha.Service) captcha.Verifier {
+	return svc
+}
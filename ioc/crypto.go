@@ -0,0 +1,25 @@
+package ioc
+
+import (
+	"github.com/spf13/viper"
+	"webBook/pkg/cryptox"
+)
+
+// InitTokenCipher 加密落库的第三方 token，key 必须是 16、24 或者 32 字节
+func InitTokenCipher() cryptox.Cipher {
+	type Config struct {
+		TokenKey string `yaml:"tokenKey"`
+	}
+	var cfg = Config{
+		TokenKey: "FqkeVd2Ka5jHFEG7FPtP6K7d9y3EXsUK",
+	}
+	err := viper.UnmarshalKey("crypto", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	c, err := cryptox.NewAESGCM([]byte(cfg.TokenKey))
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
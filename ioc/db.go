@@ -1,12 +1,15 @@
 package ioc
 
 import (
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/spf13/viper"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	glogger "gorm.io/gorm/logger"
 	"webBook/internal/repository/dao"
+	"webBook/pkg/gormx"
 	"webBook/pkg/logger"
+	"webBook/pkg/prometheusx"
 )
 
 func InitDB(l logger.LoggerV1) *gorm.DB {
@@ -21,7 +24,7 @@ func InitDB(l logger.LoggerV1) *gorm.DB {
 		panic(err)
 	}
 	db, err := gorm.Open(mysql.Open(cfg.DSN), &gorm.Config{
-		Logger: glogger.New(gormLoggerFunc(l.Debug), glogger.Config{
+		Logger: glogger.New(gormLoggerFunc(l.Named("gorm").Debug), glogger.Config{
 			SlowThreshold: 0,
 			LogLevel:      glogger.Info,
 		}),
@@ -29,6 +32,19 @@ func InitDB(l logger.LoggerV1) *gorm.DB {
 	if err != nil {
 		panic(err)
 	}
+	err = db.Use(gormx.NewTracingPlugin())
+	if err != nil {
+		panic(err)
+	}
+	err = db.Use(gormx.NewMetricsPlugin("webook", instanceID()))
+	if err != nil {
+		panic(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		panic(err)
+	}
+	prometheusx.MustRegister(collectors.NewDBStatsCollector(sqlDB, "webook"))
 	err = dao.InitTables(db)
 	if err != nil {
 		panic(err)
@@ -1,14 +1,155 @@
 package ioc
 
 import (
+	"github.com/spf13/viper"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"reflect"
+	"time"
 	"webBook/pkg/logger"
 )
 
+type logConfig struct {
+	// Level 默认的级别，Levels 是每个 logger 单独的级别，例如 gorm: warn
+	Level  string            `yaml:"level"`
+	Levels map[string]string `yaml:"levels"`
+	// Encoding json 或者 console
+	Encoding string `yaml:"encoding"`
+	// Output stdout、stderr 或者 file
+	Output string `yaml:"output"`
+	File   struct {
+		Filename string `yaml:"filename"`
+		// MaxSize 单个文件最大多少 MB
+		MaxSize int `yaml:"maxSize"`
+		// MaxAge 最多保留多少天，MaxBackups 最多保留多少个文件
+		MaxAge     int  `yaml:"maxAge"`
+		MaxBackups int  `yaml:"maxBackups"`
+		Compress   bool `yaml:"compress"`
+		// RotateInterval 按时间切分，例如 24h，0 就是只按大小切分
+		RotateInterval time.Duration `yaml:"rotateInterval"`
+	} `yaml:"file"`
+	// Sampling 每秒同样的日志前 Initial 条都打，之后每 Thereafter 条打一条，Initial 为 0 就是不采样
+	Sampling struct {
+		Initial    int `yaml:"initial"`
+		Thereafter int `yaml:"thereafter"`
+	} `yaml:"sampling"`
+	// AdminAddr 修改日志级别的管理接口，为空就不开启，不要暴露到公网
+	AdminAddr string `yaml:"adminAddr"`
+}
+
 func InitLogger() logger.LoggerV1 {
-	l, err := zap.NewDevelopment()
+	cfg, err := loadLogConfig()
+	if err != nil {
+		panic(err)
+	}
+	levels := logger.NewLevels(zapcore.InfoLevel)
+	err = levels.Set(cfg.Level, cfg.Levels)
 	if err != nil {
 		panic(err)
 	}
-	return logger.NewZapLogger(l)
+
+	var encoder zapcore.Encoder
+	switch cfg.Encoding {
+	case "console":
+		encoder = zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
+	default:
+		encoder = zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	}
+	// 级别交给 levelCore 判断
+	core := zapcore.NewCore(encoder, initLogWriter(cfg), zapcore.DebugLevel)
+	if cfg.Sampling.Initial > 0 {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, cfg.Sampling.Initial, cfg.Sampling.Thereafter)
+	}
+	l := zap.New(logger.NewLevelCore(core, levels), zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
+	res := logger.NewZapLogger(l)
+	// 没有经过请求 ID 中间件的 ctx 用这个
+	logger.SetDefault(res)
+
+	go watchLogLevels(levels, cfg)
+	if cfg.AdminAddr != "" {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/log/level", levels)
+			log.Println("日志管理接口退出", http.ListenAndServe(cfg.AdminAddr, mux))
+		}()
+	}
+	return res
+}
+
+func loadLogConfig() (logConfig, error) {
+	var cfg = logConfig{
+		Level:    "info",
+		Encoding: "json",
+		Output:   "stdout",
+	}
+	cfg.File.Filename = "logs/webook.log"
+	cfg.File.MaxSize = 100
+	cfg.File.MaxAge = 7
+	cfg.File.MaxBackups = 10
+	err := viper.UnmarshalKey("log", &cfg)
+	return cfg, err
+}
+
+func initLogWriter(cfg logConfig) zapcore.WriteSyncer {
+	var w io.Writer
+	switch cfg.Output {
+	case "stderr":
+		w = os.Stderr
+	case "file":
+		lj := &lumberjack.Logger{
+			Filename:   cfg.File.Filename,
+			MaxSize:    cfg.File.MaxSize,
+			MaxAge:     cfg.File.MaxAge,
+			MaxBackups: cfg.File.MaxBackups,
+			Compress:   cfg.File.Compress,
+			LocalTime:  true,
+		}
+		// lumberjack 只支持按大小切分
+		if cfg.File.RotateInterval > 0 {
+			go func() {
+				ticker := time.NewTicker(cfg.File.RotateInterval)
+				defer ticker.Stop()
+				for range ticker.C {
+					if err := lj.Rotate(); err != nil {
+						log.Println("切分日志文件失败", err)
+					}
+				}
+			}()
+		}
+		w = lj
+	default:
+		w = os.Stdout
+	}
+	return zapcore.AddSync(w)
+}
+
+// watchLogLevels 和限流规则一样轮询 viper，配置变了才覆盖，不会冲掉管理接口修改的级别
+func watchLogLevels(levels *logger.Levels, cfg logConfig) {
+	interval := viper.GetDuration("log.reloadInterval")
+	if interval <= 0 {
+		interval = time.Second * 10
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		newCfg, err := loadLogConfig()
+		if err != nil {
+			log.Println("读取日志配置失败", err)
+			continue
+		}
+		if newCfg.Level == cfg.Level && reflect.DeepEqual(newCfg.Levels, cfg.Levels) {
+			continue
+		}
+		if err = levels.Set(newCfg.Level, newCfg.Levels); err != nil {
+			log.Println("日志级别配置有问题，继续使用原本的级别", err)
+			continue
+		}
+		cfg = newCfg
+		log.Println("日志级别更新了", cfg.Level, cfg.Levels)
+	}
 }
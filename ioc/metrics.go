@@ -0,0 +1,18 @@
+package ioc
+
+import (
+	"github.com/spf13/viper"
+	"os"
+)
+
+// instanceID 区分同一个服务的不同实例，没有配置就用主机名
+func instanceID() string {
+	if id := viper.GetString("metrics.instanceID"); id != "" {
+		return id
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return host
+}
@@ -0,0 +1,137 @@
+package ioc
+
+import (
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
+	"net/http"
+	"time"
+	"webBook/internal/repository"
+	"webBook/internal/service/oauth2"
+	"webBook/internal/service/oauth2/server"
+	"webBook/internal/web"
+	ijwt "webBook/internal/web/jwt"
+)
+
+type oauth2StateConfig struct {
+	// state 的有效期，cookie 也是这个时间
+	Expiration time.Duration `yaml:"expiration"`
+	// 登录之后允许跳转的 origin，站内的相对路径总是允许的
+	ReturnURLs []string `yaml:"returnURLs"`
+	Cookie     struct {
+		Name   string `yaml:"name"`
+		Domain string `yaml:"domain"`
+		Secure bool   `yaml:"secure"`
+	} `yaml:"cookie"`
+}
+
+func loadOAuth2StateConfig() oauth2StateConfig {
+	var cfg = oauth2StateConfig{
+		Expiration: time.Minute * 10,
+	}
+	cfg.Cookie.Name = "oauth2-state"
+	cfg.Cookie.Secure = true
+	err := viper.UnmarshalKey("oauth2.state", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	return cfg
+}
+
+func InitOAuth2StateService(repo repository.OAuth2StateRepository) oauth2.StateService {
+	cfg := loadOAuth2StateConfig()
+	return oauth2.NewStateService(repo, cfg.Expiration, cfg.ReturnURLs)
+}
+
+func InitStateCookieConfig() web.StateCookieConfig {
+	cfg := loadOAuth2StateConfig()
+	return web.StateCookieConfig{
+		Name:   cfg.Cookie.Name,
+		Domain: cfg.Cookie.Domain,
+		Secure: cfg.Cookie.Secure,
+		MaxAge: int(cfg.Expiration / time.Second),
+	}
+}
+
+func InitOAuth2Providers() []oauth2.Provider {
+	type ProviderConfig struct {
+		// oidc 或者 oauth2
+		Kind         string   `yaml:"kind"`
+		Issuer       string   `yaml:"issuer"`
+		ClientID     string   `yaml:"clientId"`
+		ClientSecret string   `yaml:"clientSecret"`
+		RedirectURL  string   `yaml:"redirectURL"`
+		Scopes       []string `yaml:"scopes"`
+		// 下面这些只有 oauth2 才需要
+		AuthURL      string `yaml:"authURL"`
+		TokenURL     string `yaml:"tokenURL"`
+		UserInfoURL  string `yaml:"userInfoURL"`
+		SubjectField string `yaml:"subjectField"`
+		EmailField   string `yaml:"emailField"`
+		NameField    string `yaml:"nameField"`
+	}
+	var cfgs map[string]ProviderConfig
+	err := viper.UnmarshalKey("oauth2.providers", &cfgs)
+	if err != nil {
+		panic(err)
+	}
+	client := &http.Client{Timeout: time.Second * 10}
+	res := make([]oauth2.Provider, 0, len(cfgs))
+	for name, cfg := range cfgs {
+		switch cfg.Kind {
+		case "oidc":
+			res = append(res, oauth2.NewOIDCProvider(oauth2.OIDCConfig{
+				Name:         name,
+				Issuer:       cfg.Issuer,
+				ClientID:     cfg.ClientID,
+				ClientSecret: cfg.ClientSecret,
+				RedirectURL:  cfg.RedirectURL,
+				Scopes:       cfg.Scopes,
+			}, client))
+		case "oauth2":
+			res = append(res, oauth2.NewGenericProvider(oauth2.Config{
+				Name:         name,
+				ClientID:     cfg.ClientID,
+				ClientSecret: cfg.ClientSecret,
+				RedirectURL:  cfg.RedirectURL,
+				AuthURL:      cfg.AuthURL,
+				TokenURL:     cfg.TokenURL,
+				UserInfoURL:  cfg.UserInfoURL,
+				Scopes:       cfg.Scopes,
+				SubjectField: cfg.SubjectField,
+				EmailField:   cfg.EmailField,
+				NameField:    cfg.NameField,
+			}, client))
+		default:
+			panic(fmt.Sprintf("第三方登录 %s 的类型 %s 不支持", name, cfg.Kind))
+		}
+	}
+	return res
+}
+
+func InitOAuth2ServerService(clientRepo repository.OAuth2ClientRepository,
+	tokenRepo repository.OAuth2TokenRepository) server.Service {
+	type Config struct {
+		// 授权码只能用一次，有效期要短
+		CodeExpiration  time.Duration `yaml:"codeExpiration"`
+		TokenExpiration time.Duration `yaml:"tokenExpiration"`
+	}
+	var cfg = Config{
+		CodeExpiration:  time.Minute * 5,
+		TokenExpiration: time.Hour,
+	}
+	err := viper.UnmarshalKey("oauth2.server", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	return server.NewService(clientRepo, tokenRepo, cfg.CodeExpiration, cfg.TokenExpiration)
+}
+
+// InitJWTHandler 给第三方应用签发 access token 的 key 没有默认值，必须配置
+func InitJWTHandler(cmd redis.Cmdable) ijwt.Handler {
+	key := viper.GetString("oauth2.server.jwtKey")
+	if key == "" {
+		panic("没有配置授权服务器的签名 key oauth2.server.jwtKey")
+	}
+	return ijwt.NewRedisJWTHandler(cmd, []byte(key))
+}
@@ -0,0 +1,75 @@
+package ioc
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"time"
+)
+
+// InitOTEL 初始化全局的 TracerProvider，返回的函数在退出的时候调用，把没有上报的 span 刷出去。
+// 要在 InitWebServer 之前调用
+func InitOTEL() func(ctx context.Context) error {
+	type Config struct {
+		ServiceName string `yaml:"serviceName"`
+		// none 不上报，stdout 打印到控制台，otlp 用 HTTP 上报给 collector
+		Exporter string `yaml:"exporter"`
+		// otlp 的地址，例如 localhost:4318
+		Endpoint string `yaml:"endpoint"`
+		Insecure bool   `yaml:"insecure"`
+		// 采样比例，1 就是全部采样
+		SampleRatio float64 `yaml:"sampleRatio"`
+	}
+	var cfg = Config{
+		ServiceName: "webBook",
+		Exporter:    "none",
+		SampleRatio: 1,
+	}
+	err := viper.UnmarshalKey("otel", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+	res, err := resource.Merge(resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		panic(err)
+	}
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	}
+	switch cfg.Exporter {
+	case "none":
+		// 不上报也要有 TracerProvider，日志里面才有 trace ID
+	case "stdout":
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			panic(err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	case "otlp":
+		httpOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			httpOpts = append(httpOpts, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(context.Background(), httpOpts...)
+		if err != nil {
+			panic(err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter, sdktrace.WithBatchTimeout(time.Second)))
+	default:
+		panic(fmt.Sprintf("不支持的 trace exporter %s", cfg.Exporter))
+	}
+	tp := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown
+}
@@ -0,0 +1,23 @@
+package ioc
+
+import (
+	"github.com/spf13/viper"
+	"webBook/pkg/phonex"
+)
+
+func InitPhoneParser() *phonex.Parser {
+	type Config struct {
+		// 没有国家码的号码，按照这个地区解析
+		DefaultRegion string `yaml:"defaultRegion"`
+		// 支持的地区，为空就是不限制
+		Regions []string `yaml:"regions"`
+	}
+	var cfg = Config{
+		DefaultRegion: "CN",
+	}
+	err := viper.UnmarshalKey("phone", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	return phonex.NewParser(cfg.DefaultRegion, cfg.Regions...)
+}
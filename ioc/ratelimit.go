@@ -0,0 +1,171 @@
+package ioc
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	ijwt "webBook/internal/web/jwt"
+	"webBook/pkg/ginx"
+	"webBook/pkg/ginx/middleware/concurrency"
+	"webBook/pkg/ginx/middleware/ratelimit"
+	"webBook/pkg/limiter"
+	"webBook/pkg/phonex"
+	"webBook/pkg/prometheusx"
+)
+
+func initIPRateLimit(redisClient redis.Cmdable) gin.HandlerFunc {
+	type Config struct {
+		// 整个集群每秒允许每个 IP 访问多少次
+		Rate  int `yaml:"rate"`
+		Burst int `yaml:"burst"`
+		// Redis 出错的时候怎么办：closed 直接 500，open 不限流，local 退化成单机限流
+		OnError string `yaml:"onError"`
+		// 实例数量，单机限流的阈值是 Rate / Instances
+		Instances int `yaml:"instances"`
+		// 单机限流最多记录多少个 IP
+		LocalCapacity int `yaml:"localCapacity"`
+	}
+	var cfg = Config{
+		Rate:          1000,
+		Burst:         1000,
+		OnError:       "local",
+		Instances:     1,
+		LocalCapacity: 100000,
+	}
+	err := viper.UnmarshalKey("ratelimit", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	// 令牌桶每个 IP 只占一个 hash，滑动窗口在这个 QPS 下 ZSET 太大了
+	builder := ratelimit.NewBuilder(limiter.NewMetricsLimiter("ip",
+		limiter.NewRedisTokenBucketLimiter(redisClient, time.Second, cfg.Rate, cfg.Burst)))
+	switch cfg.OnError {
+	case "closed":
+		builder.FailClosed()
+	case "open":
+		builder.FailOpen()
+	case "local":
+		instances := max(cfg.Instances, 1)
+		builder.FailLocal(limiter.NewMetricsLimiter("ip-local", limiter.NewLocalTokenBucketLimiter(time.Second,
+			max(cfg.Rate/instances, 1), max(cfg.Burst/instances, 1), cfg.LocalCapacity)))
+	default:
+		panic(fmt.Sprintf("限流的 onError 不支持 %s", cfg.OnError))
+	}
+	return builder.Build()
+}
+
+// initRuleRateLimit 按照 ratelimit.rules 限流，要放在登录校验后面才能拿到 uid
+func initRuleRateLimit(redisClient redis.Cmdable, phoneParser *phonex.Parser) gin.HandlerFunc {
+	builder := ratelimit.NewRuleBuilder(func(interval time.Duration, rate int) limiter.QuotaLimiter {
+		return limiter.NewMetricsQuotaLimiter("rule", limiter.NewRedisFixedWindowLimiter(redisClient, interval, rate))
+	}).Dimension("uid", func(ctx *gin.Context, arg string) (string, bool) {
+		uc, ok := ctx.Get(ginx.ClaimsKey)
+		if !ok {
+			return "", false
+		}
+		claims, ok := uc.(ijwt.UserClaims)
+		if !ok {
+			return "", false
+		}
+		return strconv.FormatInt(claims.Uid, 10), true
+	}).Normalize("phone", func(val string) string {
+		phone, err := phoneParser.Normalize(val)
+		if err != nil {
+			// 不合法的号码后面的校验会拒绝，这里也要计数
+			return strings.TrimSpace(val)
+		}
+		return phone
+	}).Normalize("email", func(val string) string {
+		return strings.ToLower(strings.TrimSpace(val))
+	})
+	loadRules := func() []ratelimit.Rule {
+		var rules []ratelimit.Rule
+		err := viper.UnmarshalKey("ratelimit.rules", &rules)
+		if err != nil {
+			log.Println("读取限流规则失败", err)
+			return nil
+		}
+		return rules
+	}
+	rules := loadRules()
+	err := builder.Update(rules)
+	if err != nil {
+		panic(err)
+	}
+	// 不依赖 viper 的 OnConfigChange，本地文件和远程配置中心都能生效
+	go func() {
+		interval := viper.GetDuration("ratelimit.reloadInterval")
+		if interval <= 0 {
+			interval = time.Second * 10
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			newRules := loadRules()
+			if newRules == nil || reflect.DeepEqual(rules, newRules) {
+				continue
+			}
+			if err := builder.Update(newRules); err != nil {
+				log.Println("限流规则有问题，继续使用原本的规则", err)
+				continue
+			}
+			rules = newRules
+			log.Println("限流规则更新了", rules)
+		}
+	}()
+	return builder.Build()
+}
+
+func InitConcurrencyLimiter() *concurrency.GradientLimiter {
+	type Config struct {
+		// 初始的并发上限，之后根据延迟自动调整
+		Initial int `yaml:"initial"`
+		Min     int `yaml:"min"`
+		Max     int `yaml:"max"`
+	}
+	var cfg = Config{
+		Initial: 100,
+		Min:     10,
+		Max:     1000,
+	}
+	err := viper.UnmarshalKey("concurrency", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	l := concurrency.NewGradientLimiter(cfg.Initial, cfg.Min, cfg.Max)
+	registerConcurrencyMetrics(l)
+	return l
+}
+
+// registerConcurrencyMetrics 采集的时候才读取 Stats，不需要在请求路径上埋点
+func registerConcurrencyMetrics(l *concurrency.GradientLimiter) {
+	opts := func(name, help string) prometheus.Opts {
+		return prometheus.Opts{
+			Namespace: "webook",
+			Subsystem: "concurrency",
+			Name:      name,
+			Help:      help,
+		}
+	}
+	prometheusx.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts(opts("limit", "当前的并发上限")),
+		func() float64 { return float64(l.Stats().Limit) }))
+	prometheusx.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts(opts("in_flight", "正在处理的请求数量")),
+		func() float64 { return float64(l.Stats().InFlight) }))
+	prometheusx.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts(opts("rejected_total", "被拒绝的请求数量")),
+		func() float64 { return float64(l.Stats().Rejected) }))
+}
+
+func initConcurrencyLimit(l *concurrency.GradientLimiter) gin.HandlerFunc {
+	// 负载高的时候先保住登录和刷新 token，不然用户会被踢下线
+	return concurrency.NewBuilder(l).
+		Priority(concurrency.PriorityCritical,
+			"/users/login", "/users/login_sms", "/users/refresh_token").
+		Build()
+}
@@ -3,10 +3,13 @@ package ioc
 import (
 	"github.com/redis/go-redis/v9"
 	"github.com/spf13/viper"
+	"webBook/pkg/redisx"
 )
 
 func InitRedis() redis.Cmdable {
-	return redis.NewClient(&redis.Options{
+	client := redis.NewClient(&redis.Options{
 		Addr: viper.GetString("redis.addr"),
 	})
+	client.AddHook(redisx.NewTracingHook())
+	return client
 }
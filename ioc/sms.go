@@ -1,22 +1,230 @@
 package ioc
 
 import (
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 	tencentSMS "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/sms/v20210111"
+	"golang.org/x/text/language"
 	"os"
+	"time"
+	"webBook/internal/domain"
+	"webBook/internal/repository"
+	"webBook/internal/service"
+	"webBook/internal/service/metrics"
 	"webBook/internal/service/sms"
+	"webBook/internal/service/sms/auth"
 	"webBook/internal/service/sms/localsms"
+	smsmetrics "webBook/internal/service/sms/metrics"
+	"webBook/internal/service/sms/ratelimit"
 	"webBook/internal/service/sms/tencent"
+	smstracing "webBook/internal/service/sms/tracing"
+	"webBook/internal/service/tracing"
+	"webBook/pkg/limiter"
+	"webBook/pkg/phonex"
 )
 
-func InitSMSService() sms.Service {
-	return localsms.NewService()
-	// 如果有需要，就可以用这个
-	//return initTencentSMSService()
+func InitSMSService(phoneParser *phonex.Parser, cmd redis.Cmdable) sms.Service {
+	var svc sms.Service
+	provider := smsProvider()
+	switch provider {
+	case "tencent":
+		svc = initTencentSMSService(phoneParser)
+	default:
+		svc = localsms.NewService()
+	}
+	// 指标放在限流外面，被限流的也要统计
+	svc = ratelimit.NewCompositeRateLimitSMSService(svc, initSMSLimiters(cmd))
+	return smstracing.NewService(smsmetrics.NewService(svc, provider))
+}
+
+// initSMSLimiters 全局、模板和号码三个维度的限流，rate 配置成 0 就是这个维度不限流
+func initSMSLimiters(cmd redis.Cmdable) ratelimit.Limiters {
+	type Config struct {
+		Rate     int           `yaml:"rate"`
+		Interval time.Duration `yaml:"interval"`
+	}
+	var cfgs = map[string]Config{
+		"global": {Rate: 100, Interval: time.Second},
+		"tpl":    {Rate: 0, Interval: time.Second},
+		"number": {Rate: 20, Interval: time.Hour},
+	}
+	err := viper.UnmarshalKey("sms.ratelimit", &cfgs)
+	if err != nil {
+		panic(err)
+	}
+	build := func(name string) limiter.Limiter {
+		cfg := cfgs[name]
+		if cfg.Rate <= 0 {
+			return nil
+		}
+		if cfg.Interval <= 0 {
+			panic(fmt.Sprintf("短信限流 %s 的 interval 必须大于 0", name))
+		}
+		return limiter.NewMetricsLimiter("sms-"+name, limiter.NewRedisFixedWindowLimiter(cmd, cfg.Interval, cfg.Rate))
+	}
+	return ratelimit.Limiters{
+		Global: build("global"),
+		Tpl:    build("tpl"),
+		Number: build("number"),
+	}
+}
+
+// smsProvider 当前使用的短信供应商，模板 ID 是跟着供应商走的
+func smsProvider() string {
+	provider := viper.GetString("sms.provider")
+	if provider == "" {
+		return "local"
+	}
+	return provider
+}
+
+// InitAuthSMSService 签名 key 没有默认值，必须配置
+func InitAuthSMSService(svc sms.Service) *auth.SMSService {
+	key := viper.GetString("sms.auth.key")
+	if key == "" {
+		panic("没有配置模板 token 的签名 key sms.auth.key")
+	}
+	return auth.NewSMSService(svc, []byte(key))
+}
+
+// InitCodeService 验证码是内部业务，启动的时候为每个业务申请模板 token，用过一半有效期自动重新签发
+func InitCodeService(repo repository.CodeRepository, smsSvc *auth.SMSService, cmd redis.Cmdable) service.CodeService {
+	tokenExpiration := viper.GetDuration("sms.auth.tokenExpiration")
+	if tokenExpiration <= 0 {
+		tokenExpiration = time.Hour * 24
+	}
+	type Config struct {
+		// 供应商 => 模板 ID
+		TplIds map[string]string `yaml:"tplIds"`
+		// 语言 => 供应商 => 模板 ID，没有配置的语言用 TplIds
+		LocaleTplIds   map[string]map[string]string `yaml:"localeTplIds"`
+		Length         int                          `yaml:"length"`
+		Alphabet       string                       `yaml:"alphabet"`
+		Expiration     time.Duration                `yaml:"expiration"`
+		ResendInterval time.Duration                `yaml:"resendInterval"`
+		MaxAttempts    int                          `yaml:"maxAttempts"`
+	}
+	var cfgs = map[string]Config{
+		"login": {
+			TplIds: map[string]string{"tencent": "1877556", "local": "login"},
+		},
+	}
+	err := viper.UnmarshalKey("sms.code", &cfgs)
+	if err != nil {
+		panic(err)
+	}
+	provider := smsProvider()
+	bizs := make(map[string]service.CodeBizConfig, len(cfgs))
+	for biz, cfg := range cfgs {
+		tplId, ok := cfg.TplIds[provider]
+		if !ok {
+			panic(fmt.Sprintf("验证码业务 %s 没有配置供应商 %s 的模板", biz, provider))
+		}
+		tplToken, err := smsSvc.NewTokenSource(biz, tokenExpiration, tplId)
+		if err != nil {
+			panic(err)
+		}
+		// 没有配置的，就用默认值，也就是 6 位数字，10 分钟有效，1 分钟后可以重发，可以验证 3 次
+		policy := domain.CodePolicy{
+			Length:         6,
+			Alphabet:       "0123456789",
+			Expiration:     time.Minute * 10,
+			ResendInterval: time.Minute,
+			MaxAttempts:    3,
+		}
+		if cfg.Length > 0 {
+			policy.Length = cfg.Length
+		}
+		if cfg.Alphabet != "" {
+			policy.Alphabet = cfg.Alphabet
+		}
+		if cfg.Expiration > 0 {
+			policy.Expiration = cfg.Expiration
+		}
+		if cfg.ResendInterval > 0 {
+			policy.ResendInterval = cfg.ResendInterval
+		}
+		if cfg.MaxAttempts > 0 {
+			policy.MaxAttempts = cfg.MaxAttempts
+		}
+		localeTplTokens, err := initLocaleTplTokens(smsSvc, biz, provider, tokenExpiration, cfg.LocaleTplIds)
+		if err != nil {
+			panic(err)
+		}
+		bizs[biz] = service.CodeBizConfig{
+			TplToken:        tplToken,
+			LocaleTplTokens: localeTplTokens,
+			CodePolicy:      policy,
+		}
+	}
+	svc := service.NewCodeService(repo, smsSvc, bizs, initCodeQuota(cmd))
+	return tracing.NewCodeService(metrics.NewCodeService(svc))
+}
+
+// initLocaleTplTokens 每种语言的模板 token，key 统一成 en-US 这种格式，viper 读出来的 key 都是小写的
+func initLocaleTplTokens(smsSvc *auth.SMSService, biz, provider string, expiration time.Duration,
+	localeTplIds map[string]map[string]string) (map[string]auth.TokenSource, error) {
+	res := make(map[string]auth.TokenSource, len(localeTplIds))
+	for locale, tplIds := range localeTplIds {
+		tag, err := language.Parse(locale)
+		if err != nil {
+			return nil, fmt.Errorf("验证码业务 %s 的语言 %s 不对 %w", biz, locale, err)
+		}
+		tplId, ok := tplIds[provider]
+		if !ok {
+			// 这个供应商没有这种语言的模板，用默认的
+			continue
+		}
+		token, err := smsSvc.NewTokenSource(biz, expiration, tplId)
+		if err != nil {
+			return nil, err
+		}
+		res[tag.String()] = token
+	}
+	return res, nil
+}
+
+// initCodeQuota 每日的发送额度，配置成 0 就是不限制
+func initCodeQuota(cmd redis.Cmdable) service.CodeQuota {
+	type Config struct {
+		Phone int `yaml:"phone"`
+		IP    int `yaml:"ip"`
+		Biz   int `yaml:"biz"`
+		// Location 按照哪个时区的零点重置额度，例如 Asia/Shanghai，默认用本地时区
+		Location string `yaml:"location"`
+	}
+	var cfg = Config{
+		Phone:    10,
+		IP:       50,
+		Biz:      100000,
+		Location: "Local",
+	}
+	err := viper.UnmarshalKey("sms.quota", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	loc, err := time.LoadLocation(cfg.Location)
+	if err != nil {
+		panic(fmt.Errorf("验证码额度的时区 %s 不对 %w", cfg.Location, err))
+	}
+	// 每个维度每天只有一个计数器，业务的额度再大也不占内存
+	daily := func(name string, rate int) limiter.Limiter {
+		if rate <= 0 {
+			return nil
+		}
+		return limiter.NewMetricsLimiter(name, limiter.NewRedisDailyLimiter(cmd, loc, rate))
+	}
+	return service.CodeQuota{
+		Phone: daily("code-quota-phone", cfg.Phone),
+		IP:    daily("code-quota-ip", cfg.IP),
+		Biz:   daily("code-quota-biz", cfg.Biz),
+	}
 }
 
-func initTencentSMSService() sms.Service {
+func initTencentSMSService(phoneParser *phonex.Parser) sms.Service {
 	secretId, ok := os.LookupEnv("SMS_SECRET_ID")
 	if !ok {
 		panic("找不到腾讯 SMS 的 secret id")
@@ -33,5 +241,5 @@ func initTencentSMSService() sms.Service {
 	if err != nil {
 		panic(err)
 	}
-	return tencent.NewService(c, "1400842696", "妙影科技")
+	return tencent.NewService(c, "1400842696", "妙影科技", phoneParser)
 }
@@ -0,0 +1,19 @@
+package ioc
+
+import (
+	"github.com/redis/go-redis/v9"
+	"webBook/internal/repository"
+	"webBook/internal/repository/cache"
+	cachemetrics "webBook/internal/repository/cache/metrics"
+	"webBook/internal/service"
+	"webBook/internal/service/tracing"
+)
+
+func InitUserService(repo repository.UserRepository,
+	identityRepo repository.UserIdentityRepository) service.UserService {
+	return tracing.NewUserService(service.NewUserService(repo, identityRepo))
+}
+
+func InitUserCache(cmd redis.Cmdable) cache.UserCache {
+	return cachemetrics.NewUserCache(cache.NewUserCache(cmd))
+}
@@ -4,35 +4,89 @@ import (
 	"context"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
+	"log"
+	"net/http"
 	"strings"
 	"time"
+	"webBook/internal/service"
 	"webBook/internal/web"
 	ijwt "webBook/internal/web/jwt"
 	"webBook/internal/web/middleware"
-	"webBook/pkg/ginx/middleware/ratelimit"
-	"webBook/pkg/limiter"
+	"webBook/pkg/ginx/middleware/concurrency"
+	"webBook/pkg/ginx/middleware/metrics"
+	"webBook/pkg/ginx/middleware/requestid"
+	"webBook/pkg/ginx/middleware/tracing"
+	"webBook/pkg/ginx/openapi"
 	"webBook/pkg/logger"
+	"webBook/pkg/phonex"
 )
 
-func InitWebServer(mdls []gin.HandlerFunc, userHdl *web.UserHandler, wechatHdl *web.OAuth2WechatHandler) *gin.Engine {
+func InitWebServer(mdls []gin.HandlerFunc, userHdl *web.UserHandler,
+	wechatHdl *web.OAuth2WechatHandler, miniHdl *web.MiniProgramHandler, oauth2Hdl *web.OAuth2Handler,
+	oauth2ServerHdl *web.OAuth2ServerHandler, captchaHdl *web.CaptchaHandler, phoneParser *phonex.Parser) *gin.Engine {
 	server := gin.Default()
+	// 老客户端都升级之后改成 false
+	viper.SetDefault("web.legacyResult", true)
+	web.SetLegacyResult(viper.GetBool("web.legacyResult"))
+	web.RegisterResultHandlers()
+	// 要在处理请求之前注册，binding 标签才能用上
+	err := web.RegisterValidators(phoneParser)
+	if err != nil {
+		panic(err)
+	}
+	// 下面的代码直接把 *gin.Context 当作 context.Context 用，要能取到中间件放进 Request 里面的值
+	server.ContextWithFallback = true
 	server.Use(mdls...)
-	userHdl.RegisterRoutes(server)
-	wechatHdl.RegisterRoutes(server)
+	initMetricsServer()
+	// /oauth2/wechat 是静态路由，gin 会优先匹配，不会走到通用的 handler
+	hdls := []web.Handler{userHdl, wechatHdl, miniHdl, oauth2Hdl, oauth2ServerHdl, captchaHdl}
+	for _, hdl := range hdls {
+		hdl.RegisterRoutes(server)
+	}
+	// 接口文档，不想对外暴露的环境关掉
+	viper.SetDefault("web.openapi", true)
+	if viper.GetBool("web.openapi") {
+		server.GET("/openapi.json", openapi.Handler(web.OpenAPI(hdls...)))
+		server.GET("/docs", openapi.ViewerHandler("webook", "/openapi.json"))
+	}
 	return server
 }
 
-func InitGinMiddlewares(redisClient redis.Cmdable, hdl ijwt.Handler, l logger.LoggerV1) []gin.HandlerFunc {
+// initMetricsServer Prometheus 拉取指标的接口，里面有路由、实例和连接池的信息，
+// 和日志管理接口一样单独监听一个地址，为空就不开启，不要暴露到公网
+func initMetricsServer() {
+	addr := viper.GetString("metrics.adminAddr")
+	if addr == "" {
+		return
+	}
+	go func() {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		log.Println("指标接口退出", http.ListenAndServe(addr, mux))
+	}()
+}
+
+func InitGinMiddlewares(redisClient redis.Cmdable, hdl ijwt.Handler, l logger.LoggerV1,
+	cl *concurrency.GradientLimiter, userSvc service.UserService, phoneParser *phonex.Parser) []gin.HandlerFunc {
 	return []gin.HandlerFunc{
+		// 放在最前面，后面所有的日志都带上请求 ID
+		requestid.NewBuilder(l).Build(),
+		// 紧跟着请求 ID，负载高的时候尽早丢弃请求，丢弃的日志也能带上请求 ID
+		initConcurrencyLimit(cl),
+		tracing.NewBuilder().Build(),
+		metrics.NewBuilder("webook", "web", instanceID()).Build(),
 		cors.New(cors.Config{
 			//AllowAllOrigins: true,
 			//AllowOrigins:     []string{"http://localhost:3000"},
 			AllowCredentials: true,
 
-			AllowHeaders: []string{"Content-Type", "Authorization"},
+			AllowHeaders: []string{"Content-Type", "Authorization", "Accept-Language", requestid.HeaderName, web.ResultVersionHeader},
 			// 这个是允许前端访问你的后端响应中带的头部
-			ExposeHeaders: []string{"x-jwt-token", "x-refresh-token"},
+			ExposeHeaders: []string{"x-jwt-token", "x-refresh-token", "Content-Language", requestid.HeaderName,
+				"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After"},
 			//AllowHeaders: []string{"content-type"},
 			//AllowMethods: []string{"POST"},
 			AllowOriginFunc: func(origin string) bool {
@@ -44,13 +98,52 @@ func InitGinMiddlewares(redisClient redis.Cmdable, hdl ijwt.Handler, l logger.Lo
 			},
 			MaxAge: 12 * time.Hour,
 		}),
-		func(ctx *gin.Context) {
-			println("这是我的 Middleware")
-		},
-		ratelimit.NewBuilder(limiter.NewRedisSlidingWindowLimiter(redisClient, time.Second, 1000)).Build(),
-		middleware.NewLogMiddlewareBuilder(func(ctx context.Context, al middleware.AccessLog) {
-			l.Debug("", logger.Field{Key: "req", Val: al})
-		}).AllowReqBody().AllowRespBody().Build(),
+		initIPRateLimit(redisClient),
+		initAccessLog(),
 		middleware.NewLoginJWTMiddlewareBuilder(hdl).CheckLogin(),
+		// 用户资料有缓存，每个请求查一次问题不大
+		middleware.NewLocaleMiddlewareBuilder(func(ctx context.Context, uid int64) (string, error) {
+			u, err := userSvc.FindById(ctx, uid)
+			return u.Locale, err
+		}).Build(),
+		initRuleRateLimit(redisClient, phoneParser),
+	}
+}
+
+func initAccessLog() gin.HandlerFunc {
+	type Config struct {
+		ReqBody     bool `yaml:"reqBody"`
+		RespBody    bool `yaml:"respBody"`
+		MaxBodySize int  `yaml:"maxBodySize"`
+		// 请求体和响应体里面要脱敏的 JSON 路径
+		RedactReq  []string                  `yaml:"redactReq"`
+		RedactResp []string                  `yaml:"redactResp"`
+		Sampling   []middleware.SamplingRule `yaml:"sampling"`
+	}
+	var cfg = Config{
+		ReqBody:     true,
+		RespBody:    true,
+		MaxBodySize: 2048,
+		// 后面几个是 /oauth2/token、/introspect、/revoke 表单里面的凭证
+		RedactReq: []string{"password", "confirmPassword", "code", "captcha", "phone",
+			"client_secret", "code_verifier", "refresh_token", "token"},
+		RedactResp: []string{"access_token", "refresh_token", "data.image"},
+	}
+	err := viper.UnmarshalKey("accessLog", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	builder := middleware.NewLogMiddlewareBuilder(func(ctx context.Context, al middleware.AccessLog) {
+		logger.FromContext(ctx).Named("access").Info("", logger.Field{Key: "req", Val: al})
+	}).MaxBodySize(cfg.MaxBodySize).
+		RedactReq(cfg.RedactReq...).
+		RedactResp(cfg.RedactResp...).
+		Sampling(cfg.Sampling...)
+	if cfg.ReqBody {
+		builder.AllowReqBody()
+	}
+	if cfg.RespBody {
+		builder.AllowRespBody()
 	}
+	return builder.Build()
 }
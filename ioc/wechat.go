@@ -1,12 +1,14 @@
 package ioc
 
 import (
+	"github.com/spf13/viper"
 	"os"
+	"time"
+	"webBook/internal/repository"
 	"webBook/internal/service/oauth2/wechat"
-	"webBook/pkg/logger"
 )
 
-func InitWechatService(l logger.LoggerV1) wechat.Service {
+func InitWechatService() wechat.Service {
 	appID, ok := os.LookupEnv("WECHAT_APP_ID")
 	if !ok {
 		panic("找不到环境变量 WECHAT_APP_ID")
@@ -15,5 +17,44 @@ func InitWechatService(l logger.LoggerV1) wechat.Service {
 	if !ok {
 		panic("找不到环境变量 WECHAT_APP_SECRET")
 	}
-	return wechat.NewService(appID, appSecret, l)
+	type Config struct {
+		BaseURL     string `yaml:"baseURL"`
+		RedirectURL string `yaml:"redirectURL"`
+	}
+	var cfg = Config{
+		BaseURL: wechat.DefaultBaseURL,
+	}
+	err := viper.UnmarshalKey("wechat", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	if cfg.RedirectURL == "" {
+		panic("没有配置微信登录的回调地址 wechat.redirectURL")
+	}
+	return wechat.NewService(appID, appSecret, cfg.BaseURL, cfg.RedirectURL)
+}
+
+func InitMiniProgramService(repo repository.WechatSessionRepository) wechat.MiniProgramService {
+	appID, ok := os.LookupEnv("WECHAT_MINI_APP_ID")
+	if !ok {
+		panic("找不到环境变量 WECHAT_MINI_APP_ID")
+	}
+	appSecret, ok := os.LookupEnv("WECHAT_MINI_APP_SECRET")
+	if !ok {
+		panic("找不到环境变量 WECHAT_MINI_APP_SECRET")
+	}
+	type Config struct {
+		BaseURL string `yaml:"baseURL"`
+		// session key 在服务端保存多久
+		SessionExpiration time.Duration `yaml:"sessionExpiration"`
+	}
+	var cfg = Config{
+		BaseURL:           wechat.DefaultBaseURL,
+		SessionExpiration: time.Hour * 24,
+	}
+	err := viper.UnmarshalKey("wechat", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	return wechat.NewMiniProgramService(appID, appSecret, cfg.BaseURL, repo, cfg.SessionExpiration)
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"github.com/fsnotify/fsnotify"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/pflag"
@@ -9,11 +10,21 @@ import (
 	_ "github.com/spf13/viper/remote"
 	"log"
 	"net/http"
+	"time"
+	// 镜像里面不一定有时区数据，验证码额度按照时区重置
+	_ "time/tzdata"
+	"webBook/ioc"
 )
 
 func main() {
 	initViperRemote()
 	//initViperWatch()
+	shutdown := ioc.InitOTEL()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+		_ = shutdown(ctx)
+	}()
 	server := InitWebServer()
 	server.GET("/hello", func(ctx *gin.Context) {
 		ctx.String(http.StatusOK, "hello，启动成功了！")
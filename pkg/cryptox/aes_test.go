@@ -0,0 +1,35 @@
+package cryptox
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestAESGCM(t *testing.T) {
+	c, err := NewAESGCM([]byte("k6CswdUm77WKcbM68UQUuxVsHSpTCwgT"))
+	require.NoError(t, err)
+	ciphertext, err := c.Encrypt("refresh-token")
+	require.NoError(t, err)
+	assert.NotContains(t, ciphertext, "refresh-token")
+
+	// 每次加密的 nonce 都不一样
+	another, err := c.Encrypt("refresh-token")
+	require.NoError(t, err)
+	assert.NotEqual(t, ciphertext, another)
+
+	plain, err := c.Decrypt(ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "refresh-token", plain)
+
+	other, err := NewAESGCM([]byte("another key 1234"))
+	require.NoError(t, err)
+	_, err = other.Decrypt(ciphertext)
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+
+	_, err = c.Decrypt("abc")
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+
+	_, err = NewAESGCM([]byte("short"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,48 @@
+package concurrency
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+// Builder 根据延迟自适应调整并发上限，超过上限的请求直接返回 503
+type Builder struct {
+	limiter    *GradientLimiter
+	priorities map[string]Priority
+}
+
+func NewBuilder(l *GradientLimiter) *Builder {
+	return &Builder{
+		limiter:    l,
+		priorities: map[string]Priority{},
+	}
+}
+
+// Priority 设置路由的优先级，没有设置的是 PriorityNormal。
+// paths 是 gin 注册的路由，例如 /users/login
+func (b *Builder) Priority(p Priority, paths ...string) *Builder {
+	for _, path := range paths {
+		b.priorities[path] = p
+	}
+	return b
+}
+
+func (b *Builder) Build() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		p, ok := b.priorities[ctx.FullPath()]
+		if !ok {
+			p = PriorityNormal
+		}
+		if !b.limiter.Acquire(p) {
+			ctx.Header("Retry-After", "1")
+			ctx.AbortWithStatus(http.StatusServiceUnavailable)
+			return
+		}
+		start := time.Now()
+		defer func() {
+			b.limiter.Release(time.Since(start))
+		}()
+		ctx.Next()
+	}
+}
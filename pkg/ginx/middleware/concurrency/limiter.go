@@ -0,0 +1,135 @@
+package concurrency
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Priority 请求的优先级，负载高的时候先丢弃优先级低的请求
+type Priority int
+
+const (
+	// PriorityLow 最先被丢弃
+	PriorityLow Priority = iota
+	PriorityNormal
+	// PriorityCritical 例如登录和刷新 token，最后才丢弃
+	PriorityCritical
+)
+
+// shares 每个优先级最多能用掉多少比例的并发
+var shares = map[Priority]float64{
+	PriorityLow:      0.7,
+	PriorityNormal:   0.9,
+	PriorityCritical: 1,
+}
+
+const (
+	// smoothing 每次只往新的上限挪 20%，避免抖动
+	smoothing = 0.2
+	// 短期 RTT 大概是最近 10 个请求，长期 RTT 大概是最近 600 个请求
+	shortWindow = 10
+	longWindow  = 600
+)
+
+// GradientLimiter 参考 Netflix concurrency-limits 的 gradient2 算法。
+// 用长期 RTT 和短期 RTT 的比值作为梯度：
+// 延迟变高的时候梯度小于 1，并发上限跟着下降；延迟平稳的时候梯度是 1，上限慢慢往上探
+type GradientLimiter struct {
+	mutex    sync.Mutex
+	limit    float64
+	minLimit float64
+	maxLimit float64
+	inflight int
+	// 单位是纳秒，用指数移动平均计算
+	shortRTT float64
+	longRTT  float64
+
+	rejected atomic.Uint64
+}
+
+// Stats 限流器当前的状态，用来做监控
+type Stats struct {
+	Limit    int
+	InFlight int
+	ShortRTT time.Duration
+	LongRTT  time.Duration
+	// Rejected 启动以来一共拒绝了多少请求
+	Rejected uint64
+}
+
+func NewGradientLimiter(initial, minLimit, maxLimit int) *GradientLimiter {
+	return &GradientLimiter{
+		limit:    float64(initial),
+		minLimit: float64(minLimit),
+		maxLimit: float64(maxLimit),
+	}
+}
+
+// Acquire 返回 false 的时候要丢弃请求，返回 true 的时候处理完要调用 Release
+func (l *GradientLimiter) Acquire(p Priority) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	share, ok := shares[p]
+	if !ok {
+		share = shares[PriorityNormal]
+	}
+	allowed := max(int(l.limit*share), 1)
+	if l.inflight >= allowed {
+		l.rejected.Add(1)
+		return false
+	}
+	l.inflight++
+	return true
+}
+
+// Release 请求处理完了，rtt 是处理这个请求花的时间
+func (l *GradientLimiter) Release(rtt time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	// 算上刚处理完的这个请求
+	inflight := l.inflight
+	l.inflight--
+	sample := float64(rtt)
+	if sample <= 0 {
+		return
+	}
+	if l.longRTT == 0 {
+		l.shortRTT, l.longRTT = sample, sample
+	} else {
+		l.shortRTT = ema(l.shortRTT, sample, shortWindow)
+		l.longRTT = ema(l.longRTT, sample, longWindow)
+	}
+	// 延迟明显下降了，让长期 RTT 尽快跟上，不然上限会一直往上涨
+	if l.longRTT/l.shortRTT > 2 {
+		l.longRTT *= 0.95
+	}
+	gradient := math.Max(0.5, math.Min(1, l.longRTT/l.shortRTT))
+	// 允许排队的请求数量，保证上限能慢慢往上探
+	queue := math.Sqrt(l.limit)
+	newLimit := l.limit*gradient + queue
+	// 并发都没有用到一半，没有资格提高上限
+	if float64(inflight) < l.limit/2 && newLimit > l.limit {
+		return
+	}
+	newLimit = l.limit*(1-smoothing) + newLimit*smoothing
+	l.limit = math.Max(l.minLimit, math.Min(l.maxLimit, newLimit))
+}
+
+func (l *GradientLimiter) Stats() Stats {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return Stats{
+		Limit:    int(l.limit),
+		InFlight: l.inflight,
+		ShortRTT: time.Duration(l.shortRTT),
+		LongRTT:  time.Duration(l.longRTT),
+		Rejected: l.rejected.Load(),
+	}
+}
+
+func ema(avg, sample float64, window int) float64 {
+	alpha := 2 / float64(window+1)
+	return avg*(1-alpha) + sample*alpha
+}
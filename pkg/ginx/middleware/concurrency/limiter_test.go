@@ -0,0 +1,85 @@
+package concurrency
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGradientLimiter_LatencyIncrease(t *testing.T) {
+	l := NewGradientLimiter(100, 10, 1000)
+	for i := 0; i < 100; i++ {
+		assert.True(t, l.Acquire(PriorityNormal))
+		l.Release(time.Millisecond * 10)
+	}
+	assert.Equal(t, 100, l.Stats().Limit)
+	// MySQL 变慢了
+	for i := 0; i < 100; i++ {
+		assert.True(t, l.Acquire(PriorityCritical))
+		l.Release(time.Millisecond * 100)
+	}
+	stats := l.Stats()
+	assert.Less(t, stats.Limit, 50)
+	assert.GreaterOrEqual(t, stats.Limit, 10)
+	assert.Equal(t, 0, stats.InFlight)
+}
+
+func TestGradientLimiter_Probe(t *testing.T) {
+	l := NewGradientLimiter(100, 10, 1000)
+	// 并发用满了，延迟平稳，上限往上探
+	for i := 0; i < 90; i++ {
+		assert.True(t, l.Acquire(PriorityNormal))
+	}
+	for i := 0; i < 90; i++ {
+		l.Release(time.Millisecond * 10)
+	}
+	assert.Greater(t, l.Stats().Limit, 100)
+	assert.LessOrEqual(t, l.Stats().Limit, 1000)
+}
+
+func TestGradientLimiter_Priority(t *testing.T) {
+	l := NewGradientLimiter(10, 10, 10)
+	acquired := 0
+	for l.Acquire(PriorityLow) {
+		acquired++
+	}
+	assert.Equal(t, 7, acquired)
+	for l.Acquire(PriorityNormal) {
+		acquired++
+	}
+	assert.Equal(t, 9, acquired)
+	for l.Acquire(PriorityCritical) {
+		acquired++
+	}
+	assert.Equal(t, 10, acquired)
+	assert.Equal(t, uint64(3), l.Stats().Rejected)
+}
+
+func TestBuilder_Build(t *testing.T) {
+	l := NewGradientLimiter(2, 2, 2)
+	gin.SetMode(gin.ReleaseMode)
+	server := gin.New()
+	server.Use(NewBuilder(l).Priority(PriorityCritical, "/users/login").Build())
+	server.POST("/users/login", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+	server.GET("/users/profile", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+	// 模拟有一个请求正在处理
+	assert.True(t, l.Acquire(PriorityNormal))
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users/profile", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "1", recorder.Header().Get("Retry-After"))
+
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/users/login", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	// 处理完了释放
+	assert.Equal(t, 1, l.Stats().InFlight)
+}
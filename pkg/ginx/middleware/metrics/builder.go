@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"strconv"
+	"time"
+	"webBook/pkg/prometheusx"
+)
+
+// Builder 统计 HTTP 请求的数量和响应时间。
+// 用路由而不是 URL 作为标签，不然 /articles/123 这种会让标签数量爆炸
+type Builder struct {
+	Namespace  string
+	Subsystem  string
+	InstanceID string
+}
+
+func NewBuilder(namespace, subsystem, instanceID string) *Builder {
+	return &Builder{
+		Namespace:  namespace,
+		Subsystem:  subsystem,
+		InstanceID: instanceID,
+	}
+}
+
+func (b *Builder) Build() gin.HandlerFunc {
+	labels := []string{"method", "pattern", "status"}
+	constLabels := prometheus.Labels{"instance_id": b.InstanceID}
+	requests := prometheusx.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   b.Namespace,
+		Subsystem:   b.Subsystem,
+		Name:        "http_requests_total",
+		Help:        "HTTP 请求数量",
+		ConstLabels: constLabels,
+	}, labels))
+	duration := prometheusx.MustRegister(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   b.Namespace,
+		Subsystem:   b.Subsystem,
+		Name:        "http_request_duration_seconds",
+		Help:        "HTTP 响应时间",
+		ConstLabels: constLabels,
+		Buckets:     []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+	}, labels))
+	active := prometheusx.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   b.Namespace,
+		Subsystem:   b.Subsystem,
+		Name:        "http_active_requests",
+		Help:        "正在处理的 HTTP 请求数量",
+		ConstLabels: constLabels,
+	}))
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		active.Inc()
+		defer func() {
+			active.Dec()
+			pattern := ctx.FullPath()
+			// 没有匹配上路由，例如 404
+			if pattern == "" {
+				pattern = "unknown"
+			}
+			lvs := []string{ctx.Request.Method, pattern, strconv.Itoa(ctx.Writer.Status())}
+			requests.WithLabelValues(lvs...).Inc()
+			duration.WithLabelValues(lvs...).Observe(time.Since(start).Seconds())
+		}()
+		ctx.Next()
+	}
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuilder_Build(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	server := gin.New()
+	server.Use(NewBuilder("test", "web", "1").Build())
+	server.GET("/articles/:id", func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+	for _, path := range []string{"/articles/1", "/articles/2", "/not-exist"} {
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	expected := `
+# HELP test_web_http_requests_total HTTP 请求数量
+# TYPE test_web_http_requests_total counter
+test_web_http_requests_total{instance_id="1",method="GET",pattern="/articles/:id",status="200"} 2
+test_web_http_requests_total{instance_id="1",method="GET",pattern="unknown",status="404"} 1
+`
+	err := testutil.GatherAndCompare(prometheus.DefaultGatherer, strings.NewReader(expected),
+		"test_web_http_requests_total")
+	assert.NoError(t, err)
+}
@@ -3,14 +3,18 @@ package ratelimit
 import (
 	"fmt"
 	"github.com/gin-gonic/gin"
-	"log"
 	"net/http"
 	"webBook/pkg/limiter"
+	"webBook/pkg/logger"
 )
 
 type Builder struct {
 	prefix  string
 	limiter limiter.Limiter
+	// limiter 出错的时候是否放行
+	failOpen bool
+	// 不为 nil 的时候 limiter 出错用它来限流
+	fallback limiter.Limiter
 }
 
 func NewBuilder(l limiter.Limiter) *Builder {
@@ -25,21 +29,44 @@ func (b *Builder) Prefix(prefix string) *Builder {
 	return b
 }
 
+// FailClosed 默认的保守做法：因为借助于 Redis 来做限流，那么 Redis 崩溃了，为了防止系统崩溃，直接限流
+func (b *Builder) FailClosed() *Builder {
+	b.failOpen = false
+	b.fallback = nil
+	return b
+}
+
+// FailOpen 激进做法：虽然 Redis 崩溃了，但是这个时候还是要尽量服务正常的用户，所以不限流
+func (b *Builder) FailOpen() *Builder {
+	b.failOpen = true
+	b.fallback = nil
+	return b
+}
+
+// FailLocal 折中做法：Redis 崩溃了就退化成单机限流，l 的阈值要按照实例数量分摊
+func (b *Builder) FailLocal(l limiter.Limiter) *Builder {
+	b.failOpen = false
+	b.fallback = l
+	return b
+}
+
 func (b *Builder) Build() gin.HandlerFunc {
+	l := b.limiter
+	if b.fallback != nil {
+		l = limiter.NewFallbackLimiter(l, b.fallback)
+	}
 	return func(ctx *gin.Context) {
-		limited, err := b.limiter.Limit(ctx, fmt.Sprintf("%s:%s", b.prefix, ctx.ClientIP()))
+		limited, err := l.Limit(ctx, fmt.Sprintf("%s:%s", b.prefix, ctx.ClientIP()))
 		if err != nil {
-			log.Println(err)
-			// 这一步很有意思，就是如果这边出错了
-			// 要怎么办？
-			// 保守做法：因为借助于 Redis 来做限流，那么 Redis 崩溃了，为了防止系统崩溃，直接限流
+			logger.FromContext(ctx).Error("限流失败", logger.Field{Key: "err", Val: err})
+			if b.failOpen {
+				ctx.Next()
+				return
+			}
 			ctx.AbortWithStatus(http.StatusInternalServerError)
-			// 激进做法：虽然 Redis 崩溃了，但是这个时候还是要尽量服务正常的用户，所以不限流
-			// ctx.Next()
 			return
 		}
 		if limited {
-			log.Println(err)
 			ctx.AbortWithStatus(http.StatusTooManyRequests)
 			return
 		}
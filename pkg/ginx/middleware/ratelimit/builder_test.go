@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	limitermocks "webBook/pkg/limiter/mocks"
+)
+
+func TestBuilder_Build(t *testing.T) {
+	testCases := []struct {
+		name  string
+		build func(ctrl *gomock.Controller) *Builder
+
+		wantCode int
+	}{
+		{
+			name: "不限流",
+			build: func(ctrl *gomock.Controller) *Builder {
+				l := limitermocks.NewMockLimiter(ctrl)
+				l.EXPECT().Limit(gomock.Any(), "ip-limiter:10.0.0.1").Return(false, nil)
+				return NewBuilder(l)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "限流",
+			build: func(ctrl *gomock.Controller) *Builder {
+				l := limitermocks.NewMockLimiter(ctrl)
+				l.EXPECT().Limit(gomock.Any(), "ip-limiter:10.0.0.1").Return(true, nil)
+				return NewBuilder(l)
+			},
+			wantCode: http.StatusTooManyRequests,
+		},
+		{
+			name: "出错默认 fail closed",
+			build: func(ctrl *gomock.Controller) *Builder {
+				l := limitermocks.NewMockLimiter(ctrl)
+				l.EXPECT().Limit(gomock.Any(), gomock.Any()).Return(false, errors.New("redis 崩了"))
+				return NewBuilder(l)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name: "出错 fail open",
+			build: func(ctrl *gomock.Controller) *Builder {
+				l := limitermocks.NewMockLimiter(ctrl)
+				l.EXPECT().Limit(gomock.Any(), gomock.Any()).Return(false, errors.New("redis 崩了"))
+				return NewBuilder(l).FailOpen()
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "出错退化成单机限流",
+			build: func(ctrl *gomock.Controller) *Builder {
+				l := limitermocks.NewMockLimiter(ctrl)
+				l.EXPECT().Limit(gomock.Any(), gomock.Any()).Return(false, errors.New("redis 崩了"))
+				local := limitermocks.NewMockLimiter(ctrl)
+				local.EXPECT().Limit(gomock.Any(), "ip-limiter:10.0.0.1").Return(true, nil)
+				return NewBuilder(l).FailLocal(local)
+			},
+			wantCode: http.StatusTooManyRequests,
+		},
+	}
+	gin.SetMode(gin.ReleaseMode)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			server := gin.New()
+			server.Use(tc.build(ctrl).Build())
+			server.GET("/hello", func(ctx *gin.Context) {
+				ctx.String(http.StatusOK, "hello")
+			})
+			req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+			req.RemoteAddr = "10.0.0.1:12345"
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.wantCode, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package ratelimit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"webBook/pkg/limiter"
+	"webBook/pkg/logger"
+)
+
+// Rule 一条限流规则，例如 /users/login 每个 IP 每分钟 10 次
+type Rule struct {
+	// Name 规则的名字，同时也是限流 key 的一部分，不能重复
+	Name string `yaml:"name"`
+	// Path gin 注册的路由，例如 /articles/:id，为空就是所有路由
+	Path string `yaml:"path"`
+	// Method 为空就是所有方法
+	Method string `yaml:"method"`
+	// Dimension 按照什么限流：ip、header:<名字>、param:<名字>，
+	// 或者是通过 RuleBuilder.Dimension 注册的，例如 uid
+	Dimension string        `yaml:"dimension"`
+	Rate      int           `yaml:"rate"`
+	Interval  time.Duration `yaml:"interval"`
+}
+
+// KeyFunc 从请求里面取出限流维度的值，arg 是 Dimension 冒号后面的部分。
+// 返回 false 的时候这条规则不生效，例如没有登录就没有 uid
+type KeyFunc func(ctx *gin.Context, arg string) (string, bool)
+
+// RuleBuilder 按照规则限流，一个请求命中的所有规则都要满足。
+// 规则可以通过 Update 热更新
+type RuleBuilder struct {
+	newLimiter func(interval time.Duration, rate int) limiter.QuotaLimiter
+	dimensions map[string]KeyFunc
+	rules      atomic.Pointer[[]compiledRule]
+	// limiter 出错的时候是否放行
+	failOpen bool
+	// maxBodySize 按照 JSON 请求体里面的参数限流的时候最多读多少
+	maxBodySize int64
+	// normalizers param 维度的值先统一格式再限流，key 是参数名
+	normalizers map[string]func(val string) string
+}
+
+type compiledRule struct {
+	Rule
+	kind    string
+	arg     string
+	limiter limiter.QuotaLimiter
+}
+
+func NewRuleBuilder(newLimiter func(interval time.Duration, rate int) limiter.QuotaLimiter) *RuleBuilder {
+	b := &RuleBuilder{
+		newLimiter:  newLimiter,
+		maxBodySize: 1 << 20,
+		normalizers: map[string]func(val string) string{},
+	}
+	b.dimensions = map[string]KeyFunc{
+		"ip": func(ctx *gin.Context, arg string) (string, bool) {
+			return ctx.ClientIP(), true
+		},
+		"header": func(ctx *gin.Context, arg string) (string, bool) {
+			val := ctx.GetHeader(arg)
+			return val, val != ""
+		},
+		"param": b.bizParam,
+	}
+	b.rules.Store(&[]compiledRule{})
+	return b
+}
+
+// Dimension 注册新的限流维度，必须在 Update 之前调用
+func (b *RuleBuilder) Dimension(name string, fn KeyFunc) *RuleBuilder {
+	b.dimensions[name] = fn
+	return b
+}
+
+// FailOpen limiter 出错的时候放行，默认是返回 500
+func (b *RuleBuilder) FailOpen() *RuleBuilder {
+	b.failOpen = true
+	return b
+}
+
+// MaxBodySize 请求体超过 size 的时候直接返回 413，默认是 1MB
+func (b *RuleBuilder) MaxBodySize(size int64) *RuleBuilder {
+	b.maxBodySize = size
+	return b
+}
+
+// Normalize param:<name> 维度的值先经过 fn 统一格式，
+// 例如手机号码带不带国家码、邮箱的大小写，不然换一种写法就能绕过限流。必须在 Update 之前调用
+func (b *RuleBuilder) Normalize(name string, fn func(val string) string) *RuleBuilder {
+	b.normalizers[name] = fn
+	return b
+}
+
+// Update 替换全部规则，规则有问题的时候返回 error，并且继续用原本的规则
+func (b *RuleBuilder) Update(rules []Rule) error {
+	res := make([]compiledRule, 0, len(rules))
+	names := make(map[string]struct{}, len(rules))
+	for _, r := range rules {
+		if r.Name == "" {
+			return fmt.Errorf("限流规则没有名字 %+v", r)
+		}
+		if _, ok := names[r.Name]; ok {
+			return fmt.Errorf("限流规则 %s 重复了", r.Name)
+		}
+		names[r.Name] = struct{}{}
+		if r.Rate <= 0 || r.Interval <= 0 {
+			return fmt.Errorf("限流规则 %s 的 rate 和 interval 必须大于 0", r.Name)
+		}
+		kind, arg, _ := strings.Cut(r.Dimension, ":")
+		if _, ok := b.dimensions[kind]; !ok {
+			return fmt.Errorf("限流规则 %s 的维度 %s 不支持", r.Name, r.Dimension)
+		}
+		r.Method = strings.ToUpper(r.Method)
+		res = append(res, compiledRule{
+			Rule:    r,
+			kind:    kind,
+			arg:     arg,
+			limiter: b.newLimiter(r.Interval, r.Rate),
+		})
+	}
+	b.rules.Store(&res)
+	return nil
+}
+
+func (b *RuleBuilder) Build() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		rules := *b.rules.Load()
+		var (
+			matched bool
+			// 剩余额度最少的那个，用来返回响应头
+			tightest limiter.Quota
+			// 被限流的时候要等最久的那个
+			retryAfter time.Duration
+		)
+		for _, r := range rules {
+			if !r.match(ctx) {
+				continue
+			}
+			val, ok := b.dimensions[r.kind](ctx, r.arg)
+			if ctx.IsAborted() {
+				// 例如请求体太大
+				return
+			}
+			if !ok {
+				continue
+			}
+			q, err := r.limiter.Quota(ctx, fmt.Sprintf("rule-limiter:%s:%s", r.Name, val))
+			if err != nil {
+				logger.FromContext(ctx).Error("限流失败", logger.Field{Key: "err", Val: err})
+				if b.failOpen {
+					continue
+				}
+				ctx.AbortWithStatus(http.StatusInternalServerError)
+				return
+			}
+			if !matched || q.Remaining < tightest.Remaining {
+				tightest = q
+			}
+			matched = true
+			if q.Limited && q.ResetAfter > retryAfter {
+				retryAfter = q.ResetAfter
+			}
+		}
+		if !matched {
+			ctx.Next()
+			return
+		}
+		ctx.Header("X-RateLimit-Limit", strconv.Itoa(tightest.Limit))
+		ctx.Header("X-RateLimit-Remaining", strconv.Itoa(tightest.Remaining))
+		ctx.Header("X-RateLimit-Reset", seconds(tightest.ResetAfter))
+		if retryAfter > 0 {
+			ctx.Header("Retry-After", seconds(retryAfter))
+			ctx.AbortWithStatus(http.StatusTooManyRequests)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func (r compiledRule) match(ctx *gin.Context) bool {
+	if r.Method != "" && r.Method != ctx.Request.Method {
+		return false
+	}
+	return r.Path == "" || r.Path == ctx.FullPath()
+}
+
+// seconds 向上取整，Retry-After 为 0 客户端会立刻重试
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// bizParam 取出参数之后按照 Normalize 注册的规则统一格式
+func (b *RuleBuilder) bizParam(ctx *gin.Context, name string) (string, bool) {
+	val, ok := b.rawParam(ctx, name)
+	if !ok {
+		return "", false
+	}
+	if fn, ok := b.normalizers[name]; ok {
+		val = fn(val)
+	}
+	return val, val != ""
+}
+
+// rawParam 依次从路径参数、查询参数、表单和 JSON 请求体里面找
+func (b *RuleBuilder) rawParam(ctx *gin.Context, name string) (string, bool) {
+	if val := ctx.Param(name); val != "" {
+		return val, true
+	}
+	if val := ctx.Query(name); val != "" {
+		return val, true
+	}
+	if ctx.ContentType() != gin.MIMEJSON {
+		val := ctx.PostForm(name)
+		return val, val != ""
+	}
+	body, err := jsonBody(ctx, b.maxBodySize)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctx.AbortWithStatus(http.StatusRequestEntityTooLarge)
+		}
+		return "", false
+	}
+	switch val := body[name].(type) {
+	case string:
+		return val, val != ""
+	case float64, bool:
+		return fmt.Sprint(val), true
+	default:
+		return "", false
+	}
+}
+
+const jsonBodyKey = "ratelimit:json_body"
+
+// jsonBody 多条规则只解析一次，读完之后要把请求体放回去，最多读 maxSize，避免把超大的请求体读进内存
+func jsonBody(ctx *gin.Context, maxSize int64) (map[string]any, error) {
+	if val, ok := ctx.Get(jsonBodyKey); ok {
+		return val.(map[string]any), nil
+	}
+	body := map[string]any{}
+	if ctx.Request.Body == nil {
+		return body, nil
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxSize))
+	if err != nil {
+		return nil, err
+	}
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(data))
+	// 不是 JSON 对象就当作没有这个参数
+	_ = json.Unmarshal(data, &body)
+	ctx.Set(jsonBodyKey, body)
+	return body, nil
+}
@@ -0,0 +1,184 @@
+package ratelimit
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"webBook/pkg/limiter"
+)
+
+func newRuleServer(t *testing.T, rules []Rule, opts ...func(b *RuleBuilder)) *gin.Engine {
+	m := miniredis.RunT(t)
+	cmd := redis.NewClient(&redis.Options{Addr: m.Addr()})
+	b := NewRuleBuilder(func(interval time.Duration, rate int) limiter.QuotaLimiter {
+		return limiter.NewRedisFixedWindowLimiter(cmd, interval, rate)
+	}).Dimension("uid", func(ctx *gin.Context, arg string) (string, bool) {
+		uid := ctx.GetHeader("X-Uid")
+		return uid, uid != ""
+	})
+	for _, opt := range opts {
+		opt(b)
+	}
+	require.NoError(t, b.Update(rules))
+	gin.SetMode(gin.ReleaseMode)
+	server := gin.New()
+	server.Use(b.Build())
+	server.POST("/users/login", func(ctx *gin.Context) {
+		// 限流之后请求体还能读
+		body, _ := io.ReadAll(ctx.Request.Body)
+		ctx.String(http.StatusOK, string(body))
+	})
+	server.GET("/articles/:id", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+	return server
+}
+
+func login(server *gin.Engine, ip, email string) *httptest.ResponseRecorder {
+	body := `{"email":"` + email + `","password":"hello#world123"}`
+	req := httptest.NewRequest(http.MethodPost, "/users/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = ip + ":12345"
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestRuleBuilder_Build(t *testing.T) {
+	server := newRuleServer(t, []Rule{
+		{Name: "login-ip", Path: "/users/login", Method: "post", Dimension: "ip", Rate: 3, Interval: time.Hour},
+		{Name: "login-email", Path: "/users/login", Dimension: "param:email", Rate: 2, Interval: time.Hour},
+	})
+
+	recorder := login(server, "10.0.0.1", "a@qq.com")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "a@qq.com")
+	// 返回额度最少的那条规则
+	assert.Equal(t, "2", recorder.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", recorder.Header().Get("X-RateLimit-Remaining"))
+	assert.NotEmpty(t, recorder.Header().Get("X-RateLimit-Reset"))
+
+	recorder = login(server, "10.0.0.2", "a@qq.com")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	// 换了 IP，同一个邮箱也要限流
+	recorder = login(server, "10.0.0.3", "a@qq.com")
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "0", recorder.Header().Get("X-RateLimit-Remaining"))
+	assert.NotEmpty(t, recorder.Header().Get("Retry-After"))
+
+	// 换了邮箱，同一个 IP 也要限流
+	recorder = login(server, "10.0.0.1", "b@qq.com")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = login(server, "10.0.0.1", "c@qq.com")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = login(server, "10.0.0.1", "d@qq.com")
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+
+	// 没有命中规则的路由没有响应头
+	req := httptest.NewRequest(http.MethodGet, "/articles/1", nil)
+	recorder = httptest.NewRecorder()
+	server.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("X-RateLimit-Limit"))
+}
+
+func TestRuleBuilder_Dimension(t *testing.T) {
+	server := newRuleServer(t, []Rule{
+		{Name: "article-uid", Path: "/articles/:id", Dimension: "uid", Rate: 1, Interval: time.Hour},
+		{Name: "article-id", Path: "/articles/:id", Dimension: "param:id", Rate: 2, Interval: time.Hour},
+	})
+	get := func(id, uid string) int {
+		req := httptest.NewRequest(http.MethodGet, "/articles/"+id, nil)
+		if uid != "" {
+			req.Header.Set("X-Uid", uid)
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+	assert.Equal(t, http.StatusOK, get("1", "123"))
+	assert.Equal(t, http.StatusTooManyRequests, get("2", "123"))
+	// 没有登录的时候 uid 规则不生效，只剩下 id 规则
+	assert.Equal(t, http.StatusOK, get("3", ""))
+	assert.Equal(t, http.StatusOK, get("3", ""))
+	assert.Equal(t, http.StatusTooManyRequests, get("3", ""))
+}
+
+func TestRuleBuilder_Normalize(t *testing.T) {
+	server := newRuleServer(t, []Rule{
+		{Name: "login-email", Path: "/users/login", Dimension: "param:email", Rate: 2, Interval: time.Hour},
+	}, func(b *RuleBuilder) {
+		b.Normalize("email", func(val string) string {
+			return strings.ToLower(strings.TrimSpace(val))
+		})
+	})
+	assert.Equal(t, http.StatusOK, login(server, "10.0.0.1", "a@qq.com").Code)
+	assert.Equal(t, http.StatusOK, login(server, "10.0.0.2", " A@QQ.com").Code)
+	// 换一种写法也是同一个邮箱
+	assert.Equal(t, http.StatusTooManyRequests, login(server, "10.0.0.3", "A@qq.COM ").Code)
+}
+
+func TestRuleBuilder_MaxBodySize(t *testing.T) {
+	server := newRuleServer(t, []Rule{
+		{Name: "login-email", Path: "/users/login", Dimension: "param:email", Rate: 2, Interval: time.Hour},
+	}, func(b *RuleBuilder) {
+		b.MaxBodySize(64)
+	})
+	recorder := login(server, "10.0.0.1", "a@qq.com")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	// 请求体太大，不会整个读进内存
+	recorder = login(server, "10.0.0.1", strings.Repeat("a", 64)+"@qq.com")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+}
+
+func TestRuleBuilder_Update(t *testing.T) {
+	testCases := []struct {
+		name    string
+		rules   []Rule
+		wantErr string
+	}{
+		{
+			name:    "没有名字",
+			rules:   []Rule{{Dimension: "ip", Rate: 1, Interval: time.Second}},
+			wantErr: "限流规则没有名字",
+		},
+		{
+			name: "名字重复",
+			rules: []Rule{
+				{Name: "a", Dimension: "ip", Rate: 1, Interval: time.Second},
+				{Name: "a", Dimension: "ip", Rate: 1, Interval: time.Second},
+			},
+			wantErr: "限流规则 a 重复了",
+		},
+		{
+			name:    "维度不支持",
+			rules:   []Rule{{Name: "a", Dimension: "uid", Rate: 1, Interval: time.Second}},
+			wantErr: "限流规则 a 的维度 uid 不支持",
+		},
+		{
+			name:    "rate 不对",
+			rules:   []Rule{{Name: "a", Dimension: "ip", Interval: time.Second}},
+			wantErr: "限流规则 a 的 rate 和 interval 必须大于 0",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := NewRuleBuilder(func(interval time.Duration, rate int) limiter.QuotaLimiter {
+				return nil
+			})
+			err := b.Update(tc.rules)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+			// 原本的规则不变
+			assert.Empty(t, *b.rules.Load())
+		})
+	}
+}
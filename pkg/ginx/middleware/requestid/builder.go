@@ -0,0 +1,59 @@
+package requestid
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"webBook/pkg/logger"
+)
+
+// HeaderName 上游（例如网关）带过来的请求 ID，响应里面也会带上
+const HeaderName = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// Builder 给每个请求分配一个请求 ID，并且把带着请求 ID 的 logger 放进 ctx，
+// 之后通过 logger.FromContext 拿到的 logger 打的日志都会带上请求 ID
+type Builder struct {
+	l logger.LoggerV1
+}
+
+func NewBuilder(l logger.LoggerV1) *Builder {
+	return &Builder{l: l}
+}
+
+func (b *Builder) Build() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(HeaderName)
+		if !valid(id) {
+			id = uuid.NewString()
+		}
+		ctx.Header(HeaderName, id)
+		reqCtx := context.WithValue(ctx.Request.Context(), requestIDKey{}, id)
+		reqCtx = logger.WithContext(reqCtx, b.l.With(logger.Field{Key: "request_id", Val: id}))
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+		ctx.Next()
+	}
+}
+
+// FromContext 当前请求的 ID，没有经过中间件的话返回空字符串
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// valid 上游传过来的 ID 会原样写进日志和响应头，所以要限制长度和字符
+func valid(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package requestid
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"webBook/pkg/logger"
+)
+
+func TestBuilder_Build(t *testing.T) {
+	testCases := []struct {
+		name   string
+		header string
+
+		wantSame bool
+	}{
+		{name: "沿用上游的 ID", header: "abc-123", wantSame: true},
+		{name: "没有 ID"},
+		{name: "非法字符", header: "abc\n123"},
+	}
+	gin.SetMode(gin.ReleaseMode)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			core, logs := observer.New(zap.DebugLevel)
+			server := gin.New()
+			server.ContextWithFallback = true
+			server.Use(NewBuilder(logger.NewZapLogger(zap.New(core))).Build())
+			var id string
+			server.GET("/hello", func(ctx *gin.Context) {
+				id = FromContext(ctx)
+				// 直接用 gin.Context 也能拿到
+				logger.FromContext(ctx).Info("hello")
+			})
+			req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+			if tc.header != "" {
+				req.Header.Set(HeaderName, tc.header)
+			}
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, req)
+
+			assert.NotEmpty(t, id)
+			assert.Equal(t, id, recorder.Header().Get(HeaderName))
+			if tc.wantSame {
+				assert.Equal(t, tc.header, id)
+			} else {
+				assert.NotEqual(t, tc.header, id)
+			}
+			entries := logs.All()
+			assert.Len(t, entries, 1)
+			assert.Equal(t, id, entries[0].ContextMap()["request_id"])
+		})
+	}
+}
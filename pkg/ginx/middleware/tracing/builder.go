@@ -0,0 +1,63 @@
+package tracing
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"webBook/pkg/logger"
+)
+
+const instrumentationName = "webBook/pkg/ginx/middleware/tracing"
+
+// Builder 每个请求一个 server span，上游带了 traceparent 的话接着上游的链路。
+// 放在请求 ID 中间件后面，这样日志里面既有请求 ID 也有 trace ID
+type Builder struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+func NewBuilder() *Builder {
+	return &Builder{
+		tracer:     otel.Tracer(instrumentationName),
+		propagator: otel.GetTextMapPropagator(),
+	}
+}
+
+func (b *Builder) Build() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		reqCtx := b.propagator.Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+		// 用路由而不是 URL 做名字，不然 /articles/:id 每个 id 都是不同的 span 名字
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unknown"
+		}
+		reqCtx, span := b.tracer.Start(reqCtx, ctx.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(ctx.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.ClientAddress(ctx.ClientIP()),
+			))
+		defer span.End()
+		if sc := span.SpanContext(); sc.IsValid() {
+			l := logger.FromContext(reqCtx).With(logger.Field{Key: "trace_id", Val: sc.TraceID().String()})
+			reqCtx = logger.WithContext(reqCtx, l)
+		}
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		for _, err := range ctx.Errors {
+			span.RecordError(err.Err)
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package openapi
+
+import (
+	"net/http"
+	"path"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MethodAny 对应 gin 的 Any，文档里面只写 GET 和 POST，其他方法没有意义
+const MethodAny = "ANY"
+
+var anyMethods = []string{http.MethodGet, http.MethodPost}
+
+// Route 一个接口的文档，和 RegisterRoutes 里面注册的路由一一对应
+type Route struct {
+	Method string
+	// Path gin 的路由格式，例如 /oauth2/:provider/authurl
+	Path    string
+	Tag     string
+	Summary string
+	// Auth 需要登录，请求头带上 Authorization: Bearer <token>
+	Auth bool
+	// Query query 参数，字段名用 form 标签
+	Query any
+	// Body JSON 请求体
+	Body any
+	// Form 表单请求体，字段名用 form 标签
+	Form any
+	// Resp 响应的 data 部分，nil 就是没有 data
+	Resp any
+	// Raw 响应不包在统一的响应格式里面，例如按照 RFC 返回的接口
+	Raw bool
+}
+
+// Generator 根据路由和请求、响应的类型生成 OpenAPI 3 文档。
+// 字段名用 json 标签，binding 标签里面的 required、max、email 之类的转成对应的约束
+type Generator struct {
+	doc Document
+	// types 已经放进 components 的类型，用来处理同名的类型
+	types map[string]reflect.Type
+	// formats 自定义校验规则对应的 format，例如 phone
+	formats map[string]string
+}
+
+func NewGenerator(info Info) *Generator {
+	return &Generator{
+		doc: Document{
+			OpenAPI: "3.0.3",
+			Info:    info,
+			Paths:   make(map[string]PathItem),
+			Components: Components{
+				Schemas: map[string]*Schema{
+					resultSchema: envelope(&Schema{Description: "成功的时候是接口返回的数据，参数错误的时候是字段错误列表"}),
+				},
+				SecuritySchemes: map[string]SecurityScheme{
+					securityBearer: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				},
+			},
+		},
+		types:   make(map[string]reflect.Type),
+		formats: make(map[string]string),
+	}
+}
+
+const (
+	resultSchema   = "Result"
+	securityBearer = "bearer"
+)
+
+// BindingFormat 自定义的 binding 规则在文档里面用什么 format
+func (g *Generator) BindingFormat(tag, format string) *Generator {
+	g.formats[tag] = format
+	return g
+}
+
+func (g *Generator) Add(routes ...Route) *Generator {
+	for _, r := range routes {
+		p, params := convertPath(r.Path)
+		item, ok := g.doc.Paths[p]
+		if !ok {
+			item = make(PathItem)
+			g.doc.Paths[p] = item
+		}
+		op := g.operation(r, params)
+		methods := []string{r.Method}
+		if r.Method == MethodAny {
+			methods = anyMethods
+		}
+		for _, m := range methods {
+			item[strings.ToLower(m)] = op
+		}
+	}
+	return g
+}
+
+func (g *Generator) Document() Document {
+	return g.doc
+}
+
+func (g *Generator) operation(r Route, params []Parameter) *Operation {
+	op := &Operation{
+		Summary:    r.Summary,
+		Parameters: params,
+		Responses:  make(map[string]Response),
+	}
+	if r.Tag != "" {
+		op.Tags = []string{r.Tag}
+	}
+	if r.Auth {
+		op.Security = []map[string][]string{{securityBearer: {}}}
+	}
+	if r.Query != nil {
+		op.Parameters = append(op.Parameters, g.queryParams(reflect.TypeOf(r.Query))...)
+	}
+	if r.Body != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]MediaType{
+				"application/json": {Schema: g.schema(reflect.TypeOf(r.Body))},
+			},
+		}
+	}
+	if r.Form != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]MediaType{
+				"application/x-www-form-urlencoded": {Schema: g.formSchema(reflect.TypeOf(r.Form))},
+			},
+		}
+	}
+
+	if r.Raw {
+		resp := Response{Description: "OK"}
+		if r.Resp != nil {
+			resp.Content = map[string]MediaType{
+				"application/json": {Schema: g.schema(reflect.TypeOf(r.Resp))},
+			}
+		}
+		op.Responses["200"] = resp
+		return op
+	}
+	data := &Schema{Nullable: true}
+	if r.Resp != nil {
+		data = g.schema(reflect.TypeOf(r.Resp))
+	}
+	op.Responses["200"] = Response{
+		Description: "OK",
+		Content:     map[string]MediaType{"application/json": {Schema: envelope(data)}},
+	}
+	op.Responses["default"] = Response{
+		Description: "出错了，code 是错误码",
+		Content: map[string]MediaType{
+			"application/json": {Schema: &Schema{Ref: "#/components/schemas/" + resultSchema}},
+		},
+	}
+	return op
+}
+
+// envelope 统一的响应格式 ginx.Result
+func envelope(data *Schema) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"code": {Type: "integer", Description: "0 是成功，其他的是错误码"},
+			"msg":  {Type: "string"},
+			"data": data,
+		},
+		Required: []string{"code", "msg"},
+	}
+}
+
+// convertPath 把 gin 的 :name 和 *name 转成 {name}
+func convertPath(p string) (string, []Parameter) {
+	segs := strings.Split(p, "/")
+	var params []Parameter
+	for i, seg := range segs {
+		if seg == "" || (seg[0] != ':' && seg[0] != '*') {
+			continue
+		}
+		name := seg[1:]
+		segs[i] = "{" + name + "}"
+		params = append(params, Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	return strings.Join(segs, "/"), params
+}
+
+func (g *Generator) queryParams(t reflect.Type) []Parameter {
+	s := g.formSchema(t)
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	res := make([]Parameter, 0, len(names))
+	for _, name := range names {
+		res = append(res, Parameter{
+			Name:     name,
+			In:       "query",
+			Required: slices.Contains(s.Required, name),
+			Schema:   s.Properties[name],
+		})
+	}
+	return res
+}
+
+// formSchema 表单和 query 只有一层，直接展开，不放进 components
+func (g *Generator) formSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.fields(deref(t), "form", s)
+	return s
+}
+
+func (g *Generator) schema(t reflect.Type) *Schema {
+	t = deref(t)
+	if t == reflect.TypeOf(time.Time{}) {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+			g.fields(t, "json", s)
+			return s
+		}
+		return &Schema{Ref: "#/components/schemas/" + g.component(t)}
+	default:
+		// interface 之类的，什么都可能
+		return &Schema{}
+	}
+}
+
+// component 把有名字的结构体放进 components，返回它的名字
+func (g *Generator) component(t reflect.Type) string {
+	name := t.Name()
+	if old, ok := g.types[name]; ok && old != t {
+		// 不同的包里面有同名的类型
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	if _, ok := g.types[name]; ok {
+		return name
+	}
+	// 先占位，结构体里面引用了自己的时候不会死循环
+	g.types[name] = t
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.doc.Components.Schemas[name] = s
+	g.fields(t, "json", s)
+	return name
+}
+
+// fields 把 t 的字段加到 s 里面，匿名嵌入的结构体展开
+func (g *Generator) fields(t reflect.Type, tag string, s *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		ft := deref(f.Type)
+		if f.Anonymous && ft.Kind() == reflect.Struct && f.Tag.Get(tag) == "" {
+			g.fields(ft, tag, s)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		name := fieldName(f, tag)
+		if name == "-" {
+			continue
+		}
+		fs := g.schema(f.Type)
+		if g.applyBinding(fs, f.Tag.Get("binding")) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = fs
+	}
+}
+
+// applyBinding 把 binding 标签转成约束，返回是否必填
+func (g *Generator) applyBinding(s *Schema, tag string) bool {
+	if tag == "" {
+		return false
+	}
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		key, val, _ := strings.Cut(rule, "=")
+		if key == "required" {
+			required = true
+			continue
+		}
+		if s.Ref != "" {
+			// 引用的是 components 里面共用的 schema，不能改
+			continue
+		}
+		switch key {
+		case "email":
+			s.Format = "email"
+		case "datetime":
+			if val == time.DateOnly {
+				s.Format = "date"
+			} else {
+				s.Description = "格式 " + val
+			}
+		case "oneof":
+			s.Enum = strings.Fields(val)
+		case "min", "max":
+			n, err := strconv.Atoi(val)
+			if err != nil || s.Type != "string" {
+				continue
+			}
+			if key == "min" {
+				s.MinLength = &n
+			} else {
+				s.MaxLength = &n
+			}
+		default:
+			if format, ok := g.formats[key]; ok {
+				s.Format = format
+			}
+		}
+	}
+	return required
+}
+
+func fieldName(f reflect.StructField, tag string) string {
+	val := f.Tag.Get(tag)
+	if val == "" && tag != "json" {
+		val = f.Tag.Get("json")
+	}
+	name, _, _ := strings.Cut(val, ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+func deref(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
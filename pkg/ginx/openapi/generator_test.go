@@ -0,0 +1,108 @@
+package openapi
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type base struct {
+	Id int64 `json:"id"`
+}
+
+type node struct {
+	base
+	Name     string            `json:"name" binding:"required,max=32"`
+	Kind     string            `json:"kind" binding:"omitempty,oneof=a b"`
+	Phone    string            `json:"phone" binding:"phone"`
+	Children []*node           `json:"children"`
+	Labels   map[string]string `json:"labels"`
+	Ctime    time.Time         `json:"ctime"`
+	Ignored  string            `json:"-"`
+	private  string
+}
+
+type listReq struct {
+	Offset int    `form:"offset"`
+	Query  string `json:"q" binding:"required"`
+}
+
+func TestGenerator(t *testing.T) {
+	doc := NewGenerator(Info{Title: "test", Version: "v1"}).
+		BindingFormat("phone", "phone").
+		Add(
+			Route{Method: http.MethodPost, Path: "/nodes/:id", Tag: "node", Auth: true, Body: node{}, Resp: node{}},
+			Route{Method: http.MethodGet, Path: "/nodes", Query: listReq{}, Resp: []node{}},
+			Route{Method: MethodAny, Path: "/callback", Form: listReq{}, Raw: true},
+		).Document()
+
+	// 路径参数
+	create := doc.Paths["/nodes/{id}"]["post"]
+	require.NotNil(t, create)
+	assert.Equal(t, []Parameter{{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}}}, create.Parameters)
+	assert.Equal(t, []map[string][]string{{securityBearer: {}}}, create.Security)
+	assert.Equal(t, "#/components/schemas/node",
+		create.RequestBody.Content["application/json"].Schema.Ref)
+	data := create.Responses["200"].Content["application/json"].Schema.Properties["data"]
+	assert.Equal(t, "#/components/schemas/node", data.Ref)
+	assert.NotNil(t, create.Responses["default"].Content)
+
+	// 嵌入的字段展开，不导出的和 - 跳过，自己引用自己
+	n := doc.Components.Schemas["node"]
+	require.NotNil(t, n)
+	assert.Len(t, n.Properties, 7)
+	assert.Equal(t, &Schema{Type: "integer", Format: "int64"}, n.Properties["id"])
+	assert.Equal(t, []string{"name"}, n.Required)
+	assert.Equal(t, 32, *n.Properties["name"].MaxLength)
+	assert.Equal(t, []string{"a", "b"}, n.Properties["kind"].Enum)
+	assert.Equal(t, "phone", n.Properties["phone"].Format)
+	assert.Equal(t, "#/components/schemas/node", n.Properties["children"].Items.Ref)
+	assert.Equal(t, "string", n.Properties["labels"].AdditionalProperties.Type)
+	assert.Equal(t, "date-time", n.Properties["ctime"].Format)
+
+	// query 参数用 form 标签，没有的用 json 标签
+	list := doc.Paths["/nodes"]["get"]
+	require.NotNil(t, list)
+	assert.Equal(t, []Parameter{
+		{Name: "offset", In: "query", Schema: &Schema{Type: "integer"}},
+		{Name: "q", In: "query", Required: true, Schema: &Schema{Type: "string"}},
+	}, list.Parameters)
+	assert.Equal(t, "array", list.Responses["200"].Content["application/json"].Schema.Properties["data"].Type)
+
+	// Any 只有 GET 和 POST，Raw 不包统一的响应格式
+	cb := doc.Paths["/callback"]
+	assert.Len(t, cb, 2)
+	assert.Same(t, cb["get"], cb["post"])
+	assert.Nil(t, cb["post"].Responses["200"].Content)
+	assert.Contains(t, cb["post"].RequestBody.Content, "application/x-www-form-urlencoded")
+}
+
+func TestHandler(t *testing.T) {
+	doc := NewGenerator(Info{Title: "test", Version: "v1"}).
+		Add(Route{Method: http.MethodGet, Path: "/hello"}).Document()
+	server := gin.New()
+	server.GET("/openapi.json", Handler(doc))
+	server.GET("/docs", ViewerHandler("test", "/openapi.json"))
+
+	recorder := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/openapi.json", nil)
+	require.NoError(t, err)
+	server.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var got Document
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	assert.Equal(t, "3.0.3", got.OpenAPI)
+	assert.Contains(t, got.Paths, "/hello")
+
+	recorder = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, "/docs", nil)
+	require.NoError(t, err)
+	server.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `var specURL = "/openapi.json";`)
+}
@@ -0,0 +1,42 @@
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"html/template"
+	"net/http"
+)
+
+// Handler 返回 JSON 格式的文档，启动的时候就序列化好
+func Handler(doc Document) gin.HandlerFunc {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		panic(err)
+	}
+	return func(ctx *gin.Context) {
+		ctx.Data(http.StatusOK, "application/json; charset=utf-8", data)
+	}
+}
+
+//go:embed viewer.html
+var viewerHTML string
+
+var viewerTpl = template.Must(template.New("viewer").Parse(viewerHTML))
+
+// ViewerHandler 内置的文档页面，不依赖外部的 CDN，specURL 是 Handler 注册的地址
+func ViewerHandler(title, specURL string) gin.HandlerFunc {
+	var buf bytes.Buffer
+	err := viewerTpl.Execute(&buf, map[string]string{
+		"Title":   title,
+		"SpecURL": specURL,
+	})
+	if err != nil {
+		panic(err)
+	}
+	page := buf.Bytes()
+	return func(ctx *gin.Context) {
+		ctx.Data(http.StatusOK, "text/html; charset=utf-8", page)
+	}
+}
@@ -0,0 +1,118 @@
+package ginx
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entrans "github.com/go-playground/validator/v10/translations/en"
+	zhtrans "github.com/go-playground/validator/v10/translations/zh"
+	"golang.org/x/text/language"
+	"reflect"
+	"strings"
+)
+
+// FieldError 参数校验失败的字段，Field 是 json 里面的字段名
+type FieldError struct {
+	Field string `json:"field"`
+	Msg   string `json:"msg"`
+}
+
+var (
+	validate *validator.Validate
+	// translators 第一个是默认的
+	translators []ut.Translator
+	// languages 和 translators 一一对应，用来匹配 Accept-Language
+	languages []language.Tag
+	matcher   language.Matcher
+)
+
+func init() {
+	// 直接用 gin 自带的 validator，binding 标签就能生效
+	validate = binding.Validator.Engine().(*validator.Validate)
+	// 错误信息里面用 json 的字段名，前端才认识
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	zhLocale, enLocale := zh.New(), en.New()
+	uni := ut.New(zhLocale, zhLocale, enLocale)
+	zhTrans, _ := uni.GetTranslator(zhLocale.Locale())
+	enTrans, _ := uni.GetTranslator(enLocale.Locale())
+	if err := zhtrans.RegisterDefaultTranslations(validate, zhTrans); err != nil {
+		panic(err)
+	}
+	if err := entrans.RegisterDefaultTranslations(validate, enTrans); err != nil {
+		panic(err)
+	}
+	translators = []ut.Translator{zhTrans, enTrans}
+	languages = []language.Tag{language.Chinese, language.English}
+	matcher = language.NewMatcher(languages)
+}
+
+// RegisterValidation 注册自定义的校验规则，msgs 是每种语言的错误信息，key 是 zh、en，
+// 里面的 {0} 会被替换成字段名。只能在启动的时候调用
+func RegisterValidation(tag string, fn validator.Func, msgs map[string]string) error {
+	if err := validate.RegisterValidation(tag, fn); err != nil {
+		return err
+	}
+	for _, trans := range translators {
+		msg, ok := msgs[trans.Locale()]
+		if !ok {
+			continue
+		}
+		err := validate.RegisterTranslation(tag, trans, func(ut ut.Translator) error {
+			return ut.Add(tag, msg, true)
+		}, func(ut ut.Translator, fe validator.FieldError) string {
+			res, _ := ut.T(tag, fe.Field())
+			return res
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FieldErrors 把参数校验的错误翻译成 Accept-Language 对应的语言，
+// err 不是参数校验的错误就返回 nil
+func FieldErrors(ctx *gin.Context, err error) []FieldError {
+	tags, _, _ := language.ParseAcceptLanguage(ctx.GetHeader("Accept-Language"))
+	return TranslateFieldErrors(err, tags...)
+}
+
+// TranslateFieldErrors 和 FieldErrors 一样，语言由调用者决定，
+// 例如用户在个人资料里面设置的语言
+func TranslateFieldErrors(err error, tags ...language.Tag) []FieldError {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil
+	}
+	trans := translator(tags)
+	res := make([]FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		res = append(res, FieldError{Field: fe.Field(), Msg: fe.Translate(trans)})
+	}
+	return res
+}
+
+func translator(tags []language.Tag) ut.Translator {
+	if len(tags) == 0 {
+		return translators[0]
+	}
+	_, idx, conf := matcher.Match(tags...)
+	if conf == language.No {
+		return translators[0]
+	}
+	return translators[idx]
+}
@@ -0,0 +1,141 @@
+package ginx
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sync/atomic"
+	"webBook/pkg/logger"
+)
+
+// Result 统一的响应格式，Code 为 0 就是成功
+type Result struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+	Data any    `json:"data"`
+}
+
+// ClaimsKey 登录校验的中间件把 claims 放在 gin.Context 的这个 key 下面
+const ClaimsKey = "user"
+
+var (
+	// ErrBind 请求体解析或者校验失败
+	ErrBind = errors.New("ginx: 请求参数不对")
+	// ErrNoClaims 没有登录，或者 claims 的类型不对
+	ErrNoClaims = errors.New("ginx: 没有登录")
+)
+
+// ErrorHandler 业务返回 error 的时候怎么响应，一般是把 error 翻译成错误码
+type ErrorHandler func(ctx *gin.Context, err error)
+
+// ResultHandler 业务成功的时候怎么响应，默认直接返回 Result
+type ResultHandler func(ctx *gin.Context, res Result)
+
+var (
+	errorHandler  atomic.Pointer[ErrorHandler]
+	resultHandler atomic.Pointer[ResultHandler]
+)
+
+func init() {
+	SetErrorHandler(defaultErrorHandler)
+	SetResultHandler(defaultResultHandler)
+}
+
+// SetErrorHandler 替换全局的 ErrorHandler，启动的时候调用
+func SetErrorHandler(fn ErrorHandler) {
+	errorHandler.Store(&fn)
+}
+
+// SetResultHandler 替换全局的 ResultHandler，启动的时候调用
+func SetResultHandler(fn ResultHandler) {
+	resultHandler.Store(&fn)
+}
+
+func defaultResultHandler(ctx *gin.Context, res Result) {
+	ctx.JSON(http.StatusOK, res)
+}
+
+func defaultErrorHandler(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrBind):
+		ctx.JSON(http.StatusBadRequest, Result{Code: 4, Msg: "参数错误", Data: FieldErrors(ctx, err)})
+	case errors.Is(err, ErrNoClaims):
+		ctx.JSON(http.StatusUnauthorized, Result{Code: 4, Msg: "请先登录"})
+	default:
+		logger.FromContext(ctx).Error("处理请求失败",
+			logger.Field{Key: "path", Val: ctx.FullPath()},
+			logger.Field{Key: "err", Val: err})
+		ctx.JSON(http.StatusInternalServerError, Result{Code: 5, Msg: "系统错误"})
+	}
+}
+
+// Wrap 业务只需要返回 Result 或者 error，响应统一在这里写
+func Wrap(fn func(ctx *gin.Context) (Result, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		res, err := fn(ctx)
+		render(ctx, res, err)
+	}
+}
+
+// WrapBody 解析请求体之后再调用 fn
+func WrapBody[Req any](fn func(ctx *gin.Context, req Req) (Result, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req Req
+		if err := ctx.ShouldBind(&req); err != nil {
+			render(ctx, Result{}, fmt.Errorf("%w: %w", ErrBind, err))
+			return
+		}
+		res, err := fn(ctx, req)
+		render(ctx, res, err)
+	}
+}
+
+// WrapClaims 取出登录校验中间件放进去的 claims 之后再调用 fn
+func WrapClaims[Claims any](fn func(ctx *gin.Context, uc Claims) (Result, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		uc, ok := claims[Claims](ctx)
+		if !ok {
+			render(ctx, Result{}, ErrNoClaims)
+			return
+		}
+		res, err := fn(ctx, uc)
+		render(ctx, res, err)
+	}
+}
+
+// WrapBodyAndClaims 同时需要请求体和 claims
+func WrapBodyAndClaims[Req any, Claims any](fn func(ctx *gin.Context, req Req, uc Claims) (Result, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		uc, ok := claims[Claims](ctx)
+		if !ok {
+			render(ctx, Result{}, ErrNoClaims)
+			return
+		}
+		var req Req
+		if err := ctx.ShouldBind(&req); err != nil {
+			render(ctx, Result{}, fmt.Errorf("%w: %w", ErrBind, err))
+			return
+		}
+		res, err := fn(ctx, req, uc)
+		render(ctx, res, err)
+	}
+}
+
+func claims[Claims any](ctx *gin.Context) (Claims, bool) {
+	val, ok := ctx.Get(ClaimsKey)
+	if !ok {
+		var zero Claims
+		return zero, false
+	}
+	uc, ok := val.(Claims)
+	return uc, ok
+}
+
+func render(ctx *gin.Context, res Result, err error) {
+	if err != nil {
+		(*errorHandler.Load())(ctx, err)
+		return
+	}
+	(*resultHandler.Load())(ctx, res)
+}
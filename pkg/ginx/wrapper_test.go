@@ -0,0 +1,127 @@
+package ginx
+
+import (
+	"bytes"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testReq struct {
+	Name string `json:"name"`
+}
+
+type testClaims struct {
+	Uid int64
+}
+
+func TestWrapBodyAndClaims(t *testing.T) {
+	testCases := []struct {
+		name   string
+		body   string
+		claims any
+		err    error
+
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "成功",
+			body:     `{"name":"tom"}`,
+			claims:   testClaims{Uid: 123},
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"tom","data":123}`,
+		},
+		{
+			name:     "没有登录",
+			body:     `{"name":"tom"}`,
+			wantCode: http.StatusUnauthorized,
+			wantBody: `{"code":4,"msg":"请先登录","data":null}`,
+		},
+		{
+			name:     "claims 类型不对",
+			body:     `{"name":"tom"}`,
+			claims:   "tom",
+			wantCode: http.StatusUnauthorized,
+			wantBody: `{"code":4,"msg":"请先登录","data":null}`,
+		},
+		{
+			name:     "请求体不对",
+			body:     `{"name":`,
+			claims:   testClaims{Uid: 123},
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"code":4,"msg":"参数错误","data":null}`,
+		},
+		{
+			name:     "业务出错",
+			body:     `{"name":"tom"}`,
+			claims:   testClaims{Uid: 123},
+			err:      errors.New("mock error"),
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"code":5,"msg":"系统错误","data":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := gin.New()
+			server.POST("/test", func(ctx *gin.Context) {
+				if tc.claims != nil {
+					ctx.Set(ClaimsKey, tc.claims)
+				}
+			}, WrapBodyAndClaims(func(ctx *gin.Context, req testReq, uc testClaims) (Result, error) {
+				return Result{Msg: req.Name, Data: uc.Uid}, tc.err
+			}))
+
+			req, err := http.NewRequest(http.MethodPost, "/test", bytes.NewReader([]byte(tc.body)))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.JSONEq(t, tc.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestSetErrorHandler(t *testing.T) {
+	SetErrorHandler(func(ctx *gin.Context, err error) {
+		ctx.JSON(http.StatusTeapot, Result{Code: 42, Msg: err.Error()})
+	})
+	defer SetErrorHandler(defaultErrorHandler)
+
+	server := gin.New()
+	server.GET("/test", Wrap(func(ctx *gin.Context) (Result, error) {
+		return Result{}, errors.New("mock error")
+	}))
+	req, err := http.NewRequest(http.MethodGet, "/test", nil)
+	assert.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusTeapot, recorder.Code)
+	assert.JSONEq(t, `{"code":42,"msg":"mock error","data":null}`, recorder.Body.String())
+}
+
+func TestSetResultHandler(t *testing.T) {
+	SetResultHandler(func(ctx *gin.Context, res Result) {
+		ctx.String(http.StatusOK, res.Msg)
+	})
+	defer SetResultHandler(defaultResultHandler)
+
+	server := gin.New()
+	server.GET("/test", Wrap(func(ctx *gin.Context) (Result, error) {
+		return Result{Msg: "成功"}, nil
+	}))
+	req, err := http.NewRequest(http.MethodGet, "/test", nil)
+	assert.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "成功", recorder.Body.String())
+}
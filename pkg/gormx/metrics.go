@@ -0,0 +1,77 @@
+package gormx
+
+import (
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+	"time"
+	"webBook/pkg/prometheusx"
+)
+
+// MetricsPlugin 按照操作和表统计 SQL 的执行时间。
+// 连接池的状态用 collectors.NewDBStatsCollector 就可以了
+type MetricsPlugin struct {
+	duration *prometheus.HistogramVec
+}
+
+var _ gorm.Plugin = &MetricsPlugin{}
+
+func NewMetricsPlugin(namespace, instanceID string) *MetricsPlugin {
+	return &MetricsPlugin{
+		duration: prometheusx.MustRegister(prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Subsystem:   "gorm",
+			Name:        "query_duration_seconds",
+			Help:        "SQL 执行时间",
+			ConstLabels: prometheus.Labels{"instance_id": instanceID},
+			Buckets:     []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+		}, []string{"op", "table", "status"})),
+	}
+}
+
+func (p *MetricsPlugin) Name() string {
+	return "metrics"
+}
+
+func (p *MetricsPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("metrics:before_create", p.before),
+		cb.Create().After("gorm:create").Register("metrics:after_create", p.after("INSERT")),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", p.before),
+		cb.Query().After("gorm:query").Register("metrics:after_query", p.after("SELECT")),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", p.before),
+		cb.Update().After("gorm:update").Register("metrics:after_update", p.after("UPDATE")),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", p.before),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", p.after("DELETE")),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", p.before),
+		cb.Row().After("gorm:row").Register("metrics:after_row", p.after("ROW")),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", p.before),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", p.after("RAW")),
+	)
+}
+
+const startKey = "metrics:start"
+
+func (p *MetricsPlugin) before(tx *gorm.DB) {
+	tx.InstanceSet(startKey, time.Now())
+}
+
+func (p *MetricsPlugin) after(op string) func(tx *gorm.DB) {
+	return func(tx *gorm.DB) {
+		val, ok := tx.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+		table := tx.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		status := "success"
+		if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			status = "error"
+		}
+		p.duration.WithLabelValues(op, table, status).
+			Observe(time.Since(val.(time.Time)).Seconds())
+	}
+}
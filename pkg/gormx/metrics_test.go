@@ -0,0 +1,38 @@
+package gormx
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestMetricsPlugin(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+	plugin := NewMetricsPlugin("test", "1")
+	require.NoError(t, db.Use(plugin))
+
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Tom"))
+	mock.ExpectExec("INSERT .*").WillReturnError(assert.AnError)
+
+	var u User
+	require.NoError(t, db.Where("id = ?", 1).First(&u).Error)
+	require.Error(t, db.Create(&User{Name: "Jerry"}).Error)
+
+	// SELECT users success 和 INSERT users error 各一个
+	assert.Equal(t, 2, testutil.CollectAndCount(plugin.duration))
+	assert.True(t, plugin.duration.DeleteLabelValues("SELECT", "users", "success"))
+	assert.True(t, plugin.duration.DeleteLabelValues("INSERT", "users", "error"))
+}
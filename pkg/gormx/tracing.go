@@ -0,0 +1,80 @@
+package gormx
+
+import (
+	"errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const instrumentationName = "webBook/pkg/gormx"
+
+// TracingPlugin 每一条 SQL 一个 client span，只记录带占位符的 SQL，不记录参数
+type TracingPlugin struct {
+	tracer trace.Tracer
+}
+
+var _ gorm.Plugin = &TracingPlugin{}
+
+func NewTracingPlugin() *TracingPlugin {
+	return &TracingPlugin{
+		tracer: otel.Tracer(instrumentationName),
+	}
+}
+
+func (p *TracingPlugin) Name() string {
+	return "tracing"
+}
+
+func (p *TracingPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("tracing:before_create", p.before("INSERT")),
+		cb.Create().After("gorm:create").Register("tracing:after_create", p.after("INSERT")),
+		cb.Query().Before("gorm:query").Register("tracing:before_query", p.before("SELECT")),
+		cb.Query().After("gorm:query").Register("tracing:after_query", p.after("SELECT")),
+		cb.Update().Before("gorm:update").Register("tracing:before_update", p.before("UPDATE")),
+		cb.Update().After("gorm:update").Register("tracing:after_update", p.after("UPDATE")),
+		cb.Delete().Before("gorm:delete").Register("tracing:before_delete", p.before("DELETE")),
+		cb.Delete().After("gorm:delete").Register("tracing:after_delete", p.after("DELETE")),
+		cb.Row().Before("gorm:row").Register("tracing:before_row", p.before("ROW")),
+		cb.Row().After("gorm:row").Register("tracing:after_row", p.after("ROW")),
+		cb.Raw().Before("gorm:raw").Register("tracing:before_raw", p.before("RAW")),
+		cb.Raw().After("gorm:raw").Register("tracing:after_raw", p.after("RAW")),
+	)
+}
+
+func (p *TracingPlugin) before(op string) func(tx *gorm.DB) {
+	return func(tx *gorm.DB) {
+		ctx, span := p.tracer.Start(tx.Statement.Context, op,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemMySQL, semconv.DBOperationName(op)))
+		tx.Statement.Context = ctx
+		tx.InstanceSet(spanKey, span)
+	}
+}
+
+const spanKey = "tracing:span"
+
+func (p *TracingPlugin) after(op string) func(tx *gorm.DB) {
+	return func(tx *gorm.DB) {
+		val, ok := tx.InstanceGet(spanKey)
+		if !ok {
+			return
+		}
+		span := val.(trace.Span)
+		defer span.End()
+		// 执行之前还不知道表名
+		if table := tx.Statement.Table; table != "" {
+			span.SetName(op + " " + table)
+			span.SetAttributes(semconv.DBCollectionName(table))
+		}
+		span.SetAttributes(semconv.DBQueryText(tx.Statement.SQL.String()))
+		if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			span.RecordError(tx.Error)
+			span.SetStatus(codes.Error, tx.Error.Error())
+		}
+	}
+}
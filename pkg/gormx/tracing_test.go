@@ -0,0 +1,57 @@
+package gormx
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"testing"
+)
+
+type User struct {
+	Id   int64
+	Name string
+}
+
+func TestTracingPlugin(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(NewTracingPlugin()))
+
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Tom"))
+	mock.ExpectExec("INSERT .*").WillReturnError(assert.AnError)
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	var u User
+	err = db.WithContext(ctx).Where("id = ?", 1).First(&u).Error
+	require.NoError(t, err)
+	err = db.WithContext(ctx).Create(&User{Name: "Jerry"}).Error
+	require.Error(t, err)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	query, insert := spans[0], spans[1]
+	assert.Equal(t, "SELECT users", query.Name)
+	assert.Equal(t, parent.SpanContext().SpanID(), query.Parent.SpanID())
+	assert.Equal(t, codes.Unset, query.Status.Code)
+	assert.Equal(t, "INSERT users", insert.Name)
+	assert.Equal(t, codes.Error, insert.Status.Code)
+}
@@ -0,0 +1,83 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"testing"
+	"time"
+)
+
+// BenchmarkLimiter 对比三种实现的延迟和每个 key 在 Redis 里面占的空间。
+// 用的是 miniredis，延迟只有相对意义，不包含网络开销
+//
+//	go test -bench=. -benchmem ./pkg/limiter/
+func BenchmarkLimiter(b *testing.B) {
+	testCases := []struct {
+		name string
+		new  func(cmd redis.Cmdable) Limiter
+	}{
+		{
+			name: "sliding window",
+			new: func(cmd redis.Cmdable) Limiter {
+				return NewRedisSlidingWindowLimiter(cmd, time.Second, 1000)
+			},
+		},
+		{
+			name: "token bucket",
+			new: func(cmd redis.Cmdable) Limiter {
+				return NewRedisTokenBucketLimiter(cmd, time.Second, 1000, 1000)
+			},
+		},
+		{
+			name: "fixed window",
+			new: func(cmd redis.Cmdable) Limiter {
+				return NewRedisFixedWindowLimiter(cmd, time.Second, 1000)
+			},
+		},
+	}
+	// 模拟 100 个 IP
+	const ips = 100
+	for _, tc := range testCases {
+		b.Run(tc.name, func(b *testing.B) {
+			m, cmd := newMiniRedis(b)
+			l := tc.new(cmd)
+			ctx := context.Background()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := l.Limit(ctx, fmt.Sprintf("ip-limiter:%d", i%ips))
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(storedBytes(m))/ips, "redis-bytes/ip")
+		})
+	}
+}
+
+// storedBytes 粗略估计 Redis 里面存了多少数据，只算 key 和 value 的长度
+func storedBytes(m *miniredis.Miniredis) int {
+	total := 0
+	for _, key := range m.Keys() {
+		total += len(key)
+		switch m.Type(key) {
+		case "zset":
+			members, _ := m.ZMembers(key)
+			for _, member := range members {
+				// score 是 8 字节
+				total += len(member) + 8
+			}
+		case "hash":
+			fields, _ := m.HKeys(key)
+			for _, field := range fields {
+				total += len(field) + len(m.HGet(key, field))
+			}
+		case "string":
+			val, _ := m.Get(key)
+			total += len(val)
+		}
+	}
+	return total
+}
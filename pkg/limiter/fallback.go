@@ -0,0 +1,26 @@
+package limiter
+
+import "context"
+
+// FallbackLimiter 优先用 primary，primary 出错的时候用 fallback。
+// 一般 primary 是 Redis 限流，fallback 是进程内的限流，
+// 这时候 fallback 的阈值要按照实例数量分摊
+type FallbackLimiter struct {
+	primary  Limiter
+	fallback Limiter
+}
+
+func NewFallbackLimiter(primary Limiter, fallback Limiter) *FallbackLimiter {
+	return &FallbackLimiter{
+		primary:  primary,
+		fallback: fallback,
+	}
+}
+
+func (f *FallbackLimiter) Limit(ctx context.Context, key string) (bool, error) {
+	limited, err := f.primary.Limit(ctx, key)
+	if err == nil {
+		return limited, nil
+	}
+	return f.fallback.Limit(ctx, key)
+}
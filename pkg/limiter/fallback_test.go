@@ -0,0 +1,62 @@
+package limiter_test
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"webBook/pkg/limiter"
+	limitermocks "webBook/pkg/limiter/mocks"
+)
+
+func TestFallbackLimiter_Limit(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (limiter.Limiter, limiter.Limiter)
+
+		wantLimited bool
+		wantErr     error
+	}{
+		{
+			name: "primary 限流",
+			mock: func(ctrl *gomock.Controller) (limiter.Limiter, limiter.Limiter) {
+				primary := limitermocks.NewMockLimiter(ctrl)
+				primary.EXPECT().Limit(gomock.Any(), "ip:1").Return(true, nil)
+				return primary, limitermocks.NewMockLimiter(ctrl)
+			},
+			wantLimited: true,
+		},
+		{
+			name: "primary 出错，fallback 不限流",
+			mock: func(ctrl *gomock.Controller) (limiter.Limiter, limiter.Limiter) {
+				primary := limitermocks.NewMockLimiter(ctrl)
+				primary.EXPECT().Limit(gomock.Any(), "ip:1").Return(false, errors.New("redis 崩了"))
+				fallback := limitermocks.NewMockLimiter(ctrl)
+				fallback.EXPECT().Limit(gomock.Any(), "ip:1").Return(false, nil)
+				return primary, fallback
+			},
+		},
+		{
+			name: "都出错",
+			mock: func(ctrl *gomock.Controller) (limiter.Limiter, limiter.Limiter) {
+				primary := limitermocks.NewMockLimiter(ctrl)
+				primary.EXPECT().Limit(gomock.Any(), "ip:1").Return(false, errors.New("redis 崩了"))
+				fallback := limitermocks.NewMockLimiter(ctrl)
+				fallback.EXPECT().Limit(gomock.Any(), "ip:1").Return(false, errors.New("也崩了"))
+				return primary, fallback
+			},
+			wantErr: errors.New("也崩了"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			primary, fallback := tc.mock(ctrl)
+			limited, err := limiter.NewFallbackLimiter(primary, fallback).Limit(context.Background(), "ip:1")
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantLimited, limited)
+		})
+	}
+}
@@ -0,0 +1,12 @@
+-- 限流对象，已经带上了窗口的编号
+local key = KEYS[1]
+-- 窗口大小
+local window = tonumber(ARGV[1])
+
+local cnt = redis.call('INCR', key)
+if cnt == 1 then
+    -- 窗口里的第一个请求
+    redis.call('PEXPIRE', key, window)
+end
+-- 是否超过阈值在客户端判断，顺便算出剩余额度
+return {cnt, redis.call('PTTL', key)}
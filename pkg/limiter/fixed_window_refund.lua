@@ -0,0 +1,13 @@
+-- 限流对象，已经带上了窗口的编号
+local key = KEYS[1]
+
+-- 窗口已经过去了，计数器也就没了，不需要退还
+if redis.call('EXISTS', key) == 0 then
+    return 0
+end
+local cnt = redis.call('DECR', key)
+if cnt < 0 then
+    redis.call('SET', key, 0, 'KEEPTTL')
+    return 0
+end
+return cnt
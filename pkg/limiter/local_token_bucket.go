@@ -0,0 +1,79 @@
+package limiter
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LocalTokenBucketLimiter 进程内的令牌桶，算法和 RedisTokenBucketLimiter 一样。
+// 最多保存 capacity 个 key，超过之后淘汰最久没有访问的，
+// 被淘汰的 key 下次访问的时候桶是满的
+type LocalTokenBucketLimiter struct {
+	interval time.Duration
+	rate     int
+	burst    int
+	capacity int
+
+	mutex sync.Mutex
+	// 越靠前越是最近访问过的
+	lru     *list.List
+	buckets map[string]*list.Element
+}
+
+type localBucket struct {
+	key    string
+	tokens float64
+	ts     time.Time
+}
+
+// NewLocalTokenBucketLimiter burst 小于等于 0 的时候和 rate 一样
+func NewLocalTokenBucketLimiter(interval time.Duration, rate int, burst int, capacity int) *LocalTokenBucketLimiter {
+	if burst <= 0 {
+		burst = rate
+	}
+	return &LocalTokenBucketLimiter{
+		interval: interval,
+		rate:     rate,
+		burst:    burst,
+		capacity: capacity,
+		lru:      list.New(),
+		buckets:  make(map[string]*list.Element, capacity),
+	}
+}
+
+func (b *LocalTokenBucketLimiter) Limit(ctx context.Context, key string) (bool, error) {
+	now := time.Now()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	elem, ok := b.buckets[key]
+	if !ok {
+		elem = b.lru.PushFront(&localBucket{key: key, tokens: float64(b.burst), ts: now})
+		b.buckets[key] = elem
+		b.evict()
+	} else {
+		b.lru.MoveToFront(elem)
+	}
+	bucket := elem.Value.(*localBucket)
+	if elapsed := now.Sub(bucket.ts); elapsed > 0 {
+		bucket.tokens += float64(b.rate) * float64(elapsed) / float64(b.interval)
+		if bucket.tokens > float64(b.burst) {
+			bucket.tokens = float64(b.burst)
+		}
+		bucket.ts = now
+	}
+	if bucket.tokens < 1 {
+		return true, nil
+	}
+	bucket.tokens--
+	return false, nil
+}
+
+func (b *LocalTokenBucketLimiter) evict() {
+	for b.lru.Len() > b.capacity {
+		elem := b.lru.Back()
+		b.lru.Remove(elem)
+		delete(b.buckets, elem.Value.(*localBucket).key)
+	}
+}
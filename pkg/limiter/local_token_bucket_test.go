@@ -0,0 +1,50 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestLocalTokenBucketLimiter_Limit(t *testing.T) {
+	l := NewLocalTokenBucketLimiter(time.Second, 10, 5, 100)
+	for i := 0; i < 5; i++ {
+		limited, err := l.Limit(context.Background(), "ip:1")
+		require.NoError(t, err)
+		assert.False(t, limited)
+	}
+	limited, err := l.Limit(context.Background(), "ip:1")
+	require.NoError(t, err)
+	assert.True(t, limited)
+
+	// 补充了一个令牌
+	time.Sleep(time.Millisecond * 150)
+	limited, err = l.Limit(context.Background(), "ip:1")
+	require.NoError(t, err)
+	assert.False(t, limited)
+	limited, err = l.Limit(context.Background(), "ip:1")
+	require.NoError(t, err)
+	assert.True(t, limited)
+}
+
+func TestLocalTokenBucketLimiter_Evict(t *testing.T) {
+	l := NewLocalTokenBucketLimiter(time.Minute, 1, 1, 3)
+	for i := 0; i < 10; i++ {
+		_, err := l.Limit(context.Background(), fmt.Sprintf("ip:%d", i))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, l.lru.Len())
+	assert.Len(t, l.buckets, 3)
+
+	// ip:9 还在，令牌已经用完了
+	limited, err := l.Limit(context.Background(), "ip:9")
+	require.NoError(t, err)
+	assert.True(t, limited)
+	// ip:0 被淘汰了，桶是满的
+	limited, err = l.Limit(context.Background(), "ip:0")
+	require.NoError(t, err)
+	assert.False(t, limited)
+}
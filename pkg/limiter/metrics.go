@@ -0,0 +1,75 @@
+package limiter
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"webBook/pkg/prometheusx"
+)
+
+// MetricsLimiter 按照限流器的名字统计放行、拒绝和出错的次数
+type MetricsLimiter struct {
+	name      string
+	l         Limiter
+	decisions *prometheus.CounterVec
+}
+
+// MetricsQuotaLimiter 同 MetricsLimiter，给按规则限流用
+type MetricsQuotaLimiter struct {
+	*MetricsLimiter
+	l QuotaLimiter
+}
+
+func NewMetricsLimiter(name string, l Limiter) *MetricsLimiter {
+	return &MetricsLimiter{
+		name: name,
+		l:    l,
+		decisions: prometheusx.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "webook",
+			Subsystem: "limiter",
+			Name:      "decisions_total",
+			Help:      "限流判断的次数，result 是 pass、limited 或者 error",
+		}, []string{"limiter", "result"})),
+	}
+}
+
+func NewMetricsQuotaLimiter(name string, l QuotaLimiter) *MetricsQuotaLimiter {
+	return &MetricsQuotaLimiter{
+		MetricsLimiter: NewMetricsLimiter(name, l),
+		l:              l,
+	}
+}
+
+func (m *MetricsLimiter) Limit(ctx context.Context, key string) (bool, error) {
+	limited, err := m.l.Limit(ctx, key)
+	m.record(limited, err)
+	return limited, err
+}
+
+// Take 被装饰的限流器不支持退还的时候就是 Limit
+func (m *MetricsLimiter) Take(ctx context.Context, key string) (Ticket, bool, error) {
+	t, limited, err := Take(ctx, m.l, key)
+	m.record(limited, err)
+	return t, limited, err
+}
+
+// Refund 被装饰的限流器支持退还才退还
+func (m *MetricsLimiter) Refund(ctx context.Context, t Ticket) error {
+	return Refund(ctx, m.l, t)
+}
+
+func (m *MetricsQuotaLimiter) Quota(ctx context.Context, key string) (Quota, error) {
+	q, err := m.l.Quota(ctx, key)
+	m.record(q.Limited, err)
+	return q, err
+}
+
+func (m *MetricsLimiter) record(limited bool, err error) {
+	result := "pass"
+	switch {
+	case err != nil:
+		result = "error"
+	case limited:
+		result = "limited"
+	}
+	m.decisions.WithLabelValues(m.name, result).Inc()
+}
@@ -0,0 +1,42 @@
+package limiter_test
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"webBook/pkg/limiter"
+	limitermocks "webBook/pkg/limiter/mocks"
+)
+
+func TestMetricsLimiter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ql := limitermocks.NewMockQuotaLimiter(ctrl)
+	ql.EXPECT().Limit(gomock.Any(), "ip:1").Return(false, nil)
+	ql.EXPECT().Limit(gomock.Any(), "ip:1").Return(true, nil)
+	ql.EXPECT().Quota(gomock.Any(), "ip:1").Return(limiter.Quota{Limited: true}, nil)
+	ql.EXPECT().Quota(gomock.Any(), "ip:1").Return(limiter.Quota{}, errors.New("redis 崩了"))
+
+	l := limiter.NewMetricsQuotaLimiter("metrics-test", ql)
+	ctx := context.Background()
+	_, _ = l.Limit(ctx, "ip:1")
+	_, _ = l.Limit(ctx, "ip:1")
+	_, _ = l.Quota(ctx, "ip:1")
+	_, _ = l.Quota(ctx, "ip:1")
+
+	expected := `
+# HELP webook_limiter_decisions_total 限流判断的次数，result 是 pass、limited 或者 error
+# TYPE webook_limiter_decisions_total counter
+webook_limiter_decisions_total{limiter="metrics-test",result="error"} 1
+webook_limiter_decisions_total{limiter="metrics-test",result="limited"} 2
+webook_limiter_decisions_total{limiter="metrics-test",result="pass"} 1
+`
+	err := testutil.GatherAndCompare(prometheus.DefaultGatherer, strings.NewReader(expected),
+		"webook_limiter_decisions_total")
+	assert.NoError(t, err)
+}
@@ -7,6 +7,7 @@ package limitermocks
 import (
 	context "context"
 	reflect "reflect"
+	limiter "webBook/pkg/limiter"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -48,3 +49,109 @@ func (mr *MockLimiterMockRecorder) Limit(ctx, key interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Limit", reflect.TypeOf((*MockLimiter)(nil).Limit), ctx, key)
 }
+
+// MockRefunder is a mock of Refunder interface.
+type MockRefunder struct {
+	ctrl     *gomock.Controller
+	recorder *MockRefunderMockRecorder
+}
+
+// MockRefunderMockRecorder is the mock recorder for MockRefunder.
+type MockRefunderMockRecorder struct {
+	mock *MockRefunder
+}
+
+// NewMockRefunder creates a new mock instance.
+func NewMockRefunder(ctrl *gomock.Controller) *MockRefunder {
+	mock := &MockRefunder{ctrl: ctrl}
+	mock.recorder = &MockRefunderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRefunder) EXPECT() *MockRefunderMockRecorder {
+	return m.recorder
+}
+
+// Refund mocks base method.
+func (m *MockRefunder) Refund(ctx context.Context, t limiter.Ticket) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refund", ctx, t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Refund indicates an expected call of Refund.
+func (mr *MockRefunderMockRecorder) Refund(ctx, t interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refund", reflect.TypeOf((*MockRefunder)(nil).Refund), ctx, t)
+}
+
+// Take mocks base method.
+func (m *MockRefunder) Take(ctx context.Context, key string) (limiter.Ticket, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Take", ctx, key)
+	ret0, _ := ret[0].(limiter.Ticket)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Take indicates an expected call of Take.
+func (mr *MockRefunderMockRecorder) Take(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Take", reflect.TypeOf((*MockRefunder)(nil).Take), ctx, key)
+}
+
+// MockQuotaLimiter is a mock of QuotaLimiter interface.
+type MockQuotaLimiter struct {
+	ctrl     *gomock.Controller
+	recorder *MockQuotaLimiterMockRecorder
+}
+
+// MockQuotaLimiterMockRecorder is the mock recorder for MockQuotaLimiter.
+type MockQuotaLimiterMockRecorder struct {
+	mock *MockQuotaLimiter
+}
+
+// NewMockQuotaLimiter creates a new mock instance.
+func NewMockQuotaLimiter(ctrl *gomock.Controller) *MockQuotaLimiter {
+	mock := &MockQuotaLimiter{ctrl: ctrl}
+	mock.recorder = &MockQuotaLimiterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockQuotaLimiter) EXPECT() *MockQuotaLimiterMockRecorder {
+	return m.recorder
+}
+
+// Limit mocks base method.
+func (m *MockQuotaLimiter) Limit(ctx context.Context, key string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Limit", ctx, key)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Limit indicates an expected call of Limit.
+func (mr *MockQuotaLimiterMockRecorder) Limit(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Limit", reflect.TypeOf((*MockQuotaLimiter)(nil).Limit), ctx, key)
+}
+
+// Quota mocks base method.
+func (m *MockQuotaLimiter) Quota(ctx context.Context, key string) (limiter.Quota, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Quota", ctx, key)
+	ret0, _ := ret[0].(limiter.Quota)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Quota indicates an expected call of Quota.
+func (mr *MockQuotaLimiterMockRecorder) Quota(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Quota", reflect.TypeOf((*MockQuotaLimiter)(nil).Quota), ctx, key)
+}
@@ -0,0 +1,98 @@
+package limiter
+
+import (
+	"context"
+	_ "embed"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+//go:embed fixed_window.lua
+var luaFixedWindow string
+
+//go:embed fixed_window_refund.lua
+var luaFixedWindowRefund string
+
+var _ Refunder = &RedisFixedWindowLimiter{}
+
+// RedisFixedWindowLimiter 固定窗口，每个窗口只有一个计数器，最省内存。
+// 窗口交界处最多会放行 2 * rate 个请求，相当于自带了突发
+type RedisFixedWindowLimiter struct {
+	cmd redis.Cmdable
+	// window 当前时间所在的窗口编号，以及窗口的计数器多久过期
+	window func(now time.Time) (string, time.Duration)
+	// 阈值
+	rate int
+	now  func() time.Time
+}
+
+func NewRedisFixedWindowLimiter(cmd redis.Cmdable, interval time.Duration, rate int) *RedisFixedWindowLimiter {
+	return &RedisFixedWindowLimiter{
+		cmd: cmd,
+		window: func(now time.Time) (string, time.Duration) {
+			return strconv.FormatInt(now.UnixMilli()/interval.Milliseconds(), 10), interval
+		},
+		rate: rate,
+		now:  time.Now,
+	}
+}
+
+// NewRedisDailyLimiter 按照 loc 时区的自然日计数，零点重置，适合每日额度。
+// key 上带着日期，例如 code_quota:phone:xxx:20240101
+func NewRedisDailyLimiter(cmd redis.Cmdable, loc *time.Location, rate int) *RedisFixedWindowLimiter {
+	return &RedisFixedWindowLimiter{
+		cmd: cmd,
+		window: func(now time.Time) (string, time.Duration) {
+			now = now.In(loc)
+			y, m, d := now.Date()
+			tomorrow := time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+			return now.Format("20060102"), tomorrow.Sub(now)
+		},
+		rate: rate,
+		now:  time.Now,
+	}
+}
+
+func (b *RedisFixedWindowLimiter) Limit(ctx context.Context, key string) (bool, error) {
+	q, err := b.Quota(ctx, key)
+	return q.Limited, err
+}
+
+func (b *RedisFixedWindowLimiter) Quota(ctx context.Context, key string) (Quota, error) {
+	q, _, err := b.quota(ctx, key)
+	return q, err
+}
+
+// Take 同 Limit，Ticket 上记着这次计数的窗口
+func (b *RedisFixedWindowLimiter) Take(ctx context.Context, key string) (Ticket, bool, error) {
+	q, idx, err := b.quota(ctx, key)
+	return Ticket{Key: key, Window: idx}, q.Limited, err
+}
+
+// Refund 退还占用时那个窗口的一次计数，窗口已经过去了就什么都不做
+func (b *RedisFixedWindowLimiter) Refund(ctx context.Context, t Ticket) error {
+	return b.cmd.Eval(ctx, luaFixedWindowRefund, []string{t.Key + ":" + t.Window}).Err()
+}
+
+// quota 返回的 idx 是计数的窗口编号
+func (b *RedisFixedWindowLimiter) quota(ctx context.Context, key string) (Quota, string, error) {
+	// key 上带着窗口编号，过期了自然就是下一个窗口
+	idx, expiration := b.window(b.now())
+	window := expiration.Milliseconds()
+	res, err := b.cmd.Eval(ctx, luaFixedWindow, []string{key + ":" + idx},
+		window).Int64Slice()
+	if err != nil {
+		return Quota{}, "", err
+	}
+	cnt, ttl := res[0], res[1]
+	if ttl < 0 {
+		ttl = window
+	}
+	return Quota{
+		Limited:    cnt > int64(b.rate),
+		Limit:      b.rate,
+		Remaining:  max(b.rate-int(cnt), 0),
+		ResetAfter: time.Duration(ttl) * time.Millisecond,
+	}, idx, nil
+}
@@ -3,7 +3,9 @@ package limiter
 import (
 	"context"
 	_ "embed"
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
+	"strconv"
 	"time"
 )
 
@@ -26,6 +28,9 @@ func NewRedisSlidingWindowLimiter(cmd redis.Cmdable, interval time.Duration, rat
 }
 
 func (b *RedisSlidingWindowLimiter) Limit(ctx context.Context, key string) (bool, error) {
+	now := time.Now().UnixMilli()
+	// 每个请求都会在 ZSET 里面占一个 member，高 QPS 的场景考虑令牌桶或者固定窗口
+	member := strconv.FormatInt(now, 10) + ":" + uuid.NewString()
 	return b.cmd.Eval(ctx, luaScript, []string{key},
-		b.interval.Milliseconds(), b.rate, time.Now().UnixMilli()).Bool()
+		b.interval.Milliseconds(), b.rate, now, member).Bool()
 }
@@ -0,0 +1,148 @@
+package limiter
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newMiniRedis(t testing.TB) (*miniredis.Miniredis, redis.Cmdable) {
+	m := miniredis.RunT(t)
+	return m, redis.NewClient(&redis.Options{Addr: m.Addr()})
+}
+
+func TestRedisSlidingWindowLimiter_Limit(t *testing.T) {
+	m, cmd := newMiniRedis(t)
+	l := NewRedisSlidingWindowLimiter(cmd, time.Minute, 100)
+	// 这些请求大部分都落在同一毫秒，member 不能互相覆盖
+	for i := 0; i < 100; i++ {
+		limited, err := l.Limit(context.Background(), "ip:1")
+		require.NoError(t, err)
+		assert.False(t, limited)
+	}
+	members, err := m.ZMembers("ip:1")
+	require.NoError(t, err)
+	assert.Len(t, members, 100)
+
+	limited, err := l.Limit(context.Background(), "ip:1")
+	require.NoError(t, err)
+	assert.True(t, limited)
+}
+
+func TestRedisTokenBucketLimiter_Limit(t *testing.T) {
+	_, cmd := newMiniRedis(t)
+	// 平均每 100ms 一个，允许 5 个突发
+	l := NewRedisTokenBucketLimiter(cmd, time.Second, 10, 5)
+	for i := 0; i < 5; i++ {
+		limited, err := l.Limit(context.Background(), "ip:1")
+		require.NoError(t, err)
+		assert.False(t, limited)
+	}
+	limited, err := l.Limit(context.Background(), "ip:1")
+	require.NoError(t, err)
+	assert.True(t, limited)
+
+	// 别的 key 不受影响
+	limited, err = l.Limit(context.Background(), "ip:2")
+	require.NoError(t, err)
+	assert.False(t, limited)
+
+	// 补充了一个令牌
+	time.Sleep(time.Millisecond * 150)
+	limited, err = l.Limit(context.Background(), "ip:1")
+	require.NoError(t, err)
+	assert.False(t, limited)
+	limited, err = l.Limit(context.Background(), "ip:1")
+	require.NoError(t, err)
+	assert.True(t, limited)
+}
+
+func TestRedisFixedWindowLimiter_Limit(t *testing.T) {
+	m, cmd := newMiniRedis(t)
+	l := NewRedisFixedWindowLimiter(cmd, time.Hour, 5)
+	for i := 0; i < 5; i++ {
+		limited, err := l.Limit(context.Background(), "ip:1")
+		require.NoError(t, err)
+		assert.False(t, limited)
+	}
+	limited, err := l.Limit(context.Background(), "ip:1")
+	require.NoError(t, err)
+	assert.True(t, limited)
+	// 一个窗口只有一个计数器
+	assert.Len(t, m.Keys(), 1)
+}
+
+func TestRedisDailyLimiter_Limit(t *testing.T) {
+	m, cmd := newMiniRedis(t)
+	loc := time.FixedZone("UTC+8", 8*3600)
+	l := NewRedisDailyLimiter(cmd, loc, 2)
+	for i := 0; i < 2; i++ {
+		limited, err := l.Limit(context.Background(), "phone:1")
+		require.NoError(t, err)
+		assert.False(t, limited)
+	}
+	limited, err := l.Limit(context.Background(), "phone:1")
+	require.NoError(t, err)
+	assert.True(t, limited)
+
+	// key 上带着当地的日期，零点过期
+	key := "phone:1:" + time.Now().In(loc).Format("20060102")
+	assert.Equal(t, []string{key}, m.Keys())
+	assert.LessOrEqual(t, m.TTL(key), time.Hour*24)
+
+	// 退还之后又可以用了
+	ticket := Ticket{Key: "phone:1", Window: time.Now().In(loc).Format("20060102")}
+	require.NoError(t, l.Refund(context.Background(), ticket))
+	require.NoError(t, l.Refund(context.Background(), ticket))
+	limited, err = l.Limit(context.Background(), "phone:1")
+	require.NoError(t, err)
+	assert.False(t, limited)
+}
+
+func TestRedisFixedWindowLimiter_Refund(t *testing.T) {
+	m, cmd := newMiniRedis(t)
+	l := NewRedisFixedWindowLimiter(cmd, time.Hour, 1)
+	// 窗口里面没有计数，不会退成负数
+	require.NoError(t, l.Refund(context.Background(), Ticket{Key: "ip:1", Window: "1"}))
+	assert.Empty(t, m.Keys())
+
+	ticket, limited, err := l.Take(context.Background(), "ip:1")
+	require.NoError(t, err)
+	assert.False(t, limited)
+	require.NoError(t, l.Refund(context.Background(), ticket))
+	limited, err = l.Limit(context.Background(), "ip:1")
+	require.NoError(t, err)
+	assert.False(t, limited)
+	limited, err = l.Limit(context.Background(), "ip:1")
+	require.NoError(t, err)
+	assert.True(t, limited)
+}
+
+func TestRedisDailyLimiter_RefundAfterMidnight(t *testing.T) {
+	_, cmd := newMiniRedis(t)
+	loc := time.FixedZone("UTC+8", 8*3600)
+	l := NewRedisDailyLimiter(cmd, loc, 1)
+	now := time.Date(2024, 1, 1, 23, 59, 59, 0, loc)
+	l.now = func() time.Time {
+		return now
+	}
+	ticket, limited, err := l.Take(context.Background(), "phone:1")
+	require.NoError(t, err)
+	assert.False(t, limited)
+	assert.Equal(t, "20240101", ticket.Window)
+
+	// 第二天用掉了额度之后，才退还前一天的
+	now = now.Add(time.Second)
+	limited, err = l.Limit(context.Background(), "phone:1")
+	require.NoError(t, err)
+	assert.False(t, limited)
+	require.NoError(t, l.Refund(context.Background(), ticket))
+	// 退还的是前一天的，第二天的额度不会变多
+	limited, err = l.Limit(context.Background(), "phone:1")
+	require.NoError(t, err)
+	assert.True(t, limited)
+}
@@ -0,0 +1,41 @@
+package limiter
+
+import (
+	"context"
+	_ "embed"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+//go:embed token_bucket.lua
+var luaTokenBucket string
+
+// RedisTokenBucketLimiter 令牌桶，每个 key 只占一个 hash，
+// 平均每个 interval 放行 rate 个请求，最多允许 burst 个请求的突发
+type RedisTokenBucketLimiter struct {
+	cmd      redis.Cmdable
+	interval time.Duration
+	rate     int
+	// 桶的容量
+	burst int
+}
+
+// NewRedisTokenBucketLimiter burst 小于等于 0 的时候和 rate 一样
+func NewRedisTokenBucketLimiter(cmd redis.Cmdable, interval time.Duration, rate int, burst int) *RedisTokenBucketLimiter {
+	if burst <= 0 {
+		burst = rate
+	}
+	return &RedisTokenBucketLimiter{
+		cmd:      cmd,
+		interval: interval,
+		rate:     rate,
+		burst:    burst,
+	}
+}
+
+func (b *RedisTokenBucketLimiter) Limit(ctx context.Context, key string) (bool, error) {
+	// 每毫秒生成的令牌数
+	perMilli := float64(b.rate) / float64(b.interval.Milliseconds())
+	return b.cmd.Eval(ctx, luaTokenBucket, []string{key},
+		perMilli, b.burst, time.Now().UnixMilli()).Bool()
+}
@@ -9,6 +9,8 @@ local window = tonumber(ARGV[1])
 -- 阈值
 local threshold = tonumber( ARGV[2])
 local now = tonumber(ARGV[3])
+-- 每个请求唯一的 member，同一毫秒内的请求用 now 做 member 会互相覆盖
+local member = ARGV[4]
 -- 窗口的起始时间
 local min = now - window
 
@@ -19,8 +21,8 @@ if cnt >= threshold then
     -- 执行限流
     return "true"
 else
-    -- 把 score 和 member 都设置成 now
-    redis.call('ZADD', key, now, now)
+    -- score 是 now，member 要唯一
+    redis.call('ZADD', key, now, member)
     redis.call('PEXPIRE', key, window)
     return "false"
-end
\ No newline at end of file
+end
@@ -0,0 +1,29 @@
+-- 限流对象
+local key = KEYS[1]
+-- 每毫秒生成多少个令牌
+local rate = tonumber(ARGV[1])
+-- 桶的容量，也就是允许的突发流量
+local capacity = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call('HMGET', key, 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+    -- 第一次访问，桶是满的
+    tokens = capacity
+    ts = now
+end
+-- 时钟回拨的时候不补充令牌
+if now > ts then
+    tokens = math.min(capacity, tokens + (now - ts) * rate)
+    ts = now
+end
+if tokens < 1 then
+    -- 执行限流，不用写回去，下次还是从 ts 开始算
+    return "true"
+end
+redis.call('HSET', key, 'tokens', tokens - 1, 'ts', ts)
+-- 桶装满之后这个 key 和不存在是一样的
+redis.call('PEXPIRE', key, math.ceil(capacity / rate))
+return "false"
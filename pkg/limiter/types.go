@@ -1,9 +1,60 @@
 package limiter
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Limiter interface {
 	// Limit 是否触发限流
 	// 返回 true，就是触发限流
 	Limit(ctx context.Context, key string) (bool, error)
 }
+
+// Ticket 占用的一次额度，Window 是占用时所在的窗口，退还的时候要还到这个窗口
+type Ticket struct {
+	Key    string
+	Window string
+}
+
+// Refunder 可以退还额度的限流器。Take 和 Limit 一样占用额度，同时返回这次占用的 Ticket，
+// 请求被后面的检查拒绝了或者执行失败了，就用 Ticket 把额度还回去。
+// 窗口跟着 Ticket 走，跨过窗口边界才退还，也不会还到下一个窗口里面
+type Refunder interface {
+	Take(ctx context.Context, key string) (Ticket, bool, error)
+	Refund(ctx context.Context, t Ticket) error
+}
+
+// Take l 支持退还就通过 Take 占用，不支持的和 Limit 一样
+func Take(ctx context.Context, l Limiter, key string) (Ticket, bool, error) {
+	if r, ok := l.(Refunder); ok {
+		return r.Take(ctx, key)
+	}
+	limited, err := l.Limit(ctx, key)
+	return Ticket{Key: key}, limited, err
+}
+
+// Refund l 支持退还就退还，不支持的什么都不做
+func Refund(ctx context.Context, l Limiter, t Ticket) error {
+	r, ok := l.(Refunder)
+	if !ok {
+		return nil
+	}
+	return r.Refund(ctx, t)
+}
+
+// Quota 一次限流判断之后的额度
+type Quota struct {
+	Limited bool
+	Limit   int
+	// Remaining 这次请求之后还剩多少
+	Remaining int
+	// ResetAfter 多久之后额度恢复
+	ResetAfter time.Duration
+}
+
+// QuotaLimiter 除了是否限流，还能告诉调用者剩余的额度，用来返回 X-RateLimit-* 这些响应头
+type QuotaLimiter interface {
+	Limiter
+	Quota(ctx context.Context, key string) (Quota, error)
+}
@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type loggerKey struct{}
+
+var defaultLogger atomic.Pointer[LoggerV1]
+
+func init() {
+	SetDefault(NewNopLogger())
+}
+
+// SetDefault 设置 ctx 里面没有 logger 的时候用的 logger，启动的时候调用
+func SetDefault(l LoggerV1) {
+	defaultLogger.Store(&l)
+}
+
+// WithContext 把请求级别的 logger 放进 ctx，一般是带着请求 ID 和 uid 的
+func WithContext(ctx context.Context, l LoggerV1) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext 取出 WithContext 放进去的 logger，没有的话返回默认的 logger
+func FromContext(ctx context.Context) LoggerV1 {
+	if l, ok := ctx.Value(loggerKey{}).(LoggerV1); ok {
+		return l
+	}
+	return *defaultLogger.Load()
+}
@@ -0,0 +1,151 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// Levels 每个名字的 logger 单独的日志级别，可以在运行的时候修改。
+// 名字按照 . 分段匹配，例如配置了 sms 之后，sms.tencent 也用这个级别
+type Levels struct {
+	cfg atomic.Pointer[levelConfig]
+}
+
+type levelConfig struct {
+	def   zapcore.Level
+	named map[string]zapcore.Level
+	// min 所有级别里面最低的，用来快速判断
+	min zapcore.Level
+}
+
+func NewLevels(def zapcore.Level) *Levels {
+	l := &Levels{}
+	l.cfg.Store(&levelConfig{def: def, min: def})
+	return l
+}
+
+// Set 替换全部的级别，named 的 key 是 logger 的名字
+func (l *Levels) Set(def string, named map[string]string) error {
+	cfg := &levelConfig{named: make(map[string]zapcore.Level, len(named))}
+	if err := cfg.def.UnmarshalText([]byte(def)); err != nil {
+		return err
+	}
+	cfg.min = cfg.def
+	for name, text := range named {
+		var lvl zapcore.Level
+		if err := lvl.UnmarshalText([]byte(text)); err != nil {
+			return fmt.Errorf("logger %s 的级别 %s 不对 %w", name, text, err)
+		}
+		cfg.named[name] = lvl
+		cfg.min = min(cfg.min, lvl)
+	}
+	l.cfg.Store(cfg)
+	return nil
+}
+
+// SetLevel 只修改一个名字的级别，name 为空就是修改默认级别
+func (l *Levels) SetLevel(name, text string) error {
+	var lvl zapcore.Level
+	if err := lvl.UnmarshalText([]byte(text)); err != nil {
+		return err
+	}
+	old := l.cfg.Load()
+	cfg := &levelConfig{def: old.def, named: make(map[string]zapcore.Level, len(old.named)+1)}
+	for k, v := range old.named {
+		cfg.named[k] = v
+	}
+	if name == "" {
+		cfg.def = lvl
+	} else {
+		cfg.named[name] = lvl
+	}
+	cfg.min = cfg.def
+	for _, v := range cfg.named {
+		cfg.min = min(cfg.min, v)
+	}
+	l.cfg.Store(cfg)
+	return nil
+}
+
+// Level 名字是 name 的 logger 的级别，从最长的前缀开始找
+func (l *Levels) Level(name string) zapcore.Level {
+	cfg := l.cfg.Load()
+	for name != "" {
+		if lvl, ok := cfg.named[name]; ok {
+			return lvl
+		}
+		idx := strings.LastIndexByte(name, '.')
+		if idx < 0 {
+			break
+		}
+		name = name[:idx]
+	}
+	return cfg.def
+}
+
+// levelsResp 管理接口的请求和响应
+type levelsResp struct {
+	Level  string            `json:"level"`
+	Levels map[string]string `json:"levels,omitempty"`
+}
+
+type levelReq struct {
+	Name  string `json:"name"`
+	Level string `json:"level"`
+}
+
+// ServeHTTP 管理接口，GET 查询当前的级别，PUT {"name":"sms","level":"debug"} 修改级别
+func (l *Levels) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req levelReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := l.SetLevel(req.Name, req.Level); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	cfg := l.cfg.Load()
+	resp := levelsResp{Level: cfg.def.String(), Levels: make(map[string]string, len(cfg.named))}
+	for k, v := range cfg.named {
+		resp.Levels[k] = v.String()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// NewLevelCore 按照 logger 的名字过滤日志，core 自己就不要再过滤级别了
+func NewLevelCore(core zapcore.Core, levels *Levels) zapcore.Core {
+	return &levelCore{Core: core, levels: levels}
+}
+
+type levelCore struct {
+	zapcore.Core
+	levels *Levels
+}
+
+func (c *levelCore) Enabled(lvl zapcore.Level) bool {
+	return lvl >= c.levels.cfg.Load().min
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), levels: c.levels}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level < c.levels.Level(ent.LoggerName) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
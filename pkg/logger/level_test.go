@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLevels_Level(t *testing.T) {
+	levels := NewLevels(zapcore.InfoLevel)
+	require.NoError(t, levels.Set("warn", map[string]string{
+		"sms":       "debug",
+		"sms.local": "error",
+	}))
+	assert.Equal(t, zapcore.WarnLevel, levels.Level(""))
+	assert.Equal(t, zapcore.WarnLevel, levels.Level("gorm"))
+	assert.Equal(t, zapcore.DebugLevel, levels.Level("sms"))
+	assert.Equal(t, zapcore.DebugLevel, levels.Level("sms.tencent"))
+	assert.Equal(t, zapcore.ErrorLevel, levels.Level("sms.local"))
+	assert.Equal(t, zapcore.WarnLevel, levels.Level("smsx"))
+
+	// 级别不对，继续用原本的
+	assert.Error(t, levels.Set("abc", nil))
+	assert.Equal(t, zapcore.DebugLevel, levels.Level("sms"))
+}
+
+func TestLevelCore(t *testing.T) {
+	levels := NewLevels(zapcore.InfoLevel)
+	require.NoError(t, levels.Set("info", map[string]string{"gorm": "warn", "sms": "debug"}))
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := NewZapLogger(zap.New(NewLevelCore(core, levels)))
+
+	l.Debug("default debug")
+	l.Info("default info")
+	l.Named("gorm").Info("gorm info")
+	l.Named("gorm").Warn("gorm warn")
+	l.Named("sms").Named("tencent").With(Field{Key: "a", Val: 1}).Debug("sms debug")
+
+	var msgs []string
+	for _, e := range logs.All() {
+		msgs = append(msgs, e.Message)
+	}
+	assert.Equal(t, []string{"default info", "gorm warn", "sms debug"}, msgs)
+}
+
+func TestLevels_ServeHTTP(t *testing.T) {
+	levels := NewLevels(zapcore.InfoLevel)
+	req := httptest.NewRequest(http.MethodPut, "/log/level", strings.NewReader(`{"name":"gorm","level":"debug"}`))
+	recorder := httptest.NewRecorder()
+	levels.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"level":"info","levels":{"gorm":"debug"}}`, recorder.Body.String())
+	assert.Equal(t, zapcore.DebugLevel, levels.Level("gorm"))
+
+	req = httptest.NewRequest(http.MethodPut, "/log/level", strings.NewReader(`{"level":"abc"}`))
+	recorder = httptest.NewRecorder()
+	levels.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
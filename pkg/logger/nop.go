@@ -0,0 +1,26 @@
+package logger
+
+// NopLogger 什么都不打，测试里面用
+type NopLogger struct{}
+
+var _ LoggerV1 = NopLogger{}
+
+func NewNopLogger() LoggerV1 {
+	return NopLogger{}
+}
+
+func (n NopLogger) Debug(msg string, args ...Field) {}
+
+func (n NopLogger) Info(msg string, args ...Field) {}
+
+func (n NopLogger) Warn(msg string, args ...Field) {}
+
+func (n NopLogger) Error(msg string, args ...Field) {}
+
+func (n NopLogger) With(args ...Field) LoggerV1 {
+	return n
+}
+
+func (n NopLogger) Named(name string) LoggerV1 {
+	return n
+}
@@ -5,6 +5,11 @@ type LoggerV1 interface {
 	Info(msg string, args ...Field)
 	Warn(msg string, args ...Field)
 	Error(msg string, args ...Field)
+	// With 返回带着 args 的 logger，之后每一条日志都会带上
+	With(args ...Field) LoggerV1
+	// Named 返回名字是 name 的子 logger，可以给每个名字单独设置日志级别，
+	// 多次调用会用 . 连起来，例如 sms.tencent
+	Named(name string) LoggerV1
 }
 
 type Field struct {
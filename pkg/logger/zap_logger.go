@@ -26,6 +26,14 @@ func (z *ZapLogger) Error(msg string, args ...Field) {
 	z.l.Error(msg, z.toArgs(args)...)
 }
 
+func (z *ZapLogger) With(args ...Field) LoggerV1 {
+	return &ZapLogger{l: z.l.With(z.toArgs(args)...)}
+}
+
+func (z *ZapLogger) Named(name string) LoggerV1 {
+	return &ZapLogger{l: z.l.Named(name)}
+}
+
 func (z *ZapLogger) toArgs(args []Field) []zap.Field {
 	res := make([]zap.Field, 0, len(args))
 	for _, arg := range args {
@@ -0,0 +1,47 @@
+package phonex
+
+import (
+	"errors"
+	"github.com/nyaruka/phonenumbers"
+)
+
+var (
+	ErrInvalidPhone       = errors.New("非法的手机号码")
+	ErrRegionNotSupported = errors.New("不支持该地区的手机号码")
+)
+
+// Parser 把用户输入的手机号码统一成 E.164 格式，例如 +8613812345678
+// 没有带国家码的号码，按照 defaultRegion 来解析
+type Parser struct {
+	defaultRegion string
+	// 允许的地区，为空代表不限制
+	regions map[string]struct{}
+}
+
+func NewParser(defaultRegion string, regions ...string) *Parser {
+	p := &Parser{
+		defaultRegion: defaultRegion,
+		regions:       make(map[string]struct{}, len(regions)),
+	}
+	for _, r := range regions {
+		p.regions[r] = struct{}{}
+	}
+	return p
+}
+
+// Normalize 解析并校验号码，返回 E.164 格式
+func (p *Parser) Normalize(phone string) (string, error) {
+	num, err := phonenumbers.Parse(phone, p.defaultRegion)
+	if err != nil {
+		return "", ErrInvalidPhone
+	}
+	if !phonenumbers.IsValidNumber(num) {
+		return "", ErrInvalidPhone
+	}
+	if len(p.regions) > 0 {
+		if _, ok := p.regions[phonenumbers.GetRegionCodeForNumber(num)]; !ok {
+			return "", ErrRegionNotSupported
+		}
+	}
+	return phonenumbers.Format(num, phonenumbers.E164), nil
+}
@@ -0,0 +1,64 @@
+package phonex
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParser_Normalize(t *testing.T) {
+	testCases := []struct {
+		name    string
+		regions []string
+		phone   string
+
+		wantPhone string
+		wantErr   error
+	}{
+		{
+			name:      "没有国家码，按照默认地区解析",
+			phone:     "13812345678",
+			wantPhone: "+8613812345678",
+		},
+		{
+			name:      "带国家码和空格",
+			phone:     "+86 138 1234 5678",
+			wantPhone: "+8613812345678",
+		},
+		{
+			name:      "国际号码",
+			phone:     "+1 (650) 253-0000",
+			wantPhone: "+16502530000",
+		},
+		{
+			name:    "不是号码",
+			phone:   "abc",
+			wantErr: ErrInvalidPhone,
+		},
+		{
+			name:    "号码位数不对",
+			phone:   "1381234",
+			wantErr: ErrInvalidPhone,
+		},
+		{
+			name:    "不支持的地区",
+			regions: []string{"CN", "HK"},
+			phone:   "+16502530000",
+			wantErr: ErrRegionNotSupported,
+		},
+		{
+			name:      "支持的地区",
+			regions:   []string{"CN", "HK"},
+			phone:     "+852 6123 4567",
+			wantPhone: "+85261234567",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewParser("CN", tc.regions...)
+			phone, err := p.Normalize(tc.phone)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantPhone, phone)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package prometheusx
+
+import (
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MustRegister 注册到默认的 Registry，已经注册过同样的指标就复用原本的，
+// 这样装饰器可以创建多个实例，例如测试里面
+func MustRegister[T prometheus.Collector](c T) T {
+	err := prometheus.Register(c)
+	if err == nil {
+		return c
+	}
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(T); ok {
+			return existing
+		}
+	}
+	panic(err)
+}
@@ -0,0 +1,68 @@
+package redisx
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"net"
+)
+
+const instrumentationName = "webBook/pkg/redisx"
+
+// TracingHook 每个命令一个 client span。key 里面可能有手机号码，所以不记录参数
+type TracingHook struct {
+	tracer trace.Tracer
+}
+
+var _ redis.Hook = &TracingHook{}
+
+func NewTracingHook() *TracingHook {
+	return &TracingHook{
+		tracer: otel.Tracer(instrumentationName),
+	}
+}
+
+func (h *TracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h *TracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := h.tracer.Start(ctx, "redis "+cmd.Name(),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperationName(cmd.Name())))
+		defer span.End()
+		err := next(ctx, cmd)
+		record(span, err)
+		return err
+	}
+}
+
+func (h *TracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := h.tracer.Start(ctx, "redis pipeline",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemRedis,
+				attribute.Int("db.redis.pipeline_length", len(cmds))))
+		defer span.End()
+		err := next(ctx, cmds)
+		record(span, err)
+		return err
+	}
+}
+
+// record redis.Nil 是 key 不存在，不算错误
+func record(span trace.Span, err error) {
+	if err == nil || errors.Is(err, redis.Nil) {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
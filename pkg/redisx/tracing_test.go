@@ -0,0 +1,41 @@
+package redisx
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func TestTracingHook(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	m := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: m.Addr()})
+	client.AddHook(NewTracingHook())
+
+	ctx := context.Background()
+	require.NoError(t, client.Set(ctx, "key", "val", 0).Err())
+	// key 不存在不算错误
+	require.ErrorIs(t, client.Get(ctx, "not-exist").Err(), redis.Nil)
+	_, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Incr(ctx, "cnt")
+		pipe.Expire(ctx, "cnt", 0)
+		return nil
+	})
+	require.NoError(t, err)
+
+	var names []string
+	for _, span := range exporter.GetSpans() {
+		names = append(names, span.Name)
+		assert.Equal(t, codes.Unset, span.Status.Code)
+	}
+	// 第一个命令之前 go-redis 会发 hello
+	assert.Subset(t, names, []string{"redis set", "redis get", "redis pipeline"})
+}
@@ -0,0 +1,37 @@
+// 一次性的迁移脚本，把 users 表里面的手机号码统一成 E.164 格式
+// go run ./script/migrate/phone -dsn "root:root@tcp(localhost:13316)/webook"
+package main
+
+import (
+	"context"
+	"flag"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"log"
+	"webBook/internal/repository/dao"
+	"webBook/pkg/phonex"
+)
+
+func main() {
+	dsn := flag.String("dsn", "root:root@tcp(localhost:13316)/webook", "数据库连接")
+	region := flag.String("region", "CN", "没有国家码的号码，按照哪个地区解析")
+	batchSize := flag.Int("batch", 100, "每批处理多少条")
+	flag.Parse()
+
+	db, err := gorm.Open(mysql.Open(*dsn), &gorm.Config{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	parser := phonex.NewParser(*region)
+	res, err := dao.MigratePhoneToE164(context.Background(), db, *batchSize, parser.Normalize)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("更新 %d 条，无需更新 %d 条", res.Updated, res.Skipped)
+	if len(res.Invalid) > 0 {
+		log.Println("号码不合法，需要人工处理的用户", res.Invalid)
+	}
+	if len(res.Conflicts) > 0 {
+		log.Println("号码冲突，需要人工处理的用户", res.Conflicts)
+	}
+}
@@ -0,0 +1,49 @@
+// 注册接入我们授权服务器的第三方应用，secret 只在这里打印一次，落库的是 bcrypt 之后的
+// go run ./script/oauth2/client -name "示例应用" -redirect "https://app.example.com/callback" -confidential
+package main
+
+import (
+	"context"
+	"flag"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"log"
+	"strings"
+	"webBook/internal/domain"
+	"webBook/internal/repository"
+	"webBook/internal/repository/dao"
+	"webBook/internal/service/oauth2/server"
+)
+
+func main() {
+	dsn := flag.String("dsn", "root:root@tcp(localhost:13316)/webook", "数据库连接")
+	clientId := flag.String("id", "", "client_id，为空就自动生成")
+	name := flag.String("name", "", "应用名字，授权页面上给用户看的")
+	redirect := flag.String("redirect", "", "允许的 redirect_uri，多个用空格分开")
+	scopes := flag.String("scopes", "profile", "允许申请的 scope，多个用空格分开")
+	grants := flag.String("grants", server.GrantAuthorizationCode, "授权方式，多个用空格分开")
+	confidential := flag.Bool("confidential", false, "机密客户端，有后端能保存 secret 的才是")
+	flag.Parse()
+
+	db, err := gorm.Open(mysql.Open(*dsn), &gorm.Config{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	clientRepo := repository.NewOAuth2ClientRepository(dao.NewOAuth2ClientDAO(db))
+	// 注册 client 用不到 token
+	svc := server.NewService(clientRepo, nil, 0, 0)
+	client, secret, err := svc.RegisterClient(context.Background(), domain.OAuth2Client{
+		ClientId:     *clientId,
+		Name:         *name,
+		RedirectURIs: strings.Fields(*redirect),
+		Scopes:       strings.Fields(*scopes),
+		GrantTypes:   strings.Fields(*grants),
+	}, *confidential)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("client_id:", client.ClientId)
+	if secret != "" {
+		log.Println("client_secret:", secret, "只显示这一次，请妥善保存")
+	}
+}
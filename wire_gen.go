@@ -11,9 +11,7 @@ import (
 	"webBook/internal/repository"
 	"webBook/internal/repository/cache"
 	"webBook/internal/repository/dao"
-	"webBook/internal/service"
 	"webBook/internal/web"
-	"webBook/internal/web/jwt"
 	"webBook/ioc"
 )
 
@@ -25,21 +23,48 @@ import (
 
 func InitWebServer() *gin.Engine {
 	cmdable := ioc.InitRedis()
-	handler := jwt.NewRedisJWTHandler(cmdable)
+	handler := ioc.InitJWTHandler(cmdable)
 	loggerV1 := ioc.InitLogger()
-	v := ioc.InitGinMiddlewares(cmdable, handler, loggerV1)
+	gradientLimiter := ioc.InitConcurrencyLimiter()
 	db := ioc.InitDB(loggerV1)
 	userDAO := dao.NewUserDAO(db)
-	userCache := cache.NewUserCache(cmdable)
-	userRepository := repository.NewCachedUserRepository(userDAO, userCache)
-	userService := service.NewUserService(userRepository)
+	userCache := ioc.InitUserCache(cmdable)
+	cipher := ioc.InitTokenCipher()
+	userRepository := repository.NewCachedUserRepository(userDAO, userCache, cipher)
+	userIdentityDAO := dao.NewUserIdentityDAO(db)
+	userIdentityRepository := repository.NewUserIdentityRepository(userIdentityDAO)
+	userService := ioc.InitUserService(userRepository, userIdentityRepository)
+	parser := ioc.InitPhoneParser()
+	v := ioc.InitGinMiddlewares(cmdable, handler, loggerV1, gradientLimiter, userService, parser)
 	codeCache := cache.NewCodeCache(cmdable)
 	codeRepository := repository.NewCodeRepository(codeCache)
-	smsService := ioc.InitSMSService()
-	codeService := service.NewCodeService(codeRepository, smsService)
-	userHandler := web.NewUserHandler(userService, handler, codeService)
-	wechatService := ioc.InitWechatService(loggerV1)
-	oAuth2WechatHandler := web.NewOAuth2WechatHandler(wechatService, handler, userService)
-	engine := ioc.InitWebServer(v, userHandler, oAuth2WechatHandler)
+	smsService := ioc.InitSMSService(parser, cmdable)
+	authSMSService := ioc.InitAuthSMSService(smsService)
+	codeService := ioc.InitCodeService(codeRepository, authSMSService, cmdable)
+	captchaCache := cache.NewCaptchaCache(cmdable)
+	captchaRepository := repository.NewCaptchaRepository(captchaCache)
+	captchaService := ioc.InitCaptchaService(captchaRepository)
+	verifier := ioc.InitCaptchaVerifier(captchaService)
+	userHandler := web.NewUserHandler(userService, handler, codeService, parser, captchaService, verifier)
+	wechatService := ioc.InitWechatService()
+	oAuth2StateCache := cache.NewOAuth2StateCache(cmdable)
+	oAuth2StateRepository := repository.NewOAuth2StateRepository(oAuth2StateCache)
+	stateService := ioc.InitOAuth2StateService(oAuth2StateRepository)
+	stateCookieConfig := ioc.InitStateCookieConfig()
+	oAuth2WechatHandler := web.NewOAuth2WechatHandler(wechatService, handler, userService, stateService, stateCookieConfig)
+	wechatSessionCache := cache.NewWechatSessionCache(cmdable)
+	wechatSessionRepository := repository.NewWechatSessionRepository(wechatSessionCache)
+	miniProgramService := ioc.InitMiniProgramService(wechatSessionRepository)
+	miniProgramHandler := web.NewMiniProgramHandler(miniProgramService, handler, userService, parser)
+	v2 := ioc.InitOAuth2Providers()
+	oAuth2Handler := web.NewOAuth2Handler(v2, handler, userService, stateService, stateCookieConfig)
+	oAuth2ClientDAO := dao.NewOAuth2ClientDAO(db)
+	oAuth2ClientRepository := repository.NewOAuth2ClientRepository(oAuth2ClientDAO)
+	oAuth2TokenCache := cache.NewOAuth2TokenCache(cmdable)
+	oAuth2TokenRepository := repository.NewOAuth2TokenRepository(oAuth2TokenCache)
+	serverService := ioc.InitOAuth2ServerService(oAuth2ClientRepository, oAuth2TokenRepository)
+	oAuth2ServerHandler := web.NewOAuth2ServerHandler(serverService, handler)
+	captchaHandler := web.NewCaptchaHandler(captchaService)
+	engine := ioc.InitWebServer(v, userHandler, oAuth2WechatHandler, miniProgramHandler, oAuth2Handler, oAuth2ServerHandler, captchaHandler, parser)
 	return engine
 }